@@ -0,0 +1,451 @@
+// backend/cli.go
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/database"
+	"github.com/shuttlersit/ads-player/backend/importer"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// command is a subcommand of the backend binary, e.g. "ads pause"
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+// errUsage is returned by commands called with invalid arguments
+var errUsage = errors.New("invalid usage")
+
+// commands returns every subcommand
+func commands() []command {
+	return []command{
+		{"playlists list", "[-o table|json]", "list playlists", runPlaylistsList},
+		{"ads list", "[-playlist ID] [-o table|json]", "list advertisements", runAdsList},
+		{"ads create", "-playlist ID -title TITLE -url URL -duration SECONDS [-scheduled-at RFC3339] [-targeting EXPR] [-exclusion-group GROUP] [-click-through URL] [-o table|json]", "create an advertisement", runAdsCreate},
+		{"ads pause", "ID", "stop an advertisement from being selected", runAdsPause(true)},
+		{"ads resume", "ID", "allow a paused advertisement to be selected again", runAdsPause(false)},
+		{"scheduler run-once", "[-playlist ID]", "run one scheduling pass, for one playlist or all eligible ones", runSchedulerRunOnce},
+		{"events tail", "[-n COUNT] [-f] [-interval DURATION] [-o table|json]", "show recent advertisement play events", runEventsTail},
+		{"stats refresh", "", "recompute the playlist popularity stats", runStatsRefresh},
+		{"counters reconcile", "", "recompute like, dislike and follower counters", runCountersReconcile},
+		{"history rollup", "", "recompute video views and playlist play counts from the watch history", runHistoryRollup},
+		{"related refresh", "", "recompute related videos, playlists and advertisements", runRelatedRefresh},
+		{"search reindex", "", "rebuild the search index", runSearchReindex},
+		{"db migrate", "", "create or update the database tables", runDBMigrate},
+		{"import", "-user ID [-dry-run] [-format csv|json] MANIFEST", "import playlists, videos and advertisements", runImport},
+	}
+}
+
+// runCommand runs a command-line subcommand and returns the process exit code
+func runCommand(args []string) int {
+	for _, cmd := range commands() {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) || strings.Join(args[:len(words)], " ") != cmd.name {
+			continue
+		}
+
+		err := cmd.run(args[len(words):])
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: backend %s %s\n", cmd.name, cmd.usage)
+			return 2
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, "usage: backend [command]")
+	fmt.Fprintln(os.Stderr, "\nWithout a command the HTTP server and scheduler are started. Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", cmd.name, cmd.summary)
+	}
+	return 2
+}
+
+// newFlagSet creates a flag set with the shared -o output flag
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	output := flags.String("o", "table", "output format, table or json")
+	return flags, output
+}
+
+// parseFlags parses command flags, turning flag errors into errUsage
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// printOutput prints value as JSON, or rows as an aligned table under headers
+func printOutput(format string, value interface{}, headers []string, rows [][]string) error {
+	switch format {
+	case "json":
+		encoded, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(encoded))
+		return nil
+	case "table":
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(headers, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		return writer.Flush()
+	default:
+		return errUsage
+	}
+}
+
+// parseID parses a positional ID argument
+func parseID(args []string) (uint, error) {
+	if len(args) != 1 {
+		return 0, errUsage
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, errUsage
+	}
+	return uint(id), nil
+}
+
+// formatTime formats a time for table output
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// runPlaylistsList lists playlists
+func runPlaylistsList(args []string) error {
+	flags, output := newFlagSet("playlists list")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	playlists, err := models.NewPlaylistModel(db).GetAllPlaylists()
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(playlists))
+	for _, playlist := range playlists {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(playlist.ID), 10),
+			playlist.Title,
+			strconv.FormatBool(playlist.IsPublic),
+			strconv.FormatUint(uint64(playlist.PlayCount), 10),
+			formatTime(playlist.LastAdvertisementScheduledAt),
+		})
+	}
+	return printOutput(*output, playlists, []string{"ID", "TITLE", "PUBLIC", "PLAYS", "LAST AD SCHEDULED"}, rows)
+}
+
+// runAdsList lists advertisements, optionally of one playlist
+func runAdsList(args []string) error {
+	flags, output := newFlagSet("ads list")
+	playlistID := flags.Uint("playlist", 0, "only list advertisements of this playlist")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	advertisementModel := models.NewAdvertisementModel(db)
+	var advertisements []models.Advertisement
+	var err error
+	if *playlistID != 0 {
+		advertisements, err = advertisementModel.GetAdvertisementsByPlaylistID(*playlistID)
+	} else {
+		advertisements, err = advertisementModel.GetAllAdvertisements()
+	}
+	if err != nil {
+		return err
+	}
+
+	return printOutput(*output, advertisements, advertisementHeaders, advertisementRows(advertisements))
+}
+
+// runAdsCreate creates an advertisement
+func runAdsCreate(args []string) error {
+	flags, output := newFlagSet("ads create")
+	playlistID := flags.Uint("playlist", 0, "playlist the advertisement plays in")
+	title := flags.String("title", "", "title")
+	contentURL := flags.String("url", "", "content URL")
+	duration := flags.Int("duration", 0, "duration in seconds")
+	scheduledAt := flags.String("scheduled-at", "", "earliest play time, RFC 3339 (default: now)")
+	targeting := flags.String("targeting", "", "targeting expression")
+	exclusionGroup := flags.String("exclusion-group", "", "competitive exclusion group")
+	clickThrough := flags.String("click-through", "", "click-through URL")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *playlistID == 0 || *title == "" || *contentURL == "" || *duration <= 0 {
+		return errUsage
+	}
+
+	advertisementModel := models.NewAdvertisementModel(db)
+	advertisement := models.Advertisement{
+		PlaylistID:      *playlistID,
+		Title:           *title,
+		ContentURL:      *contentURL,
+		Duration:        *duration,
+		ScheduledAt:     advertisementModel.Clock.Now(),
+		Targeting:       *targeting,
+		ExclusionGroup:  *exclusionGroup,
+		ClickThroughURL: *clickThrough,
+		IsPublic:        true,
+	}
+	if *scheduledAt != "" {
+		parsed, err := time.Parse(time.RFC3339, *scheduledAt)
+		if err != nil {
+			return fmt.Errorf("invalid -scheduled-at: %w", err)
+		}
+		advertisement.ScheduledAt = parsed
+	}
+
+	if err := advertisementModel.CreateAdvertisement(&advertisement); err != nil {
+		return err
+	}
+	return printOutput(*output, advertisement, advertisementHeaders, advertisementRows([]models.Advertisement{advertisement}))
+}
+
+// runAdsPause returns a command that pauses or resumes an advertisement
+func runAdsPause(paused bool) func(args []string) error {
+	return func(args []string) error {
+		id, err := parseID(args)
+		if err != nil {
+			return err
+		}
+		if err := models.NewAdvertisementModel(db).SetPaused(id, paused); err != nil {
+			return err
+		}
+
+		state := "resumed"
+		if paused {
+			state = "paused"
+		}
+		fmt.Printf("Advertisement %d %s\n", id, state)
+		return nil
+	}
+}
+
+// runSchedulerRunOnce runs one scheduling pass like the five-minute cron job, without waiting for it
+func runSchedulerRunOnce(args []string) error {
+	flags := flag.NewFlagSet("scheduler run-once", flag.ContinueOnError)
+	playlistID := flags.Uint("playlist", 0, "only schedule this playlist, whether or not it is eligible")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	playlistModel := models.NewPlaylistModel(db)
+	advertisementController := controllers.NewAdvertisementController(playlistModel, models.NewAdvertisementModel(db), &controllers.SimplePlaybackService{})
+	if *playlistID == 0 {
+		return advertisementController.ScheduleEligiblePlaylists()
+	}
+
+	playlist, err := playlistModel.GetPlaylistForSelection(*playlistID)
+	if err != nil {
+		return err
+	}
+	return advertisementController.ScheduleAdvertisementForPlaylist(*playlist)
+}
+
+// runEventsTail prints the latest play events and, with -f, keeps printing new ones
+func runEventsTail(args []string) error {
+	flags, output := newFlagSet("events tail")
+	count := flags.Int("n", 20, "number of recent events to show")
+	follow := flags.Bool("f", false, "keep polling for new events")
+	interval := flags.Duration("interval", 2*time.Second, "polling interval with -f")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	advertisementModel := models.NewAdvertisementModel(db)
+	events, err := advertisementModel.GetLatestPlayEvents(*count)
+	if err != nil {
+		return err
+	}
+
+	var lastID uint
+	for {
+		if len(events) > 0 {
+			if err := printOutput(*output, events, []string{"ID", "TIME", "ADVERTISEMENT", "PLAYLIST"}, playEventRows(events)); err != nil {
+				return err
+			}
+			lastID = events[len(events)-1].ID
+		}
+		if !*follow {
+			return nil
+		}
+
+		time.Sleep(*interval)
+		if events, err = advertisementModel.GetPlayEventsAfter(lastID, 100); err != nil {
+			return err
+		}
+	}
+}
+
+// runStatsRefresh recomputes the playlist popularity stats like the hourly cron job
+func runStatsRefresh(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	if err := models.NewPlaylistModel(db).RefreshPlaylistStats(time.Now()); err != nil {
+		return err
+	}
+	fmt.Println("Playlist stats refreshed")
+	return nil
+}
+
+// runCountersReconcile recomputes the like, dislike and follower counters from the join tables
+func runCountersReconcile(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	if err := models.NewEngagementModel(db).ReconcileEngagementCounters(); err != nil {
+		return err
+	}
+	fmt.Println("Engagement counters reconciled")
+	return nil
+}
+
+// runHistoryRollup recomputes video views and playlist play counts from the watch history
+func runHistoryRollup(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	if err := models.NewWatchHistoryModel(db).RollUpWatchHistory(); err != nil {
+		return err
+	}
+	fmt.Println("Watch history rolled up")
+	return nil
+}
+
+// runRelatedRefresh recomputes the related videos, playlists and advertisements like the daily cron job
+func runRelatedRefresh(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	if err := models.NewRelatedModel(db).RefreshRelatedContent(); err != nil {
+		return err
+	}
+	fmt.Println("Related content refreshed")
+	return nil
+}
+
+// runSearchReindex rebuilds the search index from the content tables
+func runSearchReindex(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	if err := models.SearchEngine.Reindex(); err != nil {
+		return err
+	}
+	fmt.Println("Search index rebuilt")
+	return nil
+}
+
+// runDBMigrate creates or updates the database tables
+func runDBMigrate(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	if err := database.Migrate(db); err != nil {
+		return err
+	}
+	fmt.Println("Database migrated")
+	return nil
+}
+
+// runImport imports a CSV or JSON manifest
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the manifest without writing anything")
+	format := flags.String("format", "", "manifest format, csv or json (default: from the file extension)")
+	userID := flags.Uint("user", 0, "user importing the manifest, who must own its channels and playlists")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *userID == 0 {
+		return errUsage
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	manifest, err := importer.Parse(file, *format)
+	if err != nil {
+		return err
+	}
+
+	result, err := importer.NewImporter(db).Import(manifest, *userID, *dryRun)
+	if result != nil {
+		for _, rowError := range result.Errors {
+			fmt.Fprintln(os.Stderr, rowError.Error())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return printOutput("json", result, nil, nil)
+}
+
+// advertisementHeaders are the table columns of advertisement listings
+var advertisementHeaders = []string{"ID", "PLAYLIST", "TITLE", "DURATION", "SCHEDULED", "PLAYED", "PAUSED"}
+
+// advertisementRows formats advertisements as table rows
+func advertisementRows(advertisements []models.Advertisement) [][]string {
+	rows := make([][]string, 0, len(advertisements))
+	for _, advertisement := range advertisements {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(advertisement.ID), 10),
+			strconv.FormatUint(uint64(advertisement.PlaylistID), 10),
+			advertisement.Title,
+			fmt.Sprintf("%ds", advertisement.Duration),
+			formatTime(advertisement.ScheduledAt),
+			strconv.FormatBool(advertisement.Played),
+			strconv.FormatBool(advertisement.IsPaused),
+		})
+	}
+	return rows
+}
+
+// playEventRows formats play events as table rows
+func playEventRows(events []models.AdvertisementPlayEvent) [][]string {
+	rows := make([][]string, 0, len(events))
+	for _, event := range events {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(event.ID), 10),
+			formatTime(event.PlayTime),
+			strconv.FormatUint(uint64(event.AdvertisementID), 10),
+			strconv.FormatUint(uint64(event.PlaylistID), 10),
+		})
+	}
+	return rows
+}
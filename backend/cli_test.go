@@ -0,0 +1,202 @@
+// backend/cli_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/shuttlersit/ads-player/backend/database"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// useTestDB points the commands at a migrated in-memory SQLite database for the length of the test
+func useTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_foreign_keys=0", strings.ReplaceAll(t.Name(), "/", "_"))
+	testDB, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	sqlDB, err := testDB.DB()
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := database.Migrate(testDB); err != nil {
+		t.Fatalf("migrating database: %v", err)
+	}
+
+	previous := db
+	db = testDB
+	t.Cleanup(func() {
+		db = previous
+		sqlDB.Close()
+	})
+	return testDB
+}
+
+// runCLI runs a subcommand and returns its exit code and standard output. Standard error is discarded.
+func runCLI(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("creating pipe: %v", err)
+	}
+	discard, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("opening %s: %v", os.DevNull, err)
+	}
+	defer discard.Close()
+
+	output := make(chan string)
+	go func() {
+		read, _ := io.ReadAll(reader)
+		output <- string(read)
+	}()
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = writer, discard
+	code := runCommand(args)
+	os.Stdout, os.Stderr = stdout, stderr
+	writer.Close()
+	return code, <-output
+}
+
+func TestRunCommandUsage(t *testing.T) {
+	useTestDB(t)
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown command", []string{"ads", "delete", "1"}},
+		{"missing ID", []string{"ads", "pause"}},
+		{"invalid ID", []string{"ads", "resume", "first"}},
+		{"missing flags", []string{"ads", "create", "-title", "advertisement"}},
+		{"unknown flag", []string{"playlists", "list", "-all"}},
+		{"unknown output format", []string{"playlists", "list", "-o", "yaml"}},
+		{"extra arguments", []string{"db", "migrate", "now"}},
+		{"import without user", []string{"import", "manifest.csv"}},
+	}
+	for _, test := range tests {
+		if code, _ := runCLI(t, test.args...); code != 2 {
+			t.Errorf("%s: exit code %d, want 2", test.name, code)
+		}
+	}
+}
+
+func TestAdsCommands(t *testing.T) {
+	testDB := useTestDB(t)
+	playlist := models.Playlist{Title: "morning mix", IsPublic: true, IsPlayable: true}
+	if err := testDB.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	playlistID := fmt.Sprint(playlist.ID)
+
+	code, output := runCLI(t, "ads", "create", "-playlist", playlistID, "-title", "spring sale", "-url", "https://cdn.example.com/sale.mp4",
+		"-duration", "15", "-scheduled-at", "2026-03-06T12:00:00Z", "-targeting", "country = FR", "-o", "json")
+	if code != 0 {
+		t.Fatalf("ads create: exit code %d", code)
+	}
+	var created models.Advertisement
+	if err := json.Unmarshal([]byte(output), &created); err != nil {
+		t.Fatalf("ads create printed %q: %v", output, err)
+	}
+	var stored models.Advertisement
+	if err := testDB.First(&stored, created.ID).Error; err != nil {
+		t.Fatalf("reading created advertisement: %v", err)
+	}
+	if stored.PlaylistID != playlist.ID || stored.Title != "spring sale" || stored.Duration != 15 || stored.Targeting != "country = FR" ||
+		!stored.ScheduledAt.Equal(time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("ads create stored playlist %d, %q, %ds, %q at %s", stored.PlaylistID, stored.Title, stored.Duration, stored.Targeting, stored.ScheduledAt)
+	}
+	advertisementID := fmt.Sprint(created.ID)
+
+	for _, paused := range []bool{true, false} {
+		command := "resume"
+		if paused {
+			command = "pause"
+		}
+		if code, _ := runCLI(t, "ads", command, advertisementID); code != 0 {
+			t.Fatalf("ads %s: exit code %d", command, code)
+		}
+		var stored models.Advertisement
+		if err := testDB.First(&stored, created.ID).Error; err != nil {
+			t.Fatalf("reading advertisement: %v", err)
+		}
+		if stored.IsPaused != paused {
+			t.Errorf("after ads %s the advertisement is paused = %v", command, stored.IsPaused)
+		}
+	}
+	if code, _ := runCLI(t, "ads", "pause", "999"); code != 1 {
+		t.Errorf("pausing a missing advertisement: exit code %d, want 1", code)
+	}
+
+	code, output = runCLI(t, "ads", "list", "-playlist", playlistID, "-o", "json")
+	var listed []models.Advertisement
+	if err := json.Unmarshal([]byte(output), &listed); code != 0 || err != nil {
+		t.Fatalf("ads list: exit code %d, printed %q: %v", code, output, err)
+	}
+	if len(listed) != 1 || listed[0].ID != created.ID {
+		t.Errorf("ads list printed %d advertisements, want the created one", len(listed))
+	}
+	if code, output = runCLI(t, "ads", "list", "-playlist", fmt.Sprint(playlist.ID+1), "-o", "json"); code != 0 || strings.TrimSpace(output) != "[]" {
+		t.Errorf("ads list of another playlist: exit code %d, printed %q", code, output)
+	}
+
+	code, output = runCLI(t, "playlists", "list")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if code != 0 || len(lines) != 2 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "morning mix") {
+		t.Errorf("playlists list: exit code %d, printed:\n%s", code, output)
+	}
+}
+
+func TestSchedulerAndEventsCommands(t *testing.T) {
+	testDB := useTestDB(t)
+	// The advertisement is not due, so the scheduler has nothing to play
+	playlist := models.Playlist{Title: "playlist", IsPublic: true, IsPlayable: true}
+	if err := testDB.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	advertisement := models.Advertisement{PlaylistID: playlist.ID, Title: "later", Duration: 15, IsPublic: true, ScheduledAt: time.Now().Add(time.Hour)}
+	if err := testDB.Create(&advertisement).Error; err != nil {
+		t.Fatalf("creating advertisement: %v", err)
+	}
+
+	if code, _ := runCLI(t, "scheduler", "run-once", "-playlist", fmt.Sprint(playlist.ID)); code != 0 {
+		t.Errorf("scheduler run-once: exit code %d", code)
+	}
+	if code, _ := runCLI(t, "scheduler", "run-once"); code != 0 {
+		t.Errorf("scheduler run-once without a playlist: exit code %d", code)
+	}
+	if code, _ := runCLI(t, "scheduler", "run-once", "-playlist", fmt.Sprint(playlist.ID+1)); code != 1 {
+		t.Errorf("scheduler run-once of a missing playlist: exit code %d, want 1", code)
+	}
+
+	for i := 0; i < 3; i++ {
+		event := models.AdvertisementPlayEvent{AdvertisementID: advertisement.ID, PlaylistID: playlist.ID, PlayTime: time.Now()}
+		if err := testDB.Create(&event).Error; err != nil {
+			t.Fatalf("creating play event: %v", err)
+		}
+	}
+	code, output := runCLI(t, "events", "tail", "-n", "2", "-o", "json")
+	var events []models.AdvertisementPlayEvent
+	if err := json.Unmarshal([]byte(output), &events); code != 0 || err != nil {
+		t.Fatalf("events tail: exit code %d, printed %q: %v", code, output, err)
+	}
+	if len(events) != 2 || events[0].ID >= events[1].ID {
+		t.Errorf("events tail printed %+v, want the latest 2 events oldest first", events)
+	}
+
+	if code, _ := runCLI(t, "db", "migrate"); code != 0 {
+		t.Errorf("db migrate: exit code %d", code)
+	}
+}
@@ -0,0 +1,145 @@
+// backend/controllers/access_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// AccessTokenHeader carries the access tokens, comma separated, unlocking password protected content.
+// Players that cannot set headers pass a token in the accessToken query parameter instead.
+const AccessTokenHeader = "X-Access-Token"
+
+// AccessController handles passwords and access tokens of playlists and advertisements
+type AccessController struct {
+	AccessModel *models.AccessModel
+}
+
+// NewAccessController creates a new AccessController
+func NewAccessController(db *gorm.DB) *AccessController {
+	return &AccessController{
+		AccessModel: models.NewAccessModel(db),
+	}
+}
+
+// abortWithAccessError maps access model errors to HTTP statuses. Private content is reported as missing.
+func abortWithAccessError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.AbortWithStatus(404)
+	case errors.Is(err, models.ErrPasswordRequired), errors.Is(err, models.ErrWrongPassword),
+		errors.Is(err, models.ErrNotEditor), errors.Is(err, models.ErrEmbeddingNotAllowed):
+		c.AbortWithStatusJSON(403, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrNotPasswordProtected):
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatus(500)
+	}
+}
+
+// requestAccessTokens returns the access tokens of a request, from the X-Access-Token header and accessToken query parameter
+func requestAccessTokens(c *gin.Context) []string {
+	var tokens []string
+	for _, token := range strings.Split(c.GetHeader(AccessTokenHeader), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	if token := c.Query("accessToken"); token != "" {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// requestViewer resolves the signed-in user and access tokens of a request
+func requestViewer(c *gin.Context, accessModel *models.AccessModel) (models.Viewer, bool) {
+	userID, _ := requestUserID(c)
+	viewer, err := accessModel.ResolveViewer(userID, requestAccessTokens(c))
+	if err != nil {
+		abortWithAccessError(c, err)
+		return viewer, false
+	}
+	return viewer, true
+}
+
+// requestVisible resolves the viewer of a request and checks they can read the content
+func requestVisible(c *gin.Context, accessModel *models.AccessModel, contentType string, contentID uint) (models.Viewer, bool) {
+	viewer, ok := requestViewer(c, accessModel)
+	if !ok {
+		return viewer, false
+	}
+	if err := accessModel.CanView(contentType, contentID, viewer); err != nil {
+		abortWithAccessError(c, err)
+		return viewer, false
+	}
+	return viewer, true
+}
+
+// requestEmbedOrigin returns the site embedding the request, empty when it is not embedded
+func requestEmbedOrigin(c *gin.Context) string {
+	return models.EmbedOrigin(c.GetHeader("Origin"), c.GetHeader("Referer"), c.Request.Host)
+}
+
+// Unlock returns a handler exchanging the {"password": "..."} of a playlist or advertisement for an access token
+func (ac *AccessController) Unlock(contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := paramID(c, "id")
+		if !ok {
+			c.AbortWithStatus(400)
+			return
+		}
+		var request struct {
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatus(400)
+			return
+		}
+
+		grant, err := ac.AccessModel.Unlock(contentType, id, request.Password)
+		if err != nil {
+			abortWithAccessError(c, err)
+			return
+		}
+		c.JSON(201, grant)
+	}
+}
+
+// SetPassword returns a handler setting the {"password": "..."} of a playlist or advertisement, or removing
+// it when empty. Only owners can change it, and access tokens handed out before stop working.
+func (ac *AccessController) SetPassword(contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requestUserID(c)
+		if !ok {
+			c.AbortWithStatus(401)
+			return
+		}
+		id, ok := paramID(c, "id")
+		if !ok {
+			c.AbortWithStatus(400)
+			return
+		}
+		var request struct {
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatus(400)
+			return
+		}
+
+		if err := ac.AccessModel.CanManage(contentType, id, userID); err != nil {
+			abortWithAccessError(c, err)
+			return
+		}
+		if err := ac.AccessModel.SetPassword(contentType, id, request.Password); err != nil {
+			abortWithAccessError(c, err)
+			return
+		}
+		c.Status(204)
+	}
+}
@@ -0,0 +1,293 @@
+// backend/controllers/access_controller_test.go
+
+package controllers_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"github.com/shuttlersit/ads-player/backend/vast"
+)
+
+// accessRouter registers the playlist read endpoints along with unlocking and password changes
+func accessRouter(db *gorm.DB) *gin.Engine {
+	playlistController := controllers.NewPlaylistController(db)
+	vastController := controllers.NewVASTController(db)
+	feedController := controllers.NewFeedController(db)
+	streamController := controllers.NewStreamController(db)
+	accessController := controllers.NewAccessController(db)
+
+	router := gin.New()
+	router.GET("/playlists", playlistController.GetPlaylists)
+	router.GET("/playlists/:id", playlistController.GetPlaylistByID)
+	router.GET("/playlists/:id/vast", vastController.GetPlaylistVAST)
+	router.GET("/playlists/:id/feed.json", feedController.GetPlaylistJSON)
+	router.GET("/playlists/:id/stream.m3u8", streamController.GetPlaylistStream)
+	router.POST("/playlists/:id/unlock", accessController.Unlock(models.ContentPlaylist))
+	router.PUT("/playlists/:id/password", accessController.SetPassword(models.ContentPlaylist))
+	return router
+}
+
+// accessRequest sends a request with the given user, access token and Origin headers, each left out when empty
+func accessRequest(router *gin.Engine, method, target, body, userID, token, origin string) *httptest.ResponseRecorder {
+	var request *http.Request
+	if body != "" {
+		request = httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+	} else {
+		request = httptest.NewRequest(method, target, nil)
+	}
+	if userID != "" {
+		request.Header.Set(controllers.UserIDHeader, userID)
+	}
+	if token != "" {
+		request.Header.Set(controllers.AccessTokenHeader, token)
+	}
+	if origin != "" {
+		request.Header.Set("Origin", origin)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// createAccessPlaylist creates a playlist owned by user 7 with one video, made private when public is false
+func createAccessPlaylist(tb testing.TB, db *gorm.DB, title string, public bool, access models.AccessSetting) models.Playlist {
+	tb.Helper()
+	playlist := models.Playlist{Title: title, OwnerID: 7, IsPublic: true, IsPlayable: true, Access: access}
+	if err := db.Create(&playlist).Error; err != nil {
+		tb.Fatalf("creating playlist: %v", err)
+	}
+	if !public {
+		if err := db.Model(&playlist).Update("is_public", false).Error; err != nil {
+			tb.Fatalf("making playlist private: %v", err)
+		}
+	}
+	video := models.Video{Title: title, PlaylistID: playlist.ID, URL: "https://cdn.example.com/" + title + ".mp4", Duration: 60}
+	if err := db.Create(&video).Error; err != nil {
+		tb.Fatalf("creating video: %v", err)
+	}
+	return playlist
+}
+
+func TestPlaylistVisibilityAcrossReadEndpoints(t *testing.T) {
+	db := newTestDB(t)
+	public := createAccessPlaylist(t, db, "public", true, models.AccessSetting{})
+	private := createAccessPlaylist(t, db, "private", false, models.AccessSetting{})
+	protected := createAccessPlaylist(t, db, "protected", true, models.AccessSetting{})
+	accessModel := models.NewAccessModel(db)
+	if err := accessModel.SetPassword(models.ContentPlaylist, protected.ID, "open sesame"); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	grant, err := accessModel.Unlock(models.ContentPlaylist, protected.ID, "open sesame")
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	// User 8 contributes to the private playlist
+	if err := db.Create(&models.UserPlaylistContributors{PlaylistID: private.ID, UserID: 8, InvitedByID: 7}).Error; err != nil {
+		t.Fatalf("adding contributor: %v", err)
+	}
+
+	router := accessRouter(db)
+	viewers := []struct {
+		name          string
+		userID, token string
+		want          map[uint]int // Status of each playlist's endpoints
+		listed        []uint
+	}{
+		{"anonymous", "", "", map[uint]int{public.ID: 200, private.ID: 404, protected.ID: 403}, []uint{public.ID}},
+		{"stranger", "9", "", map[uint]int{public.ID: 200, private.ID: 404, protected.ID: 403}, []uint{public.ID}},
+		{"owner", "7", "", map[uint]int{public.ID: 200, private.ID: 200, protected.ID: 200}, []uint{public.ID, private.ID, protected.ID}},
+		{"contributor", "8", "", map[uint]int{public.ID: 200, private.ID: 200, protected.ID: 403}, []uint{public.ID, private.ID}},
+		{"token holder", "", grant.Token, map[uint]int{public.ID: 200, private.ID: 404, protected.ID: 200}, []uint{public.ID, protected.ID}},
+	}
+	for _, viewer := range viewers {
+		for id, want := range viewer.want {
+			for _, endpoint := range []string{"", "/vast", "/feed.json", "/stream.m3u8"} {
+				target := fmt.Sprintf("/playlists/%d%s", id, endpoint)
+				if got := accessRequest(router, http.MethodGet, target, "", viewer.userID, viewer.token, "").Code; got != want {
+					t.Errorf("%s: %s got status %d, want %d", viewer.name, target, got, want)
+				}
+			}
+		}
+
+		recorder := accessRequest(router, http.MethodGet, "/playlists", "", viewer.userID, viewer.token, "")
+		var listed []models.Playlist
+		if err := json.Unmarshal(recorder.Body.Bytes(), &listed); err != nil {
+			t.Fatalf("%s: parsing playlists: %v", viewer.name, err)
+		}
+		ids := []uint{}
+		for _, playlist := range listed {
+			ids = append(ids, playlist.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(viewer.listed) {
+			t.Errorf("%s: listed playlists %v, want %v", viewer.name, ids, viewer.listed)
+		}
+	}
+
+	// Players that cannot set headers pass the token in the query
+	target := fmt.Sprintf("/playlists/%d/stream.m3u8?accessToken=%s", protected.ID, grant.Token)
+	if got := accessRequest(router, http.MethodGet, target, "", "", "", "").Code; got != 200 {
+		t.Errorf("token in the query got status %d, want 200", got)
+	}
+}
+
+func TestUnlockAccessTokens(t *testing.T) {
+	db := newTestDB(t)
+	public := createAccessPlaylist(t, db, "public", true, models.AccessSetting{})
+	protected := createAccessPlaylist(t, db, "protected", true, models.AccessSetting{})
+	router := accessRouter(db)
+	setPassword := func(userID, password string) int {
+		return accessRequest(router, http.MethodPut, fmt.Sprintf("/playlists/%d/password", protected.ID),
+			fmt.Sprintf(`{"password": %q}`, password), userID, "", "").Code
+	}
+	unlock := func(id uint, password string) (int, string) {
+		recorder := accessRequest(router, http.MethodPost, fmt.Sprintf("/playlists/%d/unlock", id),
+			fmt.Sprintf(`{"password": %q}`, password), "", "", "")
+		var grant models.AccessGrant
+		json.Unmarshal(recorder.Body.Bytes(), &grant)
+		return recorder.Code, grant.Token
+	}
+	read := func(token string) int {
+		return accessRequest(router, http.MethodGet, fmt.Sprintf("/playlists/%d", protected.ID), "", "", token, "").Code
+	}
+
+	for _, test := range []struct {
+		name   string
+		userID string
+		want   int
+	}{{"anonymous", "", 401}, {"stranger", "9", 403}, {"owner", "7", 204}} {
+		if got := setPassword(test.userID, "open sesame"); got != test.want {
+			t.Errorf("setting the password as %s got status %d, want %d", test.name, got, test.want)
+		}
+	}
+
+	unlocks := []struct {
+		name     string
+		id       uint
+		password string
+		want     int
+	}{
+		{"wrong password", protected.ID, "open barley", 403},
+		{"without a password", public.ID, "open sesame", 400},
+		{"missing playlist", protected.ID + 100, "open sesame", 404},
+		{"right password", protected.ID, "open sesame", 201},
+	}
+	var token string
+	for _, test := range unlocks {
+		got, granted := unlock(test.id, test.password)
+		if got != test.want {
+			t.Errorf("unlocking with the %s got status %d, want %d", test.name, got, test.want)
+		}
+		if got == 201 {
+			token = granted
+		}
+	}
+	if token == "" {
+		t.Fatal("unlocking handed out no access token")
+	}
+	for _, test := range []struct {
+		name  string
+		token string
+		want  int
+	}{{"no token", "", 403}, {"unknown token", "not-a-token", 403}, {"access token", token, 200}, {"among other tokens", "other, " + token, 200}} {
+		if got := read(test.token); got != test.want {
+			t.Errorf("reading with %s got status %d, want %d", test.name, got, test.want)
+		}
+	}
+
+	// Expired tokens and those handed out for a previous password stop working
+	if err := db.Model(&models.AccessGrant{}).Where("token = ?", token).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expiring token: %v", err)
+	}
+	if got := read(token); got != 403 {
+		t.Errorf("reading with an expired token got status %d, want 403", got)
+	}
+	_, token = unlock(protected.ID, "open sesame")
+	if got := setPassword("7", "new password"); got != 204 {
+		t.Fatalf("changing the password got status %d, want 204", got)
+	}
+	if got := read(token); got != 403 {
+		t.Errorf("reading with a token for the previous password got status %d, want 403", got)
+	}
+	if got := setPassword("7", ""); got != 204 {
+		t.Fatalf("removing the password got status %d, want 204", got)
+	}
+	if got := read(""); got != 200 {
+		t.Errorf("reading once the password is removed got status %d, want 200", got)
+	}
+}
+
+func TestEmbeddingFollowsOrigin(t *testing.T) {
+	db := newTestDB(t)
+	restricted := createAccessPlaylist(t, db, "restricted", true, models.AccessSetting{AllowEmbedding: true, EmbedDomains: []string{"example.com"}})
+	unembeddable := createAccessPlaylist(t, db, "unembeddable", true, models.AccessSetting{})
+	if err := db.Model(&unembeddable).Update("allow_embedding", false).Error; err != nil {
+		t.Fatalf("disallowing embedding: %v", err)
+	}
+
+	router := accessRouter(db)
+	origins := []struct {
+		name     string
+		playlist models.Playlist
+		origin   string
+		want     int
+	}{
+		{"allowed site", restricted, "https://example.com", 200},
+		{"allowed subdomain", restricted, "https://player.example.com:8443", 200},
+		{"lookalike site", restricted, "https://notexample.com", 403},
+		{"other site", restricted, "https://evil.test", 403},
+		{"same site", unembeddable, "http://example.com", 200},
+		{"not embedded", unembeddable, "", 200},
+		{"embedded", unembeddable, "https://player.example.com", 403},
+	}
+	for _, test := range origins {
+		for _, endpoint := range []string{"/vast", "/feed.json"} {
+			// The requests are served for the example.com host
+			target := fmt.Sprintf("http://example.com/playlists/%d%s", test.playlist.ID, endpoint)
+			if got := accessRequest(router, http.MethodGet, target, "", "", "", test.origin).Code; got != test.want {
+				t.Errorf("%s: %s got status %d, want %d", test.name, endpoint, got, test.want)
+			}
+		}
+	}
+
+	// Embedded players only get the advertisements that allow embedding too
+	now := time.Now()
+	open := models.Advertisement{PlaylistID: restricted.ID, Title: "open", ContentURL: "https://cdn.example.com/open.mp4",
+		Duration: 15, IsPublic: true, ScheduledAt: now.Add(-time.Hour)}
+	closed := models.Advertisement{PlaylistID: restricted.ID, Title: "closed", ContentURL: "https://cdn.example.com/closed.mp4",
+		Duration: 15, IsPublic: true, ScheduledAt: now.Add(-time.Hour)}
+	for _, advertisement := range []*models.Advertisement{&open, &closed} {
+		if err := db.Create(advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+	}
+	if err := db.Model(&closed).Update("allow_embedding", false).Error; err != nil {
+		t.Fatalf("disallowing embedding: %v", err)
+	}
+	for _, test := range []struct {
+		name   string
+		origin string
+		want   int
+	}{{"embedded", "https://player.example.com", 1}, {"not embedded", "", 2}} {
+		recorder := accessRequest(router, http.MethodGet, fmt.Sprintf("/playlists/%d/vast", restricted.ID), "", "", "", test.origin)
+		var document vast.VAST
+		if err := xml.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+			t.Fatalf("%s: parsing VAST: %v", test.name, err)
+		}
+		if len(document.Ads) != test.want {
+			t.Errorf("%s: served %d ads, want %d", test.name, len(document.Ads), test.want)
+		}
+	}
+}
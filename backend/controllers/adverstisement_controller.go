@@ -4,7 +4,6 @@ package controllers
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/shuttlersit/ads-player/backend/models"
@@ -31,19 +30,8 @@ func (ac *AdvertisementController) ScheduleAdvertisements() error {
 	c := cron.New()
 
 	_, err := c.AddFunc("*/5 * * * *", func() {
-		// Get playlists that are eligible for advertisements
-		playlists, err := ac.PlaylistModel.GetPlaylistsForAdvertisements()
-		if err != nil {
+		if err := ac.ScheduleEligiblePlaylists(); err != nil {
 			fmt.Println("Error fetching playlists for advertisements:", err)
-			return
-		}
-
-		// Loop through playlists and schedule advertisements
-		for _, playlist := range playlists {
-			err := ac.ScheduleAdvertisementForPlaylist(playlist)
-			if err != nil {
-				fmt.Printf("Error scheduling advertisement for playlist %d: %v\n", playlist.ID, err)
-			}
 		}
 	})
 	if err != nil {
@@ -56,24 +44,55 @@ func (ac *AdvertisementController) ScheduleAdvertisements() error {
 	return nil
 }
 
-// ScheduleAdvertisementForPlaylist schedules an advertisement for a specific playlist
+// ScheduleEligiblePlaylists schedules advertisements for every playlist eligible for them.
+// Errors for individual playlists are logged so one bad playlist does not stop the others.
+func (ac *AdvertisementController) ScheduleEligiblePlaylists() error {
+	// Get playlists that are eligible for advertisements
+	playlists, err := ac.PlaylistModel.GetPlaylistsForAdvertisements(ac.AdvertisementModel.Clock.Now())
+	if err != nil {
+		return err
+	}
+
+	// Loop through playlists and schedule advertisements
+	for _, playlist := range playlists {
+		err := ac.ScheduleAdvertisementForPlaylist(playlist)
+		if err != nil {
+			fmt.Printf("Error scheduling advertisement for playlist %d: %v\n", playlist.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// ScheduleAdvertisementForPlaylist fills an ad break for a specific playlist and plays it
 func (ac *AdvertisementController) ScheduleAdvertisementForPlaylist(playlist models.Playlist) error {
-	// Get the next advertisement to play for the playlist
-	advertisement, err := ac.AdvertisementModel.GetNextAdvertisementForPlaylist(playlist.ID)
+	// Reload the playlist with the channel details used for targeting
+	selectionPlaylist, err := ac.PlaylistModel.GetPlaylistForSelection(playlist.ID)
+	if err != nil {
+		return err
+	}
+
+	// Build the ad pod to play for the playlist
+	pod, err := ac.AdvertisementModel.GetAdPodForPlaylist(models.SelectionContext{
+		Playlist: selectionPlaylist,
+		Time:     ac.AdvertisementModel.Clock.Now(),
+	})
 	if err != nil {
 		return err
 	}
 
 	// Check if there is an advertisement to play
-	if advertisement != nil {
-		// Perform the logic to play the advertisement
-		err := ac.PlayAdvertisement(advertisement, playlist)
-		if err != nil {
-			return err
+	if len(pod.Advertisements) > 0 {
+		// Play the advertisements of the pod in order
+		for i := range pod.Advertisements {
+			err := ac.PlayAdvertisement(&pod.Advertisements[i], playlist)
+			if err != nil {
+				return err
+			}
 		}
 
 		// Update the last scheduled time for the playlist
-		err = ac.PlaylistModel.UpdateLastScheduledTime(playlist.ID, time.Now())
+		err = ac.PlaylistModel.UpdateLastScheduledTime(playlist.ID, ac.AdvertisementModel.Clock.Now())
 		if err != nil {
 			return err
 		}
@@ -134,7 +153,7 @@ func (ac *AdvertisementController) LogAdvertisementPlayEvent(advertisementID, pl
 	playEvent := models.AdvertisementPlayEvent{
 		AdvertisementID: advertisementID,
 		PlaylistID:      playlistID,
-		PlayTime:        time.Now(),
+		PlayTime:        ac.AdvertisementModel.Clock.Now(),
 	}
 
 	err := ac.AdvertisementModel.LogAdvertisementPlayEvent(playEvent.AdvertisementID, playEvent.PlaylistID)
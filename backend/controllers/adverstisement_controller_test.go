@@ -0,0 +1,115 @@
+// backend/controllers/advertisement_controller_test.go
+
+package controllers_test
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// daypartCase is an advertisement restricted to a daypart on a channel in a timezone, checked at an instant
+type daypartCase struct {
+	name     string
+	timezone string
+	rule     models.DaypartRule
+	now      time.Time // Time of the clock
+	want     bool      // Whether the advertisement plays
+}
+
+// daypartCases cover overnight windows, day of week rollover, DST transitions and per-channel timezones
+var daypartCases = []daypartCase{
+	// 2026-03-06 is a Friday
+	{"overnight window after midnight", "UTC",
+		models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, time.Date(2026, 3, 7, 1, 30, 0, 0, time.UTC), true},
+	{"overnight window over", "UTC",
+		models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, time.Date(2026, 3, 7, 2, 30, 0, 0, time.UTC), false},
+	{"saturday night rolls over to sunday", "UTC",
+		models.DaypartRule{DayOfWeek: time.Saturday, StartTime: "22:00", EndTime: "02:00"}, time.Date(2026, 3, 8, 0, 30, 0, 0, time.UTC), true},
+	{"channel timezone moves the day", "Asia/Tokyo",
+		// Friday 20:30 UTC is Saturday 05:30 in Tokyo
+		models.DaypartRule{DayOfWeek: time.Saturday, StartTime: "05:00", EndTime: "06:00"}, time.Date(2026, 3, 6, 20, 30, 0, 0, time.UTC), true},
+	{"utc day does not apply in channel timezone", "Asia/Tokyo",
+		models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "21:00"}, time.Date(2026, 3, 6, 20, 30, 0, 0, time.UTC), false},
+	// New York springs forward at 02:00 on Sunday 2026-03-08 and falls back at 02:00 on Sunday 2026-11-01
+	{"after spring forward", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "03:00", EndTime: "04:00"}, time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC), true},
+	{"skipped hour of spring forward", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "02:00", EndTime: "03:00"}, time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), false},
+	{"second 01:30 of fall back", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "01:00", EndTime: "02:00"}, time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC), true},
+	{"after fall back", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "01:00", EndTime: "02:00"}, time.Date(2026, 11, 1, 7, 30, 0, 0, time.UTC), false},
+}
+
+// createDaypartPlaylist stores a channel in a timezone with a playlist holding one advertisement
+// restricted to a daypart, due since the day before the case's time
+func createDaypartPlaylist(tb testing.TB, db *gorm.DB, test daypartCase) (models.Playlist, models.Advertisement) {
+	tb.Helper()
+	channel := models.Channel{Name: test.name, Timezone: test.timezone}
+	if err := db.Create(&channel).Error; err != nil {
+		tb.Fatalf("creating channel: %v", err)
+	}
+	playlist := models.Playlist{Title: test.name, ChannelID: channel.ID, IsPublic: true, IsPlayable: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		tb.Fatalf("creating playlist: %v", err)
+	}
+	advertisement := models.Advertisement{
+		PlaylistID:  playlist.ID,
+		Title:       test.name,
+		ContentURL:  "https://cdn.example.com/ad.mp4",
+		Duration:    30,
+		IsPublic:    true,
+		ScheduledAt: test.now.Add(-24 * time.Hour),
+		Dayparts:    []models.DaypartRule{test.rule},
+	}
+	if err := db.Create(&advertisement).Error; err != nil {
+		tb.Fatalf("creating advertisement: %v", err)
+	}
+	return playlist, advertisement
+}
+
+// recordingPlaybackService records the advertisements it plays
+type recordingPlaybackService struct {
+	played []uint
+}
+
+// Play implements PlaybackService
+func (s *recordingPlaybackService) Play(advertisement *models.Advertisement) error {
+	s.played = append(s.played, advertisement.ID)
+	return nil
+}
+
+func TestScheduleAdvertisementForPlaylistDayparts(t *testing.T) {
+	for _, test := range daypartCases {
+		t.Run(test.name, func(t *testing.T) {
+			db := newTestDB(t)
+			playlist, advertisement := createDaypartPlaylist(t, db, test)
+
+			advertisementModel := models.NewAdvertisementModel(db)
+			advertisementModel.Clock = models.ClockFunc(func() time.Time { return test.now })
+			playback := &recordingPlaybackService{}
+			controller := controllers.NewAdvertisementController(models.NewPlaylistModel(db), advertisementModel, playback)
+			if err := controller.ScheduleAdvertisementForPlaylist(playlist); err != nil {
+				t.Fatalf("ScheduleAdvertisementForPlaylist: %v", err)
+			}
+
+			played := len(playback.played) == 1 && playback.played[0] == advertisement.ID
+			if played != test.want || (!test.want && len(playback.played) > 0) {
+				t.Fatalf("played %v, want advertisement %d played: %v", playback.played, advertisement.ID, test.want)
+			}
+
+			var stored models.Advertisement
+			if err := db.First(&stored, advertisement.ID).Error; err != nil {
+				t.Fatalf("reading advertisement: %v", err)
+			}
+			if stored.Played != test.want {
+				t.Errorf("advertisement played flag is %v, want %v", stored.Played, test.want)
+			}
+		})
+	}
+}
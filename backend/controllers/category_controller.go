@@ -0,0 +1,237 @@
+// backend/controllers/category_controller.go
+
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// CategoryController handles the category taxonomy and categorizing channels and advertisements
+type CategoryController struct {
+	CategoryModel *models.CategoryModel
+	AccessModel   *models.AccessModel
+}
+
+// NewCategoryController creates a new CategoryController
+func NewCategoryController(db *gorm.DB) *CategoryController {
+	return &CategoryController{
+		CategoryModel: models.NewCategoryModel(db),
+		AccessModel:   models.NewAccessModel(db),
+	}
+}
+
+// categoryRequest is the body of category create and update requests
+type categoryRequest struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"` // Derived from the name when empty
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parentId"`
+}
+
+// abortWithCategoryError maps category model errors to HTTP statuses
+func abortWithCategoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.AbortWithStatus(404)
+	case errors.Is(err, models.ErrInvalidCategory), errors.Is(err, models.ErrCategoryCycle):
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrCategorySlugTaken), errors.Is(err, models.ErrCategoryHasChildren):
+		c.AbortWithStatusJSON(409, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatus(500)
+	}
+}
+
+// GetCategories returns the category tree
+func (cc *CategoryController) GetCategories(c *gin.Context) {
+	tree, err := cc.CategoryModel.GetCategoryTree()
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.JSON(200, tree)
+}
+
+// GetCategory returns a category, by ID or slug, with its direct subcategories
+func (cc *CategoryController) GetCategory(c *gin.Context) {
+	category, err := cc.CategoryModel.GetCategory(c.Params.ByName("id"))
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.JSON(200, category)
+}
+
+// CreateCategory creates a category
+func (cc *CategoryController) CreateCategory(c *gin.Context) {
+	var request categoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	category := models.Category{
+		Name:        request.Name,
+		Slug:        request.Slug,
+		Description: request.Description,
+		ParentID:    request.ParentID,
+	}
+	if err := cc.CategoryModel.CreateCategory(&category); err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.JSON(201, category)
+}
+
+// UpdateCategory replaces the name, slug, description and parent of a category
+func (cc *CategoryController) UpdateCategory(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	var request categoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	category := models.Category{
+		Name:        request.Name,
+		Slug:        request.Slug,
+		Description: request.Description,
+		ParentID:    request.ParentID,
+	}
+	category.ID = id
+	if err := cc.CategoryModel.UpdateCategory(&category); err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.JSON(200, category)
+}
+
+// DeleteCategory deletes a category without subcategories
+func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	if err := cc.CategoryModel.DeleteCategory(id); err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// categoryListing resolves the category, viewer and page of a listing request
+func (cc *CategoryController) categoryListing(c *gin.Context) (categoryID uint, viewer models.Viewer, page, pageSize int, ok bool) {
+	category, err := cc.CategoryModel.GetCategory(c.Params.ByName("id"))
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return 0, viewer, 0, 0, false
+	}
+	if viewer, ok = requestViewer(c, cc.AccessModel); !ok {
+		return 0, viewer, 0, 0, false
+	}
+	page, pageSize = requestPage(c)
+	return category.ID, viewer, page, pageSize, true
+}
+
+// GetCategoryVideos lists a page of the videos the viewer can read in a category or its subcategories
+func (cc *CategoryController) GetCategoryVideos(c *gin.Context) {
+	categoryID, viewer, page, pageSize, ok := cc.categoryListing(c)
+	if !ok {
+		return
+	}
+	videos, total, err := cc.CategoryModel.GetCategoryVideos(categoryID, viewer, (page-1)*pageSize, pageSize)
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.JSON(200, Page{Items: videos, Page: page, PageSize: pageSize, Total: total})
+}
+
+// GetCategoryPlaylists lists a page of the playlists the viewer can read with videos in a category or its subcategories,
+// or belonging to a channel in them
+func (cc *CategoryController) GetCategoryPlaylists(c *gin.Context) {
+	categoryID, viewer, page, pageSize, ok := cc.categoryListing(c)
+	if !ok {
+		return
+	}
+	playlists, total, err := cc.CategoryModel.GetCategoryPlaylists(categoryID, viewer, (page-1)*pageSize, pageSize)
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.JSON(200, Page{Items: playlists, Page: page, PageSize: pageSize, Total: total})
+}
+
+// GetCategoryAdvertisements lists a page of the advertisements the viewer can read in a category or its subcategories
+func (cc *CategoryController) GetCategoryAdvertisements(c *gin.Context) {
+	categoryID, viewer, page, pageSize, ok := cc.categoryListing(c)
+	if !ok {
+		return
+	}
+	advertisements, total, err := cc.CategoryModel.GetCategoryAdvertisements(categoryID, viewer, (page-1)*pageSize, pageSize)
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.JSON(200, Page{Items: advertisements, Page: page, PageSize: pageSize, Total: total})
+}
+
+// SetChannelCategories replaces the categories of a channel, with a {"categoryIds": [1, 2]} body
+func (cc *CategoryController) SetChannelCategories(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	var request struct {
+		CategoryIDs []uint `json:"categoryIds"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	channel, err := cc.CategoryModel.SetChannelCategories(id, request.CategoryIDs)
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.JSON(200, channel)
+}
+
+// SetAdvertisementCategories sets the category of an advertisement and where it may be placed, with a
+// {"categoryId": 1, "includeCategoryIds": [2], "excludeCategoryIds": [3]} body. An advertisement with
+// included categories only plays in placements in them, and never in excluded ones.
+func (cc *CategoryController) SetAdvertisementCategories(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	var request struct {
+		CategoryID         uint   `json:"categoryId"`
+		IncludeCategoryIDs []uint `json:"includeCategoryIds"`
+		ExcludeCategoryIDs []uint `json:"excludeCategoryIds"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	advertisement, err := cc.CategoryModel.SetAdvertisementCategories(id, request.CategoryID, request.IncludeCategoryIDs, request.ExcludeCategoryIDs)
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+	c.JSON(200, advertisement)
+}
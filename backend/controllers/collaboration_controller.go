@@ -0,0 +1,292 @@
+// backend/controllers/collaboration_controller.go
+
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// CollaborationController handles playlist contributors, their edits of the videos and the edit log
+type CollaborationController struct {
+	ContributorModel  *models.ContributorModel
+	PlaylistEditModel *models.PlaylistEditModel
+	AccessModel       *models.AccessModel
+}
+
+// NewCollaborationController creates a new CollaborationController
+func NewCollaborationController(db *gorm.DB) *CollaborationController {
+	return &CollaborationController{
+		ContributorModel:  models.NewContributorModel(db),
+		PlaylistEditModel: models.NewPlaylistEditModel(db),
+		AccessModel:       models.NewAccessModel(db),
+	}
+}
+
+// videoRequest is the body of requests adding a video to a playlist
+type videoRequest struct {
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	URL          string   `json:"url"`
+	ThumbnailURL string   `json:"thumbnailUrl"`
+	Duration     int      `json:"duration"`
+	Tags         []string `json:"tags"`
+	Language     string   `json:"language"`
+}
+
+// abortWithCollaborationError maps contributor and edit log errors to HTTP statuses
+func abortWithCollaborationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidContributor), errors.Is(err, models.ErrInvalidOrder):
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrAlreadyContributor), errors.Is(err, models.ErrAlreadyRolledBack),
+		errors.Is(err, models.ErrEditNotRevertible):
+		c.AbortWithStatusJSON(409, gin.H{"error": err.Error()})
+	default:
+		abortWithAccessError(c, err)
+	}
+}
+
+// playlistRequest returns the signed-in user and the playlist of a request, aborting it unless the user
+// owns the playlist, or may edit it when owning is not required
+func (cc *CollaborationController) playlistRequest(c *gin.Context, owner bool) (userID, playlistID uint, ok bool) {
+	userID, ok = requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return 0, 0, false
+	}
+	playlistID, ok = paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return 0, 0, false
+	}
+	check := cc.AccessModel.CanEdit
+	if owner {
+		check = cc.AccessModel.CanManage
+	}
+	if err := check(models.ContentPlaylist, playlistID, userID); err != nil {
+		abortWithAccessError(c, err)
+		return 0, 0, false
+	}
+	return userID, playlistID, true
+}
+
+// GetContributors lists the contributors of a playlist the viewer can read. Owners also see the pending invitations.
+func (cc *CollaborationController) GetContributors(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	viewer, ok := requestVisible(c, cc.AccessModel, models.ContentPlaylist, id)
+	if !ok {
+		return
+	}
+
+	owner := viewer.UserID != 0 && cc.AccessModel.CanManage(models.ContentPlaylist, id, viewer.UserID) == nil
+	contributors, err := cc.ContributorModel.GetContributors(id, owner)
+	if err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.JSON(200, contributors)
+}
+
+// InviteContributor invites a user to contribute to the signed-in user's playlist, with a {"userId": 2} body
+func (cc *CollaborationController) InviteContributor(c *gin.Context) {
+	userID, playlistID, ok := cc.playlistRequest(c, true)
+	if !ok {
+		return
+	}
+	var request struct {
+		UserID uint `json:"userId"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil || request.UserID == 0 {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	invitation, err := cc.ContributorModel.InviteContributor(playlistID, request.UserID, userID)
+	if err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.JSON(201, invitation)
+}
+
+// AcceptInvitation makes the signed-in user a contributor of the playlist they were invited to
+func (cc *CollaborationController) AcceptInvitation(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	playlistID, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	contributor, err := cc.ContributorModel.AcceptInvitation(playlistID, userID)
+	if err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.JSON(200, contributor)
+}
+
+// RemoveContributor removes a contributor, or withdraws an invitation. Owners can remove anyone,
+// other users only themselves, to leave a playlist or decline an invitation.
+func (cc *CollaborationController) RemoveContributor(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	playlistID, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	contributorID, ok := paramID(c, "userId")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	if contributorID != userID {
+		if err := cc.AccessModel.CanManage(models.ContentPlaylist, playlistID, userID); err != nil {
+			abortWithAccessError(c, err)
+			return
+		}
+	}
+
+	if err := cc.ContributorModel.RemoveContributor(playlistID, contributorID); err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// GetInvitations lists the signed-in user's pending playlist invitations
+func (cc *CollaborationController) GetInvitations(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+
+	invitations, err := cc.ContributorModel.GetInvitations(userID)
+	if err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.JSON(200, invitations)
+}
+
+// AddVideo adds a video at the end of a playlist the signed-in user can edit
+func (cc *CollaborationController) AddVideo(c *gin.Context) {
+	userID, playlistID, ok := cc.playlistRequest(c, false)
+	if !ok {
+		return
+	}
+	var request videoRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.URL == "" || request.Duration < 0 {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	video := models.Video{
+		Title:        request.Title,
+		Description:  request.Description,
+		URL:          request.URL,
+		ThumbnailURL: request.ThumbnailURL,
+		Duration:     request.Duration,
+		Tags:         request.Tags,
+		Language:     request.Language,
+	}
+	if err := cc.PlaylistEditModel.AddVideo(playlistID, userID, &video); err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.JSON(201, video)
+}
+
+// RemoveVideo removes a video from a playlist the signed-in user can edit
+func (cc *CollaborationController) RemoveVideo(c *gin.Context) {
+	userID, playlistID, ok := cc.playlistRequest(c, false)
+	if !ok {
+		return
+	}
+	videoID, ok := paramID(c, "videoId")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	if err := cc.PlaylistEditModel.RemoveVideo(playlistID, videoID, userID); err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// ReorderVideos sets the playback order of a playlist the signed-in user can edit,
+// with a {"videoIds": [3, 1, 2]} body listing every video of the playlist
+func (cc *CollaborationController) ReorderVideos(c *gin.Context) {
+	userID, playlistID, ok := cc.playlistRequest(c, false)
+	if !ok {
+		return
+	}
+	var request struct {
+		VideoIDs []uint `json:"videoIds"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	if err := cc.PlaylistEditModel.ReorderVideos(playlistID, userID, request.VideoIDs); err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// GetEdits lists a page of the edit log of a playlist the signed-in user can edit, newest first
+func (cc *CollaborationController) GetEdits(c *gin.Context) {
+	_, playlistID, ok := cc.playlistRequest(c, false)
+	if !ok {
+		return
+	}
+
+	page, pageSize := requestPage(c)
+	edits, total, err := cc.PlaylistEditModel.GetEdits(playlistID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.JSON(200, Page{Items: edits, Page: page, PageSize: pageSize, Total: total})
+}
+
+// RollBackEdit undoes an edit of the signed-in user's playlist and returns the edit recording the rollback
+func (cc *CollaborationController) RollBackEdit(c *gin.Context) {
+	userID, playlistID, ok := cc.playlistRequest(c, true)
+	if !ok {
+		return
+	}
+	editID, ok := paramID(c, "editId")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	rollback, err := cc.PlaylistEditModel.RollBackEdit(playlistID, editID, userID)
+	if err != nil {
+		abortWithCollaborationError(c, err)
+		return
+	}
+	c.JSON(201, rollback)
+}
@@ -0,0 +1,173 @@
+// backend/controllers/collaboration_controller_test.go
+
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// collaborationStep is a request to the collaboration endpoints of a playlist and its expected status
+type collaborationStep struct {
+	name         string
+	userID       uint // 0 for anonymous requests
+	method, path string
+	body         string
+	want         int
+}
+
+func TestPlaylistCollaboration(t *testing.T) {
+	db := newTestDB(t)
+	users := make([]models.User, 3)
+	for i := range users {
+		users[i] = models.User{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i)}
+		if err := db.Create(&users[i]).Error; err != nil {
+			t.Fatalf("creating user: %v", err)
+		}
+	}
+	owner, contributor, stranger := users[0].ID, users[1].ID, users[2].ID
+	playlist := models.Playlist{Title: "playlist", OwnerID: owner, IsPublic: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+
+	collaborationController := controllers.NewCollaborationController(db)
+	router := gin.New()
+	router.GET("/playlists/:id/contributors", collaborationController.GetContributors)
+	router.POST("/playlists/:id/contributors", collaborationController.InviteContributor)
+	router.POST("/playlists/:id/contributors/accept", collaborationController.AcceptInvitation)
+	router.DELETE("/playlists/:id/contributors/:userId", collaborationController.RemoveContributor)
+	router.POST("/playlists/:id/videos", collaborationController.AddVideo)
+	router.DELETE("/playlists/:id/videos/:videoId", collaborationController.RemoveVideo)
+	router.PUT("/playlists/:id/videos/order", collaborationController.ReorderVideos)
+	router.GET("/playlists/:id/edits", collaborationController.GetEdits)
+	router.POST("/playlists/:id/edits/:editId/rollback", collaborationController.RollBackEdit)
+	router.GET("/me/invitations", collaborationController.GetInvitations)
+
+	user := func(id uint) string {
+		if id == 0 {
+			return ""
+		}
+		return strconv.FormatUint(uint64(id), 10)
+	}
+	base := fmt.Sprintf("/playlists/%d", playlist.ID)
+	run := func(steps []collaborationStep) {
+		t.Helper()
+		for _, step := range steps {
+			if got := accessRequest(router, step.method, base+step.path, step.body, user(step.userID), "", "").Code; got != step.want {
+				t.Errorf("%s got status %d, want %d", step.name, got, step.want)
+			}
+		}
+	}
+	run([]collaborationStep{
+		{"anonymous invites", 0, http.MethodPost, "/contributors", fmt.Sprintf(`{"userId": %d}`, contributor), 401},
+		{"stranger invites", stranger, http.MethodPost, "/contributors", fmt.Sprintf(`{"userId": %d}`, contributor), 403},
+		{"owner invites themselves", owner, http.MethodPost, "/contributors", fmt.Sprintf(`{"userId": %d}`, owner), 400},
+		{"owner invites a missing user", owner, http.MethodPost, "/contributors", `{"userId": 999}`, 400},
+		{"owner invites", owner, http.MethodPost, "/contributors", fmt.Sprintf(`{"userId": %d}`, contributor), 201},
+		{"owner invites again", owner, http.MethodPost, "/contributors", fmt.Sprintf(`{"userId": %d}`, contributor), 201},
+		{"stranger accepts uninvited", stranger, http.MethodPost, "/contributors/accept", "", 404},
+		{"invitee edits before accepting", contributor, http.MethodPost, "/videos", `{"url": "https://cdn.example.com/a.mp4"}`, 403},
+	})
+
+	// Only the owner sees the pending invitation
+	for _, test := range []struct {
+		name        string
+		userID      uint
+		invitations int
+	}{{"owner", owner, 1}, {"stranger", stranger, 0}} {
+		var contributors models.PlaylistContributors
+		recorder := accessRequest(router, http.MethodGet, base+"/contributors", "", user(test.userID), "", "")
+		if err := json.Unmarshal(recorder.Body.Bytes(), &contributors); err != nil {
+			t.Fatalf("%s: parsing contributors: %v", test.name, err)
+		}
+		if len(contributors.Contributors) != 0 || len(contributors.Invitations) != test.invitations {
+			t.Errorf("%s: got %+v, want no contributors and %d invitations", test.name, contributors, test.invitations)
+		}
+	}
+	recorder := accessRequest(router, http.MethodGet, "/me/invitations", "", user(contributor), "", "")
+	var invitations []models.PlaylistInvitation
+	if err := json.Unmarshal(recorder.Body.Bytes(), &invitations); err != nil {
+		t.Fatalf("parsing invitations: %v", err)
+	}
+	if len(invitations) != 1 || invitations[0].Playlist == nil || invitations[0].Playlist.ID != playlist.ID {
+		t.Errorf("got invitations %+v, want one to the playlist", invitations)
+	}
+
+	run([]collaborationStep{
+		{"invitee accepts", contributor, http.MethodPost, "/contributors/accept", "", 200},
+		{"owner invites a contributor", owner, http.MethodPost, "/contributors", fmt.Sprintf(`{"userId": %d}`, contributor), 409},
+		{"contributor edits a playlist that is not collaborative", contributor, http.MethodPost, "/videos", `{"url": "https://cdn.example.com/a.mp4"}`, 403},
+	})
+	var contributors models.PlaylistContributors
+	recorder = accessRequest(router, http.MethodGet, base+"/contributors", "", user(stranger), "", "")
+	if err := json.Unmarshal(recorder.Body.Bytes(), &contributors); err != nil {
+		t.Fatalf("parsing contributors: %v", err)
+	}
+	if len(contributors.Contributors) != 1 || contributors.Contributors[0].ID != contributor {
+		t.Errorf("got contributors %+v, want the user who accepted", contributors.Contributors)
+	}
+	recorder = accessRequest(router, http.MethodGet, "/me/invitations", "", user(contributor), "", "")
+	if recorder.Code != 200 || recorder.Body.String() != "[]" {
+		t.Errorf("invitations after accepting are %d %s, want none", recorder.Code, recorder.Body)
+	}
+	if err := db.Model(&playlist).Update("is_collaborative", true).Error; err != nil {
+		t.Fatalf("making playlist collaborative: %v", err)
+	}
+	var added models.Video
+	recorder = accessRequest(router, http.MethodPost, base+"/videos", `{"title": "added", "url": "https://cdn.example.com/a.mp4", "duration": 30}`, user(contributor), "", "")
+	if err := json.Unmarshal(recorder.Body.Bytes(), &added); err != nil || recorder.Code != 201 {
+		t.Fatalf("contributor adding a video got status %d: %v", recorder.Code, err)
+	}
+	other := models.Video{Title: "other", URL: "https://cdn.example.com/b.mp4"}
+	if err := models.NewPlaylistEditModel(db).AddVideo(playlist.ID, owner, &other); err != nil {
+		t.Fatalf("AddVideo: %v", err)
+	}
+	run([]collaborationStep{
+		{"stranger edits", stranger, http.MethodPost, "/videos", `{"url": "https://cdn.example.com/c.mp4"}`, 403},
+		{"contributor adds a video without a URL", contributor, http.MethodPost, "/videos", `{"title": "no url"}`, 400},
+		{"contributor reorders with a missing video", contributor, http.MethodPut, "/videos/order", fmt.Sprintf(`{"videoIds": [%d]}`, other.ID), 400},
+		{"contributor reorders", contributor, http.MethodPut, "/videos/order", fmt.Sprintf(`{"videoIds": [%d, %d]}`, other.ID, added.ID), 204},
+		{"contributor removes a video", contributor, http.MethodDelete, fmt.Sprintf("/videos/%d", other.ID), "", 204},
+		{"stranger reads the edit log", stranger, http.MethodGet, "/edits", "", 403},
+	})
+
+	var edits struct {
+		Items []models.PlaylistEdit `json:"items"`
+		Total int64                 `json:"total"`
+	}
+	recorder = accessRequest(router, http.MethodGet, base+"/edits?pageSize=2", "", user(contributor), "", "")
+	if err := json.Unmarshal(recorder.Body.Bytes(), &edits); err != nil {
+		t.Fatalf("parsing edits: %v", err)
+	}
+	if edits.Total != 4 || len(edits.Items) != 2 || edits.Items[0].Action != models.PlaylistEditRemove || edits.Items[0].UserID != contributor {
+		t.Fatalf("got edits %+v of %d, want the contributor's removal first of 4", edits.Items, edits.Total)
+	}
+	removal := fmt.Sprintf("/edits/%d/rollback", edits.Items[0].ID)
+	run([]collaborationStep{
+		{"contributor rolls back", contributor, http.MethodPost, removal, "", 403},
+		{"owner rolls back", owner, http.MethodPost, removal, "", 201},
+		{"owner rolls back twice", owner, http.MethodPost, removal, "", 409},
+		{"owner rolls back a missing edit", owner, http.MethodPost, "/edits/999/rollback", "", 404},
+		{"stranger removes the contributor", stranger, http.MethodDelete, fmt.Sprintf("/contributors/%d", contributor), "", 403},
+		{"contributor leaves", contributor, http.MethodDelete, fmt.Sprintf("/contributors/%d", contributor), "", 204},
+		{"former contributor edits", contributor, http.MethodPost, "/videos", `{"url": "https://cdn.example.com/c.mp4"}`, 403},
+		{"owner invites the stranger", owner, http.MethodPost, "/contributors", fmt.Sprintf(`{"userId": %d}`, stranger), 201},
+		{"owner withdraws the invitation", owner, http.MethodDelete, fmt.Sprintf("/contributors/%d", stranger), "", 204},
+		{"owner withdraws it again", owner, http.MethodDelete, fmt.Sprintf("/contributors/%d", stranger), "", 404},
+		{"stranger accepts the withdrawn invitation", stranger, http.MethodPost, "/contributors/accept", "", 404},
+	})
+
+	var restored models.Video
+	if err := db.First(&restored, other.ID).Error; err != nil {
+		t.Errorf("video whose removal was rolled back: %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+// backend/controllers/comment_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTrendingWindow is the period trending hashtags are counted over by default
+	DefaultTrendingWindow = 24 * time.Hour
+	// MaxTrendingWindow is the longest accepted trending window
+	MaxTrendingWindow = 30 * 24 * time.Hour
+)
+
+// CommentController handles comments on videos, playlists and advertisements
+type CommentController struct {
+	CommentModel *models.CommentModel
+	AccessModel  *models.AccessModel
+}
+
+// NewCommentController creates a new CommentController
+func NewCommentController(db *gorm.DB) *CommentController {
+	return &CommentController{
+		CommentModel: models.NewCommentModel(db),
+		AccessModel:  models.NewAccessModel(db),
+	}
+}
+
+// commentRequest is the body of comment create and edit requests
+type commentRequest struct {
+	Text            string `json:"text"`
+	ParentCommentID uint   `json:"parentCommentId"`
+}
+
+// abortWithCommentError maps comment model errors to HTTP statuses
+func abortWithCommentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.AbortWithStatus(404)
+	case errors.Is(err, models.ErrCommentsDisabled), errors.Is(err, models.ErrNotCommentAuthor),
+		errors.Is(err, models.ErrNotModerator):
+		c.AbortWithStatusJSON(403, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidReply):
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatus(500)
+	}
+}
+
+// GetComments returns a handler listing a page of the top-level comments of a video, playlist or advertisement
+func (cc *CommentController) GetComments(commentableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := paramID(c, "id")
+		if !ok {
+			c.AbortWithStatus(400)
+			return
+		}
+		if _, ok := requestVisible(c, cc.AccessModel, commentableType, id); !ok {
+			return
+		}
+		parent := models.Commentable{Type: commentableType, ID: id}
+		if err := cc.CommentModel.CheckCommentsAllowed(parent); err != nil {
+			abortWithCommentError(c, err)
+			return
+		}
+
+		page, pageSize := requestPage(c)
+		comments, total, err := cc.CommentModel.GetComments(parent, (page-1)*pageSize, pageSize)
+		if err != nil {
+			abortWithCommentError(c, err)
+			return
+		}
+		c.JSON(200, Page{Items: comments, Page: page, PageSize: pageSize, Total: total})
+	}
+}
+
+// CreateComment returns a handler adding a comment, or a reply when parentCommentId is set,
+// to a video, playlist or advertisement
+func (cc *CommentController) CreateComment(commentableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requestUserID(c)
+		if !ok {
+			c.AbortWithStatus(401)
+			return
+		}
+		id, ok := paramID(c, "id")
+		if !ok {
+			c.AbortWithStatus(400)
+			return
+		}
+		var request commentRequest
+		if err := c.ShouldBindJSON(&request); err != nil || strings.TrimSpace(request.Text) == "" {
+			c.AbortWithStatus(400)
+			return
+		}
+		if _, ok := requestVisible(c, cc.AccessModel, commentableType, id); !ok {
+			return
+		}
+
+		comment := models.Comment{
+			UserID:          userID,
+			Text:            request.Text,
+			ParentCommentID: request.ParentCommentID,
+		}
+		if err := cc.CommentModel.CreateComment(models.Commentable{Type: commentableType, ID: id}, &comment); err != nil {
+			abortWithCommentError(c, err)
+			return
+		}
+		c.JSON(201, comment)
+	}
+}
+
+// GetReplies lists a page of the replies to a comment on content the viewer can read
+func (cc *CommentController) GetReplies(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	comment, err := cc.CommentModel.GetComment(id)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	if parent, ok := comment.Commentable(); ok {
+		if _, ok := requestVisible(c, cc.AccessModel, parent.Type, parent.ID); !ok {
+			return
+		}
+	}
+
+	page, pageSize := requestPage(c)
+	replies, total, err := cc.CommentModel.GetReplies(id, (page-1)*pageSize, pageSize)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.JSON(200, Page{Items: replies, Page: page, PageSize: pageSize, Total: total})
+}
+
+// UpdateComment edits the text of the signed-in user's comment
+func (cc *CommentController) UpdateComment(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	var request commentRequest
+	if err := c.ShouldBindJSON(&request); err != nil || strings.TrimSpace(request.Text) == "" {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	comment, err := cc.CommentModel.UpdateCommentText(id, userID, request.Text)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.JSON(200, comment)
+}
+
+// DeleteComment deletes the signed-in user's comment
+func (cc *CommentController) DeleteComment(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	if err := cc.CommentModel.DeleteComment(id, userID); err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// commentVisible reports whether the viewer can read the content a comment belongs to, aborting the request
+// with 404 otherwise so that reports do not reveal private comments
+func (cc *CommentController) commentVisible(c *gin.Context, id uint) bool {
+	comment, err := cc.CommentModel.GetComment(id)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return false
+	}
+	viewer, ok := requestViewer(c, cc.AccessModel)
+	if !ok {
+		return false
+	}
+	parent, ok := comment.Commentable()
+	if !ok {
+		c.AbortWithStatus(404)
+		return false
+	}
+	if err := cc.AccessModel.CanView(parent.Type, parent.ID, viewer); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, models.ErrPasswordRequired) {
+			c.AbortWithStatus(404)
+		} else {
+			c.AbortWithStatus(500)
+		}
+		return false
+	}
+	return true
+}
+
+// ReportComment reports a comment on content the signed-in user can read, with an optional {"reason": "..."} body
+func (cc *CommentController) ReportComment(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatus(400)
+			return
+		}
+	}
+	if !cc.commentVisible(c, id) {
+		return
+	}
+
+	comment, err := cc.CommentModel.ReportComment(id, userID, request.Reason)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.JSON(200, comment)
+}
+
+// UnreportComment withdraws the signed-in user's report of a comment on content they can read
+func (cc *CommentController) UnreportComment(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	if !cc.commentVisible(c, id) {
+		return
+	}
+
+	comment, err := cc.CommentModel.UnreportComment(id, userID)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.JSON(200, comment)
+}
+
+// moderationRequest returns the comment of a request, aborting it unless the signed-in user moderates it
+func (cc *CommentController) moderationRequest(c *gin.Context) (uint, bool) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return 0, false
+	}
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return 0, false
+	}
+	if err := cc.CommentModel.CanModerate(id, userID); err != nil {
+		abortWithCommentError(c, err)
+		return 0, false
+	}
+	return id, true
+}
+
+// GetModerationQueue lists a page of the reported comments on the signed-in user's content, hidden ones first
+func (cc *CommentController) GetModerationQueue(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	page, pageSize := requestPage(c)
+	comments, total, err := cc.CommentModel.GetModerationQueue(userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.JSON(200, Page{Items: comments, Page: page, PageSize: pageSize, Total: total})
+}
+
+// ApproveComment clears the reports of a comment on the signed-in user's content and shows it again
+func (cc *CommentController) ApproveComment(c *gin.Context) {
+	id, ok := cc.moderationRequest(c)
+	if !ok {
+		return
+	}
+
+	comment, err := cc.CommentModel.ApproveComment(id)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.JSON(200, comment)
+}
+
+// RemoveComment deletes a reported comment on the signed-in user's content
+func (cc *CommentController) RemoveComment(c *gin.Context) {
+	id, ok := cc.moderationRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := cc.CommentModel.RemoveComment(id); err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// GetHashtagComments lists a page of the comments using a hashtag on content the viewer can read
+func (cc *CommentController) GetHashtagComments(c *gin.Context) {
+	viewer, ok := requestViewer(c, cc.AccessModel)
+	if !ok {
+		return
+	}
+	page, pageSize := requestPage(c)
+	comments, total, err := cc.CommentModel.GetHashtagComments(c.Params.ByName("name"), viewer, (page-1)*pageSize, pageSize)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.JSON(200, Page{Items: comments, Page: page, PageSize: pageSize, Total: total})
+}
+
+// GetTrendingHashtags lists the hashtags used by the most comments in a recent window.
+// Optional query parameters: window, a duration such as 24h up to MaxTrendingWindow, and limit.
+func (cc *CommentController) GetTrendingHashtags(c *gin.Context) {
+	window := DefaultTrendingWindow
+	if value := c.Query("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > MaxTrendingWindow {
+			c.AbortWithStatus(400)
+			return
+		}
+		window = parsed
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > MaxPageSize {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	trending, err := cc.CommentModel.GetTrendingHashtags(cc.CommentModel.Clock.Now().Add(-window), limit)
+	if err != nil {
+		abortWithCommentError(c, err)
+		return
+	}
+	c.JSON(200, trending)
+}
@@ -0,0 +1,79 @@
+// backend/controllers/comment_controller_test.go
+
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestReportCommentRequiresVisibleContent(t *testing.T) {
+	db := newTestDB(t)
+	// User 1 owns the private playlist, user 2 reports
+	public := models.Playlist{Title: "public", OwnerID: 1, IsPublic: true}
+	private := models.Playlist{Title: "private", OwnerID: 1}
+	for _, p := range []*models.Playlist{&public, &private} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("creating playlist: %v", err)
+		}
+	}
+	if err := db.Model(&private).Update("is_public", false).Error; err != nil {
+		t.Fatalf("hiding playlist: %v", err)
+	}
+	commentModel := models.NewCommentModel(db)
+	visible := models.Comment{UserID: 1, Text: "on a public playlist"}
+	hidden := models.Comment{UserID: 1, Text: "on a private playlist"}
+	if err := commentModel.CreateComment(models.Commentable{Type: models.CommentablePlaylist, ID: public.ID}, &visible); err != nil {
+		t.Fatalf("creating comment: %v", err)
+	}
+	if err := commentModel.CreateComment(models.Commentable{Type: models.CommentablePlaylist, ID: private.ID}, &hidden); err != nil {
+		t.Fatalf("creating comment: %v", err)
+	}
+
+	commentController := controllers.NewCommentController(db)
+	router := gin.New()
+	router.POST("/comments/:id/report", commentController.ReportComment)
+	router.DELETE("/comments/:id/report", commentController.UnreportComment)
+
+	tests := []struct {
+		name      string
+		method    string
+		commentID uint
+		userID    string
+		want      int
+	}{
+		{"report on a public playlist", http.MethodPost, visible.ID, "2", 200},
+		{"unreport on a public playlist", http.MethodDelete, visible.ID, "2", 200},
+		{"report on a private playlist", http.MethodPost, hidden.ID, "2", 404},
+		{"unreport on a private playlist", http.MethodDelete, hidden.ID, "2", 404},
+		{"owner report on a private playlist", http.MethodPost, hidden.ID, "1", 200},
+		{"anonymous report", http.MethodPost, visible.ID, "", 401},
+		{"report of a missing comment", http.MethodPost, hidden.ID + 1, "2", 404},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, fmt.Sprintf("/comments/%d/report", test.commentID), nil)
+		if test.userID != "" {
+			request.Header.Set(controllers.UserIDHeader, test.userID)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf("%s got status %d, want %d: %s", test.name, recorder.Code, test.want, recorder.Body)
+		}
+	}
+
+	var reports int64
+	if err := db.Model(&models.CommentReport{}).Where("comment_id = ? AND user_id = ?", hidden.ID, 2).Count(&reports).Error; err != nil {
+		t.Fatalf("counting reports: %v", err)
+	}
+	if reports != 0 {
+		t.Errorf("a user who cannot read the playlist reported its comment %d times", reports)
+	}
+}
@@ -0,0 +1,169 @@
+// backend/controllers/creative_controller.go
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"github.com/shuttlersit/ads-player/backend/storage"
+)
+
+// MaxCreativeSize is the largest creative file accepted, in bytes
+const MaxCreativeSize = 512 << 20
+
+// multipartOverhead allows for the form's boundaries, headers and other fields on top of the file
+const multipartOverhead = 1 << 20
+
+// contentTypes lists the MIME types accepted as advertisement content
+var contentTypes = []string{"video/mp4", "video/quicktime", "video/webm", "image/jpeg", "image/png", "image/gif"}
+
+// thumbnailTypes lists the MIME types accepted as advertisement thumbnails
+var thumbnailTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// CreativeController handles uploads of advertisement media files
+type CreativeController struct {
+	AdvertisementModel *models.AdvertisementModel
+	AccessModel        *models.AccessModel
+	Storage            storage.Storage
+	ProbeService       *MediaProbeService
+	MaxSize            int64 // Largest file accepted, in bytes
+}
+
+// NewCreativeController creates a new CreativeController
+func NewCreativeController(advertisementModel *models.AdvertisementModel, store storage.Storage, probeService *MediaProbeService) *CreativeController {
+	return &CreativeController{
+		AdvertisementModel: advertisementModel,
+		AccessModel:        models.NewAccessModel(advertisementModel.DB),
+		Storage:            store,
+		ProbeService:       probeService,
+		MaxSize:            MaxCreativeSize,
+	}
+}
+
+// UploadCreative stores the multipart "file" field as the advertisement's content.
+// Video files are probed so Duration and quality fields are filled from the file itself.
+func (cc *CreativeController) UploadCreative(c *gin.Context) {
+	cc.upload(c, contentTypes, func(advertisement *models.Advertisement, file multipart.File, url string, creative models.CreativeFile) error {
+		if err := cc.AdvertisementModel.UpdateCreative(advertisement.ID, url, creative); err != nil {
+			return err
+		}
+		if cc.ProbeService != nil && strings.HasPrefix(creative.ContentType, "video/") {
+			if err := cc.ProbeService.ProbeUpload(advertisement.ID, file); err != nil {
+				fmt.Printf("Error probing creative for advertisement %d: %v\n", advertisement.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// UploadThumbnail stores the multipart "file" field as the advertisement's thumbnail
+func (cc *CreativeController) UploadThumbnail(c *gin.Context) {
+	cc.upload(c, thumbnailTypes, func(advertisement *models.Advertisement, file multipart.File, url string, creative models.CreativeFile) error {
+		return cc.AdvertisementModel.UpdateThumbnail(advertisement.ID, url)
+	})
+}
+
+// upload validates and stores an uploaded file, then records it with save. Only the advertisement's owners may upload.
+func (cc *CreativeController) upload(c *gin.Context, allowedTypes []string, save func(*models.Advertisement, multipart.File, string, models.CreativeFile) error) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	if err := cc.AccessModel.CanManage(models.ContentAdvertisement, id, userID); err != nil {
+		abortWithAccessError(c, err)
+		return
+	}
+	var advertisement models.Advertisement
+	if err := cc.AdvertisementModel.DB.First(&advertisement, id).Error; err != nil {
+		c.AbortWithStatus(404)
+		return
+	}
+
+	// Stop reading oversized bodies instead of spooling them to disk first
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cc.MaxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(413, gin.H{"error": "file too large"})
+			return
+		}
+		c.AbortWithStatusJSON(400, gin.H{"error": "missing file"})
+		return
+	}
+	if header.Size > cc.MaxSize {
+		c.AbortWithStatusJSON(413, gin.H{"error": "file too large"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+	defer file.Close()
+
+	detected, checksum, err := inspectUpload(file)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	if !mimetype.EqualsAny(detected.String(), allowedTypes...) {
+		c.AbortWithStatusJSON(415, gin.H{"error": fmt.Sprintf("unsupported media type %s", detected.String())})
+		return
+	}
+
+	// Files are stored by checksum, so re-uploading the same file reuses the object
+	creative := models.CreativeFile{
+		ContentKey:      "creatives/" + checksum + detected.Extension(),
+		ContentType:     detected.String(),
+		ContentSize:     header.Size,
+		ContentChecksum: checksum,
+	}
+	if err := cc.Storage.Put(c.Request.Context(), creative.ContentKey, file, header.Size, creative.ContentType); err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+
+	url := cc.Storage.URL(creative.ContentKey)
+	if err := save(&advertisement, file, url, creative); err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	c.JSON(200, gin.H{"url": url, "creative": creative})
+}
+
+// inspectUpload sniffs the MIME type and computes the SHA-256 of an upload, then rewinds it
+func inspectUpload(file multipart.File) (*mimetype.MIME, string, error) {
+	detected, err := mimetype.DetectReader(file)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+	return detected, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,127 @@
+// backend/controllers/creative_controller_test.go
+
+package controllers_test
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"github.com/shuttlersit/ads-player/backend/storage"
+)
+
+// pngHeader is enough of a PNG file to be sniffed as image/png
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+
+func TestUploadThumbnailRequiresOwner(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "owned", OwnerID: 7, IsPublic: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	advertisement := models.Advertisement{Title: "advertisement", PlaylistID: playlist.ID, IsPublic: true}
+	if err := db.Create(&advertisement).Error; err != nil {
+		t.Fatalf("creating advertisement: %v", err)
+	}
+	store, err := storage.NewLocalStorage(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	creativeController := controllers.NewCreativeController(models.NewAdvertisementModel(db), store, nil)
+	router := gin.New()
+	router.POST("/advertisements/:id/thumbnail", creativeController.UploadThumbnail)
+
+	tests := []struct {
+		name            string
+		userID          string
+		advertisementID uint
+		want            int
+	}{
+		{"anonymous", "", advertisement.ID, 401},
+		{"not an owner", "8", advertisement.ID, 403},
+		{"missing advertisement", "7", advertisement.ID + 1, 404},
+		{"owner", "7", advertisement.ID, 200},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, _ := form.CreateFormFile("file", "thumbnail.png")
+			part.Write(pngHeader)
+			form.Close()
+
+			request := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/advertisements/%d/thumbnail", test.advertisementID), &body)
+			request.Header.Set("Content-Type", form.FormDataContentType())
+			if test.userID != "" {
+				request.Header.Set(controllers.UserIDHeader, test.userID)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.want {
+				t.Fatalf("got status %d, want %d: %s", recorder.Code, test.want, recorder.Body)
+			}
+
+			var stored models.Advertisement
+			if err := db.First(&stored, advertisement.ID).Error; err != nil {
+				t.Fatalf("reading advertisement: %v", err)
+			}
+			if updated := stored.ThumbnailURL != ""; updated != (test.want == 200) {
+				t.Errorf("thumbnail is %q after a %d", stored.ThumbnailURL, test.want)
+			}
+		})
+	}
+}
+
+func TestUploadCreativeRejectsOversizedFiles(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "owned", OwnerID: 7, IsPublic: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	advertisement := models.Advertisement{Title: "advertisement", PlaylistID: playlist.ID, IsPublic: true}
+	if err := db.Create(&advertisement).Error; err != nil {
+		t.Fatalf("creating advertisement: %v", err)
+	}
+	directory := t.TempDir()
+	store, err := storage.NewLocalStorage(directory, "/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	creativeController := controllers.NewCreativeController(models.NewAdvertisementModel(db), store, nil)
+	creativeController.MaxSize = 4 << 10
+	router := gin.New()
+	router.POST("/advertisements/:id/thumbnail", creativeController.UploadThumbnail)
+
+	tests := []struct {
+		name string
+		size int
+		want int
+	}{
+		{"within the limit", 4 << 10, 200},
+		{"just over the limit", 4<<10 + 1, 413},
+		{"body over the limit", 4 << 20, 413},
+	}
+	for _, test := range tests {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", "thumbnail.png")
+		part.Write(append(pngHeader, make([]byte, test.size-len(pngHeader))...))
+		form.Close()
+
+		request := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/advertisements/%d/thumbnail", advertisement.ID), &body)
+		request.Header.Set("Content-Type", form.FormDataContentType())
+		request.Header.Set(controllers.UserIDHeader, "7")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf("%s: got status %d, want %d: %s", test.name, recorder.Code, test.want, recorder.Body)
+		}
+	}
+}
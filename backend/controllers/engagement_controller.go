@@ -0,0 +1,125 @@
+// backend/controllers/engagement_controller.go
+
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// EngagementController handles likes, dislikes and follows of videos, playlists and advertisements
+type EngagementController struct {
+	EngagementModel *models.EngagementModel
+	AccessModel     *models.AccessModel
+}
+
+// NewEngagementController creates a new EngagementController
+func NewEngagementController(db *gorm.DB) *EngagementController {
+	return &EngagementController{
+		EngagementModel: models.NewEngagementModel(db),
+		AccessModel:     models.NewAccessModel(db),
+	}
+}
+
+// abortWithEngagementError maps engagement model errors to HTTP statuses
+func abortWithEngagementError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.AbortWithStatus(404)
+	case errors.Is(err, models.ErrNotFollowable):
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatus(500)
+	}
+}
+
+// engagementRequest returns the signed-in user and the content ID of an engagement request,
+// aborting the request when either is missing or the user cannot read the content
+func (ec *EngagementController) engagementRequest(c *gin.Context, contentType string) (userID, contentID uint, ok bool) {
+	userID, ok = requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return 0, 0, false
+	}
+	contentID, ok = paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return 0, 0, false
+	}
+	if _, ok := requestVisible(c, ec.AccessModel, contentType, contentID); !ok {
+		return 0, 0, false
+	}
+	return userID, contentID, true
+}
+
+// GetReaction returns a handler showing the signed-in user's reaction to content along with its counters
+func (ec *EngagementController) GetReaction(contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, contentID, ok := ec.engagementRequest(c, contentType)
+		if !ok {
+			return
+		}
+
+		state, err := ec.EngagementModel.GetReaction(contentType, contentID, userID)
+		if err != nil {
+			abortWithEngagementError(c, err)
+			return
+		}
+		c.JSON(200, state)
+	}
+}
+
+// SetReaction returns a handler making the signed-in user like or dislike content.
+// Repeating the request changes nothing.
+func (ec *EngagementController) SetReaction(contentType, reaction string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, contentID, ok := ec.engagementRequest(c, contentType)
+		if !ok {
+			return
+		}
+
+		state, err := ec.EngagementModel.SetReaction(contentType, contentID, userID, reaction)
+		if err != nil {
+			abortWithEngagementError(c, err)
+			return
+		}
+		c.JSON(200, state)
+	}
+}
+
+// RemoveReaction returns a handler withdrawing the signed-in user's like or dislike of content
+func (ec *EngagementController) RemoveReaction(contentType, reaction string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, contentID, ok := ec.engagementRequest(c, contentType)
+		if !ok {
+			return
+		}
+
+		state, err := ec.EngagementModel.RemoveReaction(contentType, contentID, userID, reaction)
+		if err != nil {
+			abortWithEngagementError(c, err)
+			return
+		}
+		c.JSON(200, state)
+	}
+}
+
+// SetFollowing returns a handler making the signed-in user follow or unfollow content
+func (ec *EngagementController) SetFollowing(contentType string, following bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, contentID, ok := ec.engagementRequest(c, contentType)
+		if !ok {
+			return
+		}
+
+		state, err := ec.EngagementModel.SetFollowing(contentType, contentID, userID, following)
+		if err != nil {
+			abortWithEngagementError(c, err)
+			return
+		}
+		c.JSON(200, state)
+	}
+}
@@ -0,0 +1,172 @@
+// backend/controllers/feed_controller.go
+
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/feed"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// FeedController exports playlists as feeds for third-party signage players
+type FeedController struct {
+	PlaylistModel      *models.PlaylistModel
+	VideoModel         *models.VideoModel
+	AdvertisementModel *models.AdvertisementModel
+	AccessModel        *models.AccessModel
+}
+
+// NewFeedController creates a new FeedController
+func NewFeedController(db *gorm.DB) *FeedController {
+	return &FeedController{
+		PlaylistModel:      models.NewPlaylistModel(db),
+		VideoModel:         models.NewVideoModel(db),
+		AdvertisementModel: models.NewAdvertisementModel(db),
+		AccessModel:        models.NewAccessModel(db),
+	}
+}
+
+// GetPlaylistRSS returns the playlist as a Media RSS feed
+func (fc *FeedController) GetPlaylistRSS(c *gin.Context) {
+	fc.serveFeed(c, "application/rss+xml; charset=utf-8", feed.Feed.RSS)
+}
+
+// GetPlaylistJSON returns the playlist as a JSON Feed
+func (fc *FeedController) GetPlaylistJSON(c *gin.Context) {
+	fc.serveFeed(c, "application/feed+json; charset=utf-8", feed.Feed.JSON)
+}
+
+// serveFeed builds the playlist's feed and renders it. The ETag follows the latest change to the playlist,
+// its videos and its advertisements, and the advertisements selected, which also change with the time of day
+// and schedules falling due. Last-Modified cannot reflect those, so only the ETag validates conditional requests.
+// Feeds requested from another site follow the embedding settings of the playlist and its advertisements.
+func (fc *FeedController) serveFeed(c *gin.Context, contentType string, render func(feed.Feed) ([]byte, error)) {
+	id, err := strconv.ParseUint(c.Params.ByName("id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+	if _, ok := requestVisible(c, fc.AccessModel, models.ContentPlaylist, uint(id)); !ok {
+		return
+	}
+	playlist, err := fc.PlaylistModel.GetPlaylistForSelection(uint(id))
+	if err != nil {
+		c.AbortWithStatus(404)
+		return
+	}
+	origin := requestEmbedOrigin(c)
+	if !playlist.Access.AllowsEmbedding(origin) {
+		abortWithAccessError(c, models.ErrEmbeddingNotAllowed)
+		return
+	}
+
+	lastModified, err := fc.PlaylistModel.GetFeedLastModified(*playlist)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	videos, err := fc.VideoModel.GetPlaylistVideos(playlist.ID)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	breaks, err := fc.AdvertisementModel.PlanAdBreaks(models.SelectionContext{
+		Playlist: playlist,
+		Location: requestLocation(c),
+		Time:     fc.AdvertisementModel.Clock.Now(),
+		Origin:   origin,
+	}, videos)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+
+	etag := fmt.Sprintf(`W/"playlist-%d-%d-%s"`, playlist.ID, lastModified.UnixNano(), selectionDigest(breaks))
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	if c.GetHeader("If-None-Match") != "" && feed.NotModified(c.Request, etag, lastModified) {
+		c.Status(304)
+		return
+	}
+
+	body, err := render(feed.Feed{
+		Title:       playlist.Title,
+		Description: playlist.Description,
+		Link:        requestURL(c),
+		Updated:     lastModified,
+		Items:       feedItems(videos, breaks),
+	})
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	c.Data(200, contentType, body)
+}
+
+// selectionDigest identifies the advertisements selected for each ad break
+func selectionDigest(breaks []models.AdBreak) string {
+	hash := fnv.New64a()
+	for _, adBreak := range breaks {
+		fmt.Fprintf(hash, "%d:", adBreak.Position)
+		for _, advertisement := range adBreak.Pod.Advertisements {
+			fmt.Fprintf(hash, "%d,", advertisement.ID)
+		}
+		hash.Write([]byte{';'})
+	}
+	return strconv.FormatUint(hash.Sum64(), 36)
+}
+
+// feedItems interleaves videos with the advertisements of the breaks before them
+func feedItems(videos []models.Video, breaks []models.AdBreak) []feed.Item {
+	breakAt := make(map[int]models.AdPod, len(breaks))
+	for _, adBreak := range breaks {
+		breakAt[adBreak.Position] = adBreak.Pod
+	}
+
+	items := make([]feed.Item, 0, len(videos))
+	for i, video := range videos {
+		for _, advertisement := range breakAt[i].Advertisements {
+			items = append(items, feed.Item{
+				ID:              fmt.Sprintf("advertisement-%d-%d", advertisement.ID, i),
+				Kind:            feed.KindAdvertisement,
+				Title:           advertisement.Title,
+				Description:     advertisement.Description,
+				URL:             advertisement.ContentURL,
+				ContentType:     advertisement.Creative.ContentType,
+				ThumbnailURL:    advertisement.ThumbnailURL,
+				Duration:        advertisement.Duration,
+				ClickThroughURL: advertisement.ClickThroughURL,
+			})
+		}
+		items = append(items, feed.Item{
+			ID:           fmt.Sprintf("video-%d", video.ID),
+			Kind:         feed.KindVideo,
+			Title:        video.Title,
+			Description:  video.Description,
+			URL:          video.URL,
+			ThumbnailURL: video.ThumbnailURL,
+			Duration:     video.PlaybackDuration(),
+		})
+	}
+	return items
+}
+
+// requestURL reconstructs the absolute URL of the current request
+func requestURL(c *gin.Context) string {
+	return requestBaseURL(c) + c.Request.URL.RequestURI()
+}
+
+// requestBaseURL returns the scheme and host the current request was sent to
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
@@ -0,0 +1,127 @@
+// backend/controllers/feed_controller_test.go
+
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestFeedValidatorsFollowVideosAndAdvertisements(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "playlist", IsPublic: true, IsPlayable: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	video := models.Video{Title: "video", PlaylistID: playlist.ID, URL: "https://cdn.example.com/video.mp4", Duration: 60}
+	if err := db.Create(&video).Error; err != nil {
+		t.Fatalf("creating video: %v", err)
+	}
+	advertisement := models.Advertisement{Title: "advertisement", PlaylistID: playlist.ID, IsPublic: true, Duration: 30}
+	if err := db.Create(&advertisement).Error; err != nil {
+		t.Fatalf("creating advertisement: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/playlists/:id/feed.json", controllers.NewFeedController(db).GetPlaylistJSON)
+	get := func(etag string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/playlists/%d/feed.json", playlist.ID), nil)
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	etag := get("").Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("feed has no ETag")
+	}
+	if code := get(etag).Code; code != 304 {
+		t.Fatalf("unchanged feed got status %d, want 304", code)
+	}
+
+	changes := []struct {
+		name   string
+		change func() error
+	}{
+		{"video updated", func() error { return db.Model(&video).Update("title", "renamed").Error }},
+		{"advertisement updated", func() error { return db.Model(&advertisement).Update("description", "new copy").Error }},
+		{"advertisement deleted", func() error { return db.Delete(&advertisement).Error }},
+	}
+	for _, change := range changes {
+		if err := change.change(); err != nil {
+			t.Fatalf("%s: %v", change.name, err)
+		}
+		response := get(etag)
+		if response.Code != 200 || response.Header().Get("ETag") == etag {
+			t.Fatalf("after %s got status %d with ETag %s, want 200 with a new ETag", change.name, response.Code, response.Header().Get("ETag"))
+		}
+		etag = response.Header().Get("ETag")
+	}
+}
+
+func TestFeedValidatorsFollowAdvertisementSelection(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "playlist", IsPublic: true, IsPlayable: true, AdBreakInterval: 60}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	for _, title := range []string{"first", "second"} {
+		video := models.Video{Title: title, PlaylistID: playlist.ID, URL: "https://cdn.example.com/" + title + ".mp4", Duration: 60}
+		if err := db.Create(&video).Error; err != nil {
+			t.Fatalf("creating video: %v", err)
+		}
+	}
+	// The advertisement falls due at noon without any row changing
+	noon := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	advertisement := models.Advertisement{Title: "launch", PlaylistID: playlist.ID, IsPublic: true, Duration: 30,
+		ContentURL: "https://cdn.example.com/launch.mp4", ScheduledAt: noon}
+	if err := db.Create(&advertisement).Error; err != nil {
+		t.Fatalf("creating advertisement: %v", err)
+	}
+
+	feedController := controllers.NewFeedController(db)
+	now := noon.Add(-time.Hour)
+	feedController.AdvertisementModel.Clock = models.ClockFunc(func() time.Time { return now })
+	router := gin.New()
+	router.GET("/playlists/:id/feed.json", feedController.GetPlaylistJSON)
+	get := func(header, value string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/playlists/%d/feed.json", playlist.ID), nil)
+		if header != "" {
+			request.Header.Set(header, value)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	before := get("", "")
+	etag := before.Header().Get("ETag")
+	if strings.Contains(before.Body.String(), "launch") {
+		t.Fatal("advertisement listed before it is due")
+	}
+	if code := get("If-None-Match", etag).Code; code != 304 {
+		t.Fatalf("unchanged feed got status %d, want 304", code)
+	}
+
+	now = noon.Add(time.Minute)
+	after := get("If-None-Match", etag)
+	if after.Code != 200 || after.Header().Get("ETag") == etag || !strings.Contains(after.Body.String(), "launch") {
+		t.Errorf("once the advertisement is due got status %d with ETag %s, want 200 with a new ETag listing it",
+			after.Code, after.Header().Get("ETag"))
+	}
+	if code := get("If-Modified-Since", before.Header().Get("Last-Modified")).Code; code != 200 {
+		t.Errorf("If-Modified-Since alone got status %d, want 200", code)
+	}
+}
@@ -0,0 +1,79 @@
+// backend/controllers/import_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/importer"
+	"gorm.io/gorm"
+)
+
+// ImportController handles bulk imports of playlists, videos and advertisements
+type ImportController struct {
+	Importer *importer.Importer
+}
+
+// NewImportController creates a new ImportController
+func NewImportController(db *gorm.DB) *ImportController {
+	return &ImportController{
+		Importer: importer.NewImporter(db),
+	}
+}
+
+// Import applies a CSV or JSON manifest sent either as the request body or as a multipart "file".
+// The format comes from the format query parameter, the file extension or the content type.
+// With dryRun=true the manifest is only validated. Any invalid row fails the whole import with 422.
+// The signed-in user owns what is imported and must own the channels and playlists the manifest refers to.
+func (ic *ImportController) Import(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+	format := c.Query("format")
+
+	var body io.Reader = c.Request.Body
+	if header, err := c.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			c.AbortWithStatus(400)
+			return
+		}
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(header.Filename), ".")
+		}
+	}
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.ContentType(), "csv") {
+			format = "csv"
+		}
+	}
+
+	manifest, err := importer.Parse(body, format)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := ic.Importer.Import(manifest, userID, dryRun)
+	if err != nil {
+		var validationError *importer.ValidationError
+		if errors.As(err, &validationError) {
+			c.AbortWithStatusJSON(422, result)
+			return
+		}
+		c.AbortWithStatusJSON(500, result)
+		return
+	}
+	c.JSON(200, result)
+}
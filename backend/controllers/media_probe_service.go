@@ -0,0 +1,154 @@
+// backend/controllers/media_probe_service.go
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/ads-player/backend/media"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"github.com/shuttlersit/ads-player/backend/storage"
+)
+
+// MediaProbeService inspects advertisement and video files to record their real duration, dimensions and codecs
+type MediaProbeService struct {
+	AdvertisementModel *models.AdvertisementModel
+	VideoModel         *models.VideoModel
+	Storage            storage.Storage
+	Client             *http.Client
+}
+
+// NewMediaProbeService creates a new MediaProbeService
+func NewMediaProbeService(advertisementModel *models.AdvertisementModel, videoModel *models.VideoModel, store storage.Storage) *MediaProbeService {
+	return &MediaProbeService{
+		AdvertisementModel: advertisementModel,
+		VideoModel:         videoModel,
+		Storage:            store,
+		Client:             &http.Client{Timeout: time.Minute},
+	}
+}
+
+// ProbeUpload probes a freshly uploaded creative and records the result on the advertisement
+func (s *MediaProbeService) ProbeUpload(advertisementID uint, file io.ReadSeeker) error {
+	info, err := media.Probe(file)
+	return s.AdvertisementModel.RecordProbe(advertisementID, s.result(info, err), videoQuality(info), audioQuality(info))
+}
+
+// ProbeAdvertisement probes an advertisement's creative, from storage when it was uploaded or else from its ContentURL
+func (s *MediaProbeService) ProbeAdvertisement(ctx context.Context, advertisement models.Advertisement) error {
+	var info *media.Info
+	var err error
+	if advertisement.Creative.ContentKey != "" && s.Storage != nil {
+		info, err = s.probeStored(ctx, advertisement.Creative.ContentKey)
+	} else {
+		info, err = s.probeURL(ctx, advertisement.ContentURL)
+	}
+	return s.AdvertisementModel.RecordProbe(advertisement.ID, s.result(info, err), videoQuality(info), audioQuality(info))
+}
+
+// ProbeVideo probes a video from its URL
+func (s *MediaProbeService) ProbeVideo(ctx context.Context, video models.Video) error {
+	info, err := s.probeURL(ctx, video.URL)
+	return s.VideoModel.RecordProbe(video.ID, s.result(info, err))
+}
+
+// ReprobeStale probes every advertisement and video that has not been probed within maxAge
+func (s *MediaProbeService) ReprobeStale(ctx context.Context, maxAge time.Duration) error {
+	probedBefore := s.AdvertisementModel.Clock.Now().Add(-maxAge)
+
+	advertisements, err := s.AdvertisementModel.GetAdvertisementsToProbe(probedBefore)
+	if err != nil {
+		return err
+	}
+	for _, advertisement := range advertisements {
+		if err := s.ProbeAdvertisement(ctx, advertisement); err != nil {
+			fmt.Printf("Error recording probe for advertisement %d: %v\n", advertisement.ID, err)
+		}
+	}
+
+	videos, err := s.VideoModel.GetVideosToProbe(probedBefore)
+	if err != nil {
+		return err
+	}
+	for _, video := range videos {
+		if err := s.ProbeVideo(ctx, video); err != nil {
+			fmt.Printf("Error recording probe for video %d: %v\n", video.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// probeStored probes an object from storage, spooling it to a temporary file when the store cannot seek
+func (s *MediaProbeService) probeStored(ctx context.Context, key string) (*media.Info, error) {
+	object, err := s.Storage.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	if seeker, ok := object.(io.ReadSeeker); ok {
+		return media.Probe(seeker)
+	}
+
+	tmp, err := os.CreateTemp("", "probe-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if _, err := io.Copy(tmp, object); err != nil {
+		return nil, err
+	}
+	return media.Probe(tmp)
+}
+
+// probeURL probes a remote file with range requests
+func (s *MediaProbeService) probeURL(ctx context.Context, url string) (*media.Info, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("cannot probe %q: not an http(s) URL", url)
+	}
+	return media.ProbeURL(ctx, s.Client, url)
+}
+
+// result converts a probe outcome into the stored MediaProbe
+func (s *MediaProbeService) result(info *media.Info, err error) models.MediaProbe {
+	probedAt := s.AdvertisementModel.Clock.Now()
+	if err != nil {
+		return models.MediaProbe{ProbedAt: &probedAt, Error: err.Error()}
+	}
+	return models.MediaProbe{
+		Duration:       int(math.Round(info.Duration)),
+		Width:          info.Width,
+		Height:         info.Height,
+		VideoCodec:     info.VideoCodec,
+		AudioCodec:     info.AudioCodec,
+		Bitrate:        info.Bitrate,
+		Size:           info.Size,
+		FragmentOffset: info.FragmentOffset,
+		ProbedAt:       &probedAt,
+	}
+}
+
+// videoQuality returns the video quality label of a probe, if any
+func videoQuality(info *media.Info) string {
+	if info == nil {
+		return ""
+	}
+	return info.VideoQuality()
+}
+
+// audioQuality returns the audio quality label of a probe, if any
+func audioQuality(info *media.Info) string {
+	if info == nil {
+		return ""
+	}
+	return info.AudioQuality()
+}
@@ -10,29 +10,59 @@ import (
 
 // PlaylistController handles CRUD operations for playlists
 type PlaylistController struct {
-	DB *gorm.DB
+	DB          *gorm.DB
+	AccessModel *models.AccessModel
 }
 
 // NewPlaylistController creates a new PlaylistController
 func NewPlaylistController(db *gorm.DB) *PlaylistController {
 	return &PlaylistController{
-		DB: db,
+		DB:          db,
+		AccessModel: models.NewAccessModel(db),
 	}
 }
 
-// GetPlaylists retrieves all playlists
+// GetPlaylists retrieves the playlists the viewer can read, or those in the category given by the optional
+// category query parameter, an ID or slug, and its subcategories
 func (pc *PlaylistController) GetPlaylists(c *gin.Context) {
+	viewer, ok := requestViewer(c, pc.AccessModel)
+	if !ok {
+		return
+	}
+	query := pc.DB.Scopes(models.VisiblePlaylists(viewer))
+	if ref := c.Query("category"); ref != "" {
+		categoryModel := models.NewCategoryModel(pc.DB)
+		category, err := categoryModel.GetCategory(ref)
+		if err != nil {
+			abortWithCategoryError(c, err)
+			return
+		}
+		subtree, err := categoryModel.CategorySubtree(category.ID)
+		if err != nil {
+			abortWithCategoryError(c, err)
+			return
+		}
+		query = query.Scopes(models.PlaylistsInCategories(subtree))
+	}
+
 	var playlists []models.Playlist
-	if err := pc.DB.Find(&playlists).Error; err != nil {
+	if err := query.Find(&playlists).Error; err != nil {
 		c.AbortWithStatus(500)
 		return
 	}
 	c.JSON(200, playlists)
 }
 
-// GetPlaylistByID retrieves a playlist by ID
+// GetPlaylistByID retrieves a playlist by ID, if the viewer can read it
 func (pc *PlaylistController) GetPlaylistByID(c *gin.Context) {
-	id := c.Params.ByName("id")
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	if _, ok := requestVisible(c, pc.AccessModel, models.ContentPlaylist, id); !ok {
+		return
+	}
 	var playlist models.Playlist
 	if err := pc.DB.Preload("Videos").First(&playlist, id).Error; err != nil {
 		c.AbortWithStatus(404)
@@ -41,13 +71,16 @@ func (pc *PlaylistController) GetPlaylistByID(c *gin.Context) {
 	c.JSON(200, playlist)
 }
 
-// CreatePlaylist creates a new playlist
+// CreatePlaylist creates a new playlist owned by the signed-in user
 func (pc *PlaylistController) CreatePlaylist(c *gin.Context) {
 	var playlist models.Playlist
 	if err := c.ShouldBindJSON(&playlist); err != nil {
 		c.AbortWithStatus(400)
 		return
 	}
+	if userID, ok := requestUserID(c); ok {
+		playlist.OwnerID = userID
+	}
 	if err := pc.DB.Create(&playlist).Error; err != nil {
 		c.AbortWithStatus(500)
 		return
@@ -55,28 +88,110 @@ func (pc *PlaylistController) CreatePlaylist(c *gin.Context) {
 	c.JSON(200, playlist)
 }
 
-// UpdatePlaylist updates a playlist by ID
+// playlistUpdate is the body of playlist updates. Only these fields are taken from the body:
+// counters, owners and associations such as videos and advertisements are never written through it.
+type playlistUpdate struct {
+	Title           string          `json:"title"`
+	Description     string          `json:"description"`
+	FeaturedArtwork string          `json:"featuredArtwork"`
+	Tags            []string        `json:"tags"`
+	Language        string          `json:"language"`
+	IsPlayable      bool            `json:"isPlayable"`
+	AdBreakInterval int             `json:"adBreakInterval"`
+	AdBreakDuration int             `json:"adBreakDuration"`
+	MaxAdsPerBreak  int             `json:"maxAdsPerBreak"`
+	Location        models.Location `json:"location"`
+	// Owners only
+	IsPublic       bool                  `json:"isPublic"`
+	PrivacySetting models.PrivacySetting `json:"privacySetting"`
+	Access         models.AccessSetting  `json:"access"`
+}
+
+// playlistEditorColumns are the columns of the fields of playlistUpdate any editor may change
+var playlistEditorColumns = []string{"title", "description", "featured_artwork", "tags", "language", "is_playable",
+	"ad_break_interval", "ad_break_duration", "max_ads_per_break", "latitude", "longitude", "name", "address", "city",
+	"state", "country", "zip_code", "region", "place_id", "formatted_address"}
+
+// playlistOwnerColumns are the columns of the fields of playlistUpdate only owners may change
+var playlistOwnerColumns = []string{"is_public", "is_collaborative", "allow_comments", "allow_downloads", "allow_embedding", "embed_domains"}
+
+// UpdatePlaylist updates a playlist by ID. Fields missing from the body keep their values. Contributors of
+// collaborative playlists can change the fields of playlistUpdate but visibility, privacy and access settings.
 func (pc *PlaylistController) UpdatePlaylist(c *gin.Context) {
-	id := c.Params.ByName("id")
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	if err := pc.AccessModel.CanEdit(models.ContentPlaylist, id, userID); err != nil {
+		abortWithAccessError(c, err)
+		return
+	}
 	var playlist models.Playlist
 	if err := pc.DB.First(&playlist, id).Error; err != nil {
 		c.AbortWithStatus(404)
 		return
 	}
-	if err := c.ShouldBindJSON(&playlist); err != nil {
+	request := playlistUpdate{
+		Title:           playlist.Title,
+		Description:     playlist.Description,
+		FeaturedArtwork: playlist.FeaturedArtwork,
+		Tags:            playlist.Tags,
+		Language:        playlist.Language,
+		IsPlayable:      playlist.IsPlayable,
+		AdBreakInterval: playlist.AdBreakInterval,
+		AdBreakDuration: playlist.AdBreakDuration,
+		MaxAdsPerBreak:  playlist.MaxAdsPerBreak,
+		Location:        playlist.Location,
+		IsPublic:        playlist.IsPublic,
+		PrivacySetting:  playlist.PrivacySetting,
+		Access:          playlist.Access,
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
 		c.AbortWithStatus(400)
 		return
 	}
-	if err := pc.DB.Save(&playlist).Error; err != nil {
+
+	playlist.Title, playlist.Description, playlist.FeaturedArtwork = request.Title, request.Description, request.FeaturedArtwork
+	playlist.Tags, playlist.Language, playlist.IsPlayable = request.Tags, request.Language, request.IsPlayable
+	playlist.AdBreakInterval, playlist.AdBreakDuration = request.AdBreakInterval, request.AdBreakDuration
+	playlist.MaxAdsPerBreak, playlist.Location = request.MaxAdsPerBreak, request.Location
+	columns := playlistEditorColumns
+	if pc.AccessModel.CanManage(models.ContentPlaylist, id, userID) == nil {
+		playlist.IsPublic, playlist.PrivacySetting = request.IsPublic, request.PrivacySetting
+		playlist.Access.AllowDownloads, playlist.Access.AllowEmbedding = request.Access.AllowDownloads, request.Access.AllowEmbedding
+		playlist.Access.EmbedDomains = request.Access.EmbedDomains
+		columns = append(append([]string{}, columns...), playlistOwnerColumns...)
+	}
+	if err := pc.DB.Model(&playlist).Select(columns).Updates(&playlist).Error; err != nil {
 		c.AbortWithStatus(500)
 		return
 	}
 	c.JSON(200, playlist)
 }
 
-// DeletePlaylist deletes a playlist by ID
+// DeletePlaylist deletes a playlist by ID, for its owners only
 func (pc *PlaylistController) DeletePlaylist(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
 	id := c.Params.ByName("id")
+	playlistID, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	if err := pc.AccessModel.CanManage(models.ContentPlaylist, playlistID, userID); err != nil {
+		abortWithAccessError(c, err)
+		return
+	}
 	var playlist models.Playlist
 	if err := pc.DB.First(&playlist, id).Error; err != nil {
 		c.AbortWithStatus(404)
@@ -0,0 +1,69 @@
+// backend/controllers/playlist_controller_test.go
+
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestUpdatePlaylistByContributor(t *testing.T) {
+	db := newTestDB(t)
+	// User 1 owns both playlists, user 2 contributes to the collaborative one
+	playlist := models.Playlist{Title: "shared", OwnerID: 1, IsPublic: true, PlayCount: 5}
+	playlist.PrivacySetting.IsCollaborative = true
+	other := models.Playlist{Title: "other", OwnerID: 1}
+	for _, p := range []*models.Playlist{&playlist, &other} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("creating playlist: %v", err)
+		}
+	}
+	if err := db.Create(&models.UserPlaylistContributors{PlaylistID: playlist.ID, UserID: 2, InvitedByID: 1}).Error; err != nil {
+		t.Fatalf("adding contributor: %v", err)
+	}
+	video := models.Video{Title: "video", PlaylistID: other.ID}
+	if err := db.Create(&video).Error; err != nil {
+		t.Fatalf("creating video: %v", err)
+	}
+
+	router := gin.New()
+	router.PUT("/playlists/:id", controllers.NewPlaylistController(db).UpdatePlaylist)
+	body := fmt.Sprintf(`{"title": "renamed", "isPublic": false, "playCount": 999, "likeCount": 7, "ownerId": 2,
+		"Videos": [{"ID": %d, "title": "taken"}]}`, video.ID)
+	request := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/playlists/%d", playlist.ID), strings.NewReader(body))
+	request.Header.Set(controllers.UserIDHeader, "2")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != 200 {
+		t.Fatalf("update got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+
+	var stored models.Video
+	if err := db.First(&stored, video.ID).Error; err != nil {
+		t.Fatalf("loading video: %v", err)
+	}
+	if stored.PlaylistID != other.ID || stored.Title != "video" {
+		t.Errorf("video moved to playlist %d as %q, want it left in %d as %q", stored.PlaylistID, stored.Title, other.ID, "video")
+	}
+	var updated models.Playlist
+	if err := db.First(&updated, playlist.ID).Error; err != nil {
+		t.Fatalf("loading playlist: %v", err)
+	}
+	if updated.Title != "renamed" {
+		t.Errorf("title is %q, want renamed", updated.Title)
+	}
+	if updated.PlayCount != 5 || updated.LikeCount != 0 || updated.OwnerID != 1 {
+		t.Errorf("play count %d, like count %d and owner %d changed, want 5, 0 and 1", updated.PlayCount, updated.LikeCount, updated.OwnerID)
+	}
+	if !updated.IsPublic || !updated.PrivacySetting.IsCollaborative {
+		t.Errorf("contributor changed the visibility or privacy settings: public %v, collaborative %v", updated.IsPublic, updated.PrivacySetting.IsCollaborative)
+	}
+}
@@ -0,0 +1,154 @@
+// backend/controllers/related_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// DefaultRelatedLimit is the number of related items returned when the limit query parameter is missing
+const DefaultRelatedLimit = 10
+
+// RelatedController handles related videos, playlists and advertisements
+type RelatedController struct {
+	RelatedModel *models.RelatedModel
+	AccessModel  *models.AccessModel
+}
+
+// NewRelatedController creates a new RelatedController
+func NewRelatedController(db *gorm.DB) *RelatedController {
+	return &RelatedController{
+		RelatedModel: models.NewRelatedModel(db),
+		AccessModel:  models.NewAccessModel(db),
+	}
+}
+
+// abortWithRelatedError maps related model errors to HTTP statuses
+func abortWithRelatedError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.AbortWithStatus(404)
+	case errors.Is(err, models.ErrInvalidRelated):
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatus(500)
+	}
+}
+
+// GetRelated returns a handler listing the items the viewer can read related to a video, playlist or advertisement,
+// pinned ones first. The optional limit query parameter is capped at MaxRelatedItems.
+func (rc *RelatedController) GetRelated(contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := paramID(c, "id")
+		if !ok {
+			c.AbortWithStatus(400)
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultRelatedLimit)))
+		if err != nil || limit < 1 {
+			c.AbortWithStatus(400)
+			return
+		}
+		if limit > models.MaxRelatedItems {
+			limit = models.MaxRelatedItems
+		}
+		viewer, ok := requestVisible(c, rc.AccessModel, contentType, id)
+		if !ok {
+			return
+		}
+
+		var related interface{}
+		switch contentType {
+		case models.ContentVideo:
+			related, err = rc.RelatedModel.GetRelatedVideos(id, viewer, limit)
+		case models.ContentPlaylist:
+			related, err = rc.RelatedModel.GetRelatedPlaylists(id, viewer, limit)
+		default:
+			related, err = rc.RelatedModel.GetRelatedAdvertisements(id, viewer, limit)
+		}
+		if err != nil {
+			abortWithRelatedError(c, err)
+			return
+		}
+		c.JSON(200, related)
+	}
+}
+
+// requestManager checks that the signed-in user manages the source item of a pin, aborting otherwise
+func (rc *RelatedController) requestManager(c *gin.Context, contentType string, id uint) bool {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return false
+	}
+	if err := rc.AccessModel.CanManage(contentType, id, userID); err != nil {
+		abortWithAccessError(c, err)
+		return false
+	}
+	return true
+}
+
+// PinRelated returns a handler pinning an item as related to another, with an optional {"order": 0} body.
+// Only the owners of the item may pin.
+func (rc *RelatedController) PinRelated(contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := paramID(c, "id")
+		if !ok {
+			c.AbortWithStatus(400)
+			return
+		}
+		relatedID, ok := paramID(c, "relatedId")
+		if !ok {
+			c.AbortWithStatus(400)
+			return
+		}
+		if !rc.requestManager(c, contentType, id) {
+			return
+		}
+		var request struct {
+			Order int `json:"order"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				c.AbortWithStatus(400)
+				return
+			}
+		}
+
+		if err := rc.RelatedModel.PinRelated(contentType, id, relatedID, request.Order); err != nil {
+			abortWithRelatedError(c, err)
+			return
+		}
+		c.Status(204)
+	}
+}
+
+// UnpinRelated returns a handler removing a pinned related item, for the owners of the item only
+func (rc *RelatedController) UnpinRelated(contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := paramID(c, "id")
+		if !ok {
+			c.AbortWithStatus(400)
+			return
+		}
+		relatedID, ok := paramID(c, "relatedId")
+		if !ok {
+			c.AbortWithStatus(400)
+			return
+		}
+		if !rc.requestManager(c, contentType, id) {
+			return
+		}
+
+		if err := rc.RelatedModel.UnpinRelated(contentType, id, relatedID); err != nil {
+			abortWithRelatedError(c, err)
+			return
+		}
+		c.Status(204)
+	}
+}
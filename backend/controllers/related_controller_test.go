@@ -0,0 +1,75 @@
+// backend/controllers/related_controller_test.go
+
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestPinRelatedRequiresOwner(t *testing.T) {
+	db := newTestDB(t)
+	// User 7 owns the channel, 8 the playlist and 9 uploaded the first video
+	channel := models.Channel{Name: "channel", OwnerID: 7}
+	if err := db.Create(&channel).Error; err != nil {
+		t.Fatalf("creating channel: %v", err)
+	}
+	playlist := models.Playlist{Title: "playlist", OwnerID: 8, IsPublic: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	video := models.Video{Title: "video", PlaylistID: playlist.ID, ChannelID: channel.ID, UploaderID: 9}
+	related := models.Video{Title: "related", PlaylistID: playlist.ID}
+	for _, v := range []*models.Video{&video, &related} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatalf("creating video: %v", err)
+		}
+	}
+
+	relatedController := controllers.NewRelatedController(db)
+	router := gin.New()
+	router.PUT("/videos/:id/related/:relatedId", relatedController.PinRelated(models.ContentVideo))
+	router.DELETE("/videos/:id/related/:relatedId", relatedController.UnpinRelated(models.ContentVideo))
+
+	tests := []struct {
+		name   string
+		method string
+		userID string
+		want   int
+	}{
+		{"anonymous pin", http.MethodPut, "", 401},
+		{"stranger pin", http.MethodPut, "10", 403},
+		{"channel owner pin", http.MethodPut, "7", 204},
+		{"stranger unpin", http.MethodDelete, "10", 403},
+		{"playlist owner unpin", http.MethodDelete, "8", 204},
+		{"uploader pin", http.MethodPut, "9", 204},
+		{"anonymous unpin", http.MethodDelete, "", 401},
+		{"uploader unpin", http.MethodDelete, "9", 204},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, fmt.Sprintf("/videos/%d/related/%d", video.ID, related.ID), nil)
+		if test.userID != "" {
+			request.Header.Set(controllers.UserIDHeader, test.userID)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Fatalf("%s got status %d, want %d: %s", test.name, recorder.Code, test.want, recorder.Body)
+		}
+	}
+
+	missing := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/videos/%d/related/%d", related.ID+1, video.ID), nil)
+	missing.Header.Set(controllers.UserIDHeader, "7")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, missing)
+	if recorder.Code != 404 {
+		t.Errorf("pinning to a missing video got status %d, want 404", recorder.Code)
+	}
+}
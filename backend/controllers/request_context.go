@@ -0,0 +1,61 @@
+// backend/controllers/request_context.go
+
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserIDHeader carries the ID of the signed-in user, set by the authenticating proxy in front of the API
+const UserIDHeader = "X-User-ID"
+
+const (
+	// DefaultPageSize is the number of items per page when the pageSize query parameter is missing
+	DefaultPageSize = 20
+	// MaxPageSize is the largest accepted pageSize query parameter
+	MaxPageSize = 100
+)
+
+// Page is one page of a paginated listing
+type Page struct {
+	Items    interface{} `json:"items"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"pageSize"`
+	Total    int64       `json:"total"`
+}
+
+// requestUserID returns the ID of the signed-in user, if any
+func requestUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.GetHeader(UserIDHeader), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// requestPage reads the 1-based page and pageSize query parameters
+func requestPage(c *gin.Context) (page, pageSize int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err = strconv.Atoi(c.Query("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return page, pageSize
+}
+
+// paramID parses a numeric path parameter
+func paramID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Params.ByName(name), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,50 @@
+// backend/controllers/search_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/search"
+)
+
+// SearchController handles full-text search across playlists, videos, channels and advertisements
+type SearchController struct {
+	Engine search.Engine
+}
+
+// NewSearchController creates a new SearchController
+func NewSearchController(engine search.Engine) *SearchController {
+	return &SearchController{
+		Engine: engine,
+	}
+}
+
+// Search lists a page of the items matching the q query parameter, best first, with highlighted snippets.
+// The optional type query parameter restricts the search to video, playlist, channel or advertisement.
+func (sc *SearchController) Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	page, pageSize := requestPage(c)
+	results, total, err := sc.Engine.Search(search.Query{
+		Text:   text,
+		Type:   c.Query("type"),
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	})
+	if errors.Is(err, search.ErrUnknownType) {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	c.JSON(200, Page{Items: results, Page: page, PageSize: pageSize, Total: total})
+}
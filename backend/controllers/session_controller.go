@@ -0,0 +1,149 @@
+// backend/controllers/session_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/hls"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// SessionController serves per-viewer streams with advertisements stitched in server-side (SSAI)
+type SessionController struct {
+	PlaylistModel      *models.PlaylistModel
+	VideoModel         *models.VideoModel
+	AdvertisementModel *models.AdvertisementModel
+	SessionModel       *models.SessionModel
+	AccessModel        *models.AccessModel
+}
+
+// NewSessionController creates a new SessionController
+func NewSessionController(db *gorm.DB) *SessionController {
+	return &SessionController{
+		PlaylistModel:      models.NewPlaylistModel(db),
+		VideoModel:         models.NewVideoModel(db),
+		AdvertisementModel: models.NewAdvertisementModel(db),
+		SessionModel:       models.NewSessionModel(db),
+		AccessModel:        models.NewAccessModel(db),
+	}
+}
+
+// CreateSession plans the ad breaks for one viewer of a playlist and returns the session's manifest URL.
+// The optional JSON body {"viewerId": "..."} and the lat, lng, country, region and city query parameters
+// identify the viewer and their location for targeting.
+func (sc *SessionController) CreateSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Params.ByName("id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+	var request struct {
+		ViewerID string `json:"viewerId"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatus(400)
+			return
+		}
+	}
+
+	if _, ok := requestVisible(c, sc.AccessModel, models.ContentPlaylist, uint(id)); !ok {
+		return
+	}
+	playlist, err := sc.PlaylistModel.GetPlaylistForSelection(uint(id))
+	if err != nil {
+		c.AbortWithStatus(404)
+		return
+	}
+	videos, err := sc.VideoModel.GetPlaylistVideos(playlist.ID)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+
+	selection := models.SelectionContext{
+		Playlist: playlist,
+		Location: requestLocation(c),
+		Time:     sc.AdvertisementModel.Clock.Now(),
+	}
+	breaks, err := sc.AdvertisementModel.PlanAdBreaks(selection, videos)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+
+	var location models.Location
+	if selection.Location != nil {
+		location = *selection.Location
+	}
+	session, err := sc.SessionModel.CreateSession(playlist.ID, request.ViewerID, location, breaks)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"id":          session.ID,
+		"manifestURL": fmt.Sprintf("/sessions/%s/stream.m3u8", session.ID),
+	})
+}
+
+// GetSessionStream returns the session's HLS playlist. Ad segments point back at this server
+// so that requesting them fires the session's beacons. Advertisements gone since the session began are left out.
+func (sc *SessionController) GetSessionStream(c *gin.Context) {
+	session, err := sc.SessionModel.GetSession(c.Params.ByName("id"))
+	if err != nil {
+		c.AbortWithStatus(404)
+		return
+	}
+	videos, err := sc.VideoModel.GetPlaylistVideos(session.PlaylistID)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+
+	// Breaks only keeps playable insertions, in order, so they are numbered the same way here
+	insertionIDs := make(map[int][]uint, len(session.Insertions))
+	for _, insertion := range session.Insertions {
+		if insertion.Playable() {
+			insertionIDs[insertion.BreakPosition] = append(insertionIDs[insertion.BreakPosition], insertion.ID)
+		}
+	}
+
+	stream := StitchStream(videos, session.Breaks(), func(position, sequence int, advertisement models.Advertisement) string {
+		return fmt.Sprintf("/sessions/%s/ads/%d", session.ID, insertionIDs[position][sequence-1])
+	})
+	c.Header("Cache-Control", "no-store")
+	c.Data(200, hls.ContentType, []byte(stream.String()))
+}
+
+// GetSessionAdSegment records the impression of an inserted advertisement and redirects to its media,
+// or answers 410 Gone when the advertisement was deleted since the session began
+func (sc *SessionController) GetSessionAdSegment(c *gin.Context) {
+	session, err := sc.SessionModel.GetSession(c.Params.ByName("id"))
+	if err != nil {
+		c.AbortWithStatus(404)
+		return
+	}
+	insertionID, err := strconv.ParseUint(c.Params.ByName("insertionId"), 10, 64)
+	if err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	insertion, err := sc.SessionModel.RecordImpression(session, uint(insertionID))
+	if errors.Is(err, models.ErrAdvertisementGone) {
+		c.AbortWithStatus(410)
+		return
+	}
+	if err != nil {
+		c.AbortWithStatus(404)
+		return
+	}
+	c.Redirect(302, insertion.Advertisement.ContentURL)
+}
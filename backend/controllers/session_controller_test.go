@@ -0,0 +1,138 @@
+// backend/controllers/session_controller_test.go
+
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestGetSessionAdSegmentOfRemovedAdvertisements(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "playlist", IsPublic: true, IsPlayable: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	playable := models.Advertisement{Title: "playable", PlaylistID: playlist.ID, ContentURL: "https://cdn.example.com/ad.mp4", Duration: 15}
+	deleted := models.Advertisement{Title: "deleted", PlaylistID: playlist.ID, ContentURL: "https://cdn.example.com/gone.mp4", Duration: 15}
+	emptied := models.Advertisement{Title: "emptied", PlaylistID: playlist.ID, ContentURL: "https://cdn.example.com/old.mp4", Duration: 15}
+	pod := models.AdPod{}
+	for _, advertisement := range []*models.Advertisement{&playable, &deleted, &emptied} {
+		if err := db.Create(advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+		pod.Advertisements = append(pod.Advertisements, *advertisement)
+	}
+
+	sessionController := controllers.NewSessionController(db)
+	session, err := sessionController.SessionModel.CreateSession(playlist.ID, "viewer", models.Location{}, []models.AdBreak{{Position: 0, Pod: pod}})
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	// After the session began, one advertisement is deleted and another loses its creative
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatalf("deleting advertisement: %v", err)
+	}
+	if err := db.Model(&emptied).Update("content_url", "").Error; err != nil {
+		t.Fatalf("clearing content: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/sessions/:id/ads/:insertionId", sessionController.GetSessionAdSegment)
+	tests := []struct {
+		name        string
+		insertionID uint
+		want        int
+	}{
+		{"playable", session.Insertions[0].ID, 302},
+		{"deleted", session.Insertions[1].ID, 410},
+		{"without content", session.Insertions[2].ID, 410},
+		{"unknown insertion", session.Insertions[2].ID + 1, 404},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/sessions/%s/ads/%d", session.ID, test.insertionID), nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf("%s got status %d, want %d", test.name, recorder.Code, test.want)
+		}
+		if test.want == 302 && recorder.Header().Get("Location") != playable.ContentURL {
+			t.Errorf("%s redirects to %q, want %q", test.name, recorder.Header().Get("Location"), playable.ContentURL)
+		}
+	}
+
+	var impressions []models.AdvertisementTrackingEvent
+	if err := db.Find(&impressions).Error; err != nil {
+		t.Fatalf("reading tracking events: %v", err)
+	}
+	if len(impressions) != 1 || impressions[0].AdvertisementID != playable.ID {
+		t.Errorf("got impressions %+v, want one for advertisement %d", impressions, playable.ID)
+	}
+}
+
+func TestGetSessionStreamOmitsRemovedAdvertisements(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "playlist", IsPublic: true, IsPlayable: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	for i, title := range []string{"first", "second"} {
+		video := models.Video{Title: title, PlaylistID: playlist.ID, URL: "https://cdn.example.com/" + title + ".mp4", Duration: 60, Order: i}
+		if err := db.Create(&video).Error; err != nil {
+			t.Fatalf("creating video: %v", err)
+		}
+	}
+	playable := models.Advertisement{Title: "playable", PlaylistID: playlist.ID, ContentURL: "https://cdn.example.com/ad.mp4", Duration: 15}
+	deleted := models.Advertisement{Title: "deleted", PlaylistID: playlist.ID, ContentURL: "https://cdn.example.com/gone.mp4", Duration: 20}
+	emptied := models.Advertisement{Title: "emptied", PlaylistID: playlist.ID, ContentURL: "https://cdn.example.com/old.mp4", Duration: 25}
+	for _, advertisement := range []*models.Advertisement{&playable, &deleted, &emptied} {
+		if err := db.Create(advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+	}
+
+	sessionController := controllers.NewSessionController(db)
+	// The first break keeps one advertisement, the second loses both of its
+	breaks := []models.AdBreak{
+		{Position: 0, Pod: models.AdPod{Advertisements: []models.Advertisement{deleted, playable}}},
+		{Position: 1, Pod: models.AdPod{Advertisements: []models.Advertisement{emptied, deleted}}},
+	}
+	session, err := sessionController.SessionModel.CreateSession(playlist.ID, "viewer", models.Location{}, breaks)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatalf("deleting advertisement: %v", err)
+	}
+	if err := db.Model(&emptied).Update("content_url", "").Error; err != nil {
+		t.Fatalf("clearing content: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/sessions/:id/stream.m3u8", sessionController.GetSessionStream)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/sessions/"+session.ID+"/stream.m3u8", nil))
+	if recorder.Code != 200 {
+		t.Fatalf("stream got status %d, want 200", recorder.Code)
+	}
+
+	manifest := recorder.Body.String()
+	playableURI := fmt.Sprintf("/sessions/%s/ads/%d", session.ID, session.Insertions[1].ID)
+	if strings.Count(manifest, "/ads/") != 1 || !strings.Contains(manifest, playableURI+"\n") {
+		t.Errorf("manifest should only stitch %s:\n%s", playableURI, manifest)
+	}
+	if strings.Contains(manifest, "deleted") || strings.Contains(manifest, "emptied") {
+		t.Errorf("manifest lists a removed advertisement:\n%s", manifest)
+	}
+	if strings.Count(manifest, "#EXT-X-CUE-OUT:DURATION=15\n") != 1 || strings.Count(manifest, "#EXT-X-CUE-OUT") != 1 {
+		t.Errorf("manifest should have a single 15s break:\n%s", manifest)
+	}
+}
@@ -0,0 +1,251 @@
+// backend/controllers/simulation_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultSimulationInterval is the time between simulated scheduling passes, matching the scheduler's cron job
+	DefaultSimulationInterval = 5 * time.Minute
+	// MaxSimulationRange is the longest period a single simulation may cover
+	MaxSimulationRange = 31 * 24 * time.Hour
+	// MaxSimulationSelections caps the ad pods a single simulation selects, scheduling passes times playlists
+	MaxSimulationSelections = 100000
+)
+
+// ErrSimulationTooLarge is returned when a simulation would select more than MaxSimulationSelections ad pods
+var ErrSimulationTooLarge = errors.New("simulation would select more than 100000 ad pods, use a shorter period, a longer interval or fewer playlists")
+
+// SpacingNote tells simulation readers that playlist spacing is taken from the stored schedule
+const SpacingNote = "Playlists are ranked by their stored lastAdvertisementScheduledAt; the simulated plays do not update it, " +
+	"so channels ranking by least-recently-scheduled may see playlists in a different order than the scheduler would"
+
+// SimulationRequest describes a dry run of the advertisement scheduler
+type SimulationRequest struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	PlaylistIDs []uint    `json:"playlistIds"` // Defaults to the user's playlists eligible for advertisements at each pass
+	Interval    int       `json:"interval"`    // Minutes between scheduling passes, defaults to DefaultSimulationInterval
+}
+
+// SimulatedPlay is one advertisement the scheduler would play
+type SimulatedPlay struct {
+	Time            time.Time `json:"time"`
+	PlaylistID      uint      `json:"playlistId"`
+	AdvertisementID uint      `json:"advertisementId"`
+	Title           string    `json:"title"`
+	Sequence        int       `json:"sequence"` // Position in the ad pod, starting at 1
+	Duration        int       `json:"duration"`
+}
+
+// ProjectedImpressions summarizes the simulated plays of one advertisement
+type ProjectedImpressions struct {
+	AdvertisementID uint      `json:"advertisementId"`
+	Title           string    `json:"title"`
+	Impressions     int       `json:"impressions"`
+	FirstPlayAt     time.Time `json:"firstPlayAt"`
+	LastPlayAt      time.Time `json:"lastPlayAt"`
+}
+
+// SimulationResult is the outcome of a simulation
+type SimulationResult struct {
+	Timeline    []SimulatedPlay        `json:"timeline"`
+	Impressions []ProjectedImpressions `json:"impressions"`
+	Notes       []string               `json:"notes"` // Scheduler behaviour the simulation does not reproduce
+}
+
+// interval returns the time between scheduling passes
+func (sr SimulationRequest) interval() time.Duration {
+	if sr.Interval <= 0 {
+		return DefaultSimulationInterval
+	}
+	return time.Duration(sr.Interval) * time.Minute
+}
+
+// passes returns the number of scheduling passes between start and end
+func (sr SimulationRequest) passes() int {
+	interval := sr.interval()
+	return int((sr.End.Sub(sr.Start) + interval - 1) / interval)
+}
+
+// Validate checks the simulated period
+func (sr SimulationRequest) Validate() error {
+	if sr.Start.IsZero() || sr.End.IsZero() {
+		return errors.New("start and end are required")
+	}
+	if !sr.End.After(sr.Start) {
+		return errors.New("end must be after start")
+	}
+	if sr.End.Sub(sr.Start) > MaxSimulationRange {
+		return errors.New("simulations may cover at most 31 days")
+	}
+	playlists := len(sr.PlaylistIDs)
+	if playlists == 0 {
+		playlists = 1
+	}
+	if sr.passes() > MaxSimulationSelections/playlists {
+		return ErrSimulationTooLarge
+	}
+	return nil
+}
+
+// SimulationController previews advertisement delivery without playing anything
+type SimulationController struct {
+	PlaylistModel      *models.PlaylistModel
+	AdvertisementModel *models.AdvertisementModel
+}
+
+// NewSimulationController creates a new SimulationController
+func NewSimulationController(db *gorm.DB) *SimulationController {
+	return &SimulationController{
+		PlaylistModel:      models.NewPlaylistModel(db),
+		AdvertisementModel: models.NewAdvertisementModel(db),
+	}
+}
+
+// Simulate runs the scheduler's selection logic on a virtual clock stepping from start to end, over the playlists
+// of the channels the user owns. Without requested playlists, the user's playlists eligible for advertisements are
+// selected again at every pass, as the scheduler does; requesting another playlist fails with models.ErrNotEditor.
+// Plays are only tracked in memory, so Played flags, play events and the playback service are untouched.
+func (sc *SimulationController) Simulate(request SimulationRequest, userID uint) (*SimulationResult, error) {
+	var channelIDs []uint
+	if err := sc.PlaylistModel.DB.Model(&models.Channel{}).Where("owner_id = ?", userID).Pluck("id", &channelIDs).Error; err != nil {
+		return nil, err
+	}
+	owned := make(map[uint]bool, len(channelIDs))
+	for _, id := range channelIDs {
+		owned[id] = true
+	}
+
+	loaded := map[uint]*models.Playlist{}
+	selections := 0
+	played := map[uint]bool{}
+	impressions := map[uint]*ProjectedImpressions{}
+	result := &SimulationResult{Timeline: []SimulatedPlay{}, Impressions: []ProjectedImpressions{}, Notes: []string{SpacingNote}}
+
+	for now := request.Start; now.Before(request.End); now = now.Add(request.interval()) {
+		playlists, err := sc.simulationPlaylists(request.PlaylistIDs, owned, now, loaded)
+		if err != nil {
+			return nil, err
+		}
+		selections += len(playlists)
+		if selections > MaxSimulationSelections {
+			return nil, ErrSimulationTooLarge
+		}
+
+		for _, playlist := range playlists {
+			pod, err := sc.AdvertisementModel.GetAdPodForPlaylist(models.SelectionContext{
+				Playlist: playlist,
+				Time:     now,
+				Played:   played,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for sequence, advertisement := range pod.Advertisements {
+				played[advertisement.ID] = true
+				result.Timeline = append(result.Timeline, SimulatedPlay{
+					Time:            now,
+					PlaylistID:      playlist.ID,
+					AdvertisementID: advertisement.ID,
+					Title:           advertisement.Title,
+					Sequence:        sequence + 1,
+					Duration:        advertisement.Duration,
+				})
+
+				projection, ok := impressions[advertisement.ID]
+				if !ok {
+					projection = &ProjectedImpressions{AdvertisementID: advertisement.ID, Title: advertisement.Title, FirstPlayAt: now}
+					impressions[advertisement.ID] = projection
+				}
+				projection.Impressions++
+				projection.LastPlayAt = now
+			}
+		}
+	}
+
+	for _, projection := range impressions {
+		result.Impressions = append(result.Impressions, *projection)
+	}
+	sort.Slice(result.Impressions, func(i, j int) bool {
+		return result.Impressions[i].AdvertisementID < result.Impressions[j].AdvertisementID
+	})
+
+	return result, nil
+}
+
+// simulationPlaylists returns the requested playlists, or the ones eligible for advertisements at now if none are given,
+// in the owned channels. Playlists are loaded for selection once and kept in loaded.
+func (sc *SimulationController) simulationPlaylists(playlistIDs []uint, owned map[uint]bool, now time.Time, loaded map[uint]*models.Playlist) ([]*models.Playlist, error) {
+	if len(playlistIDs) == 0 {
+		eligible, err := sc.PlaylistModel.GetPlaylistsForAdvertisements(now)
+		if err != nil {
+			return nil, err
+		}
+		for _, playlist := range eligible {
+			if owned[playlist.ChannelID] {
+				playlistIDs = append(playlistIDs, playlist.ID)
+			}
+		}
+	}
+
+	playlists := make([]*models.Playlist, 0, len(playlistIDs))
+	for _, id := range playlistIDs {
+		playlist, ok := loaded[id]
+		if !ok {
+			var err error
+			if playlist, err = sc.PlaylistModel.GetPlaylistForSelection(id); err != nil {
+				return nil, err
+			}
+			if !owned[playlist.ChannelID] {
+				return nil, fmt.Errorf("playlist %d: %w", id, models.ErrNotEditor)
+			}
+			loaded[id] = playlist
+		}
+		playlists = append(playlists, playlist)
+	}
+	return playlists, nil
+}
+
+// PostSimulation previews which advertisements would play on the user's playlists over a period
+func (sc *SimulationController) PostSimulation(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	var request SimulationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := sc.Simulate(request, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, models.ErrNotEditor) {
+		abortWithAccessError(c, err)
+		return
+	}
+	if errors.Is(err, ErrSimulationTooLarge) {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, result)
+}
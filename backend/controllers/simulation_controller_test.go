@@ -0,0 +1,145 @@
+// backend/controllers/simulation_controller_test.go
+
+package controllers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestSimulateReselectsPlaylistsEachPass(t *testing.T) {
+	db := newTestDB(t)
+	start := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	channel := models.Channel{Name: "active only", PlaylistFilters: "active", OwnerID: 7}
+	if err := db.Create(&channel).Error; err != nil {
+		t.Fatalf("creating channel: %v", err)
+	}
+	// The first playlist has an advertisement from the start, the second only becomes eligible an hour in
+	var advertisements []models.Advertisement
+	for i, due := range []time.Time{start, start.Add(time.Hour)} {
+		playlist := models.Playlist{Title: "playlist", ChannelID: channel.ID, IsPublic: true, IsPlayable: true}
+		if err := db.Create(&playlist).Error; err != nil {
+			t.Fatalf("creating playlist: %v", err)
+		}
+		advertisement := models.Advertisement{PlaylistID: playlist.ID, Title: "advertisement", Duration: 30 + i, IsPublic: true, ScheduledAt: due}
+		if err := db.Create(&advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+		advertisements = append(advertisements, advertisement)
+	}
+
+	result, err := controllers.NewSimulationController(db).Simulate(controllers.SimulationRequest{Start: start, End: start.Add(2 * time.Hour)}, 7)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	want := []time.Time{start, start.Add(time.Hour)}
+	if len(result.Timeline) != len(want) {
+		t.Fatalf("got %d plays, want %d: %+v", len(result.Timeline), len(want), result.Timeline)
+	}
+	for i, play := range result.Timeline {
+		if play.AdvertisementID != advertisements[i].ID || !play.Time.Equal(want[i]) {
+			t.Errorf("play %d is advertisement %d at %s, want %d at %s", i, play.AdvertisementID, play.Time, advertisements[i].ID, want[i])
+		}
+	}
+}
+
+func TestSimulationSelectionsAreCapped(t *testing.T) {
+	start := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		request controllers.SimulationRequest
+		want    error
+	}{
+		{"default interval over 31 days", controllers.SimulationRequest{Start: start, End: start.Add(controllers.MaxSimulationRange)}, nil},
+		{"every minute for 31 days on 3 playlists", controllers.SimulationRequest{Start: start, End: start.Add(controllers.MaxSimulationRange),
+			Interval: 1, PlaylistIDs: []uint{1, 2, 3}}, controllers.ErrSimulationTooLarge},
+		{"every minute for a day on 3 playlists", controllers.SimulationRequest{Start: start, End: start.Add(24 * time.Hour),
+			Interval: 1, PlaylistIDs: []uint{1, 2, 3}}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.request.Validate(); !errors.Is(err, test.want) {
+				t.Errorf("Validate got %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestPostSimulationCoversOwnedChannels(t *testing.T) {
+	db := newTestDB(t)
+	start := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	var playlists []models.Playlist
+	for _, ownerID := range []uint{7, 8} {
+		channel := models.Channel{Name: "channel", PlaylistFilters: "active", OwnerID: ownerID}
+		if err := db.Create(&channel).Error; err != nil {
+			t.Fatalf("creating channel: %v", err)
+		}
+		playlist := models.Playlist{Title: "playlist", ChannelID: channel.ID, IsPublic: true, IsPlayable: true}
+		if err := db.Create(&playlist).Error; err != nil {
+			t.Fatalf("creating playlist: %v", err)
+		}
+		advertisement := models.Advertisement{PlaylistID: playlist.ID, Title: "advertisement", Duration: 30, IsPublic: true, ScheduledAt: start}
+		if err := db.Create(&advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+		playlists = append(playlists, playlist)
+	}
+
+	router := gin.New()
+	router.POST("/simulate", controllers.NewSimulationController(db).PostSimulation)
+	period := fmt.Sprintf(`"start": %q, "end": %q`, start.Format(time.RFC3339), start.Add(time.Hour).Format(time.RFC3339))
+	tests := []struct {
+		name      string
+		userID    string
+		body      string
+		want      int
+		playlists []uint // Playlists with simulated plays
+	}{
+		{"anonymous", "", "{" + period + "}", 401, nil},
+		{"eligible playlists", "7", "{" + period + "}", 200, []uint{playlists[0].ID}},
+		{"own playlist", "8", fmt.Sprintf(`{%s, "playlistIds": [%d]}`, period, playlists[1].ID), 200, []uint{playlists[1].ID}},
+		{"another user's playlist", "7", fmt.Sprintf(`{%s, "playlistIds": [%d, %d]}`, period, playlists[0].ID, playlists[1].ID), 403, nil},
+		{"without channels", "9", "{" + period + "}", 200, []uint{}},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(test.body))
+		if test.userID != "" {
+			request.Header.Set(controllers.UserIDHeader, test.userID)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf("%s: got status %d, want %d: %s", test.name, recorder.Code, test.want, recorder.Body)
+			continue
+		}
+		if test.want != 200 {
+			continue
+		}
+
+		var result controllers.SimulationResult
+		if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+			t.Fatalf("%s: decoding result: %v", test.name, err)
+		}
+		simulated := []uint{}
+		for _, play := range result.Timeline {
+			simulated = append(simulated, play.PlaylistID)
+		}
+		if fmt.Sprint(simulated) != fmt.Sprint(test.playlists) {
+			t.Errorf("%s: simulated plays on playlists %v, want %v", test.name, simulated, test.playlists)
+		}
+		if len(result.Notes) != 1 || result.Notes[0] != controllers.SpacingNote {
+			t.Errorf("%s: got notes %q, want the spacing note", test.name, result.Notes)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// backend/controllers/stream_controller.go
+
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/hls"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// StreamController serves playlists as HLS streams with ad breaks
+type StreamController struct {
+	PlaylistModel      *models.PlaylistModel
+	VideoModel         *models.VideoModel
+	AdvertisementModel *models.AdvertisementModel
+	AccessModel        *models.AccessModel
+}
+
+// NewStreamController creates a new StreamController
+func NewStreamController(db *gorm.DB) *StreamController {
+	return &StreamController{
+		PlaylistModel:      models.NewPlaylistModel(db),
+		VideoModel:         models.NewVideoModel(db),
+		AdvertisementModel: models.NewAdvertisementModel(db),
+		AccessModel:        models.NewAccessModel(db),
+	}
+}
+
+// GetPlaylistStream returns the playlist's videos, ordered by Order, as an HLS media playlist.
+// Ad breaks are filled from the advertisement selection and marked with EXT-X-CUE-OUT/EXT-X-CUE-IN.
+func (sc *StreamController) GetPlaylistStream(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Params.ByName("id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+	if _, ok := requestVisible(c, sc.AccessModel, models.ContentPlaylist, uint(id)); !ok {
+		return
+	}
+	playlist, err := sc.PlaylistModel.GetPlaylistForSelection(uint(id))
+	if err != nil {
+		c.AbortWithStatus(404)
+		return
+	}
+
+	videos, err := sc.VideoModel.GetPlaylistVideos(playlist.ID)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	breaks, err := sc.AdvertisementModel.PlanAdBreaks(models.SelectionContext{
+		Playlist: playlist,
+		Location: requestLocation(c),
+		Time:     sc.AdvertisementModel.Clock.Now(),
+	}, videos)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+
+	stream := StitchStream(videos, breaks, func(position, sequence int, advertisement models.Advertisement) string {
+		return advertisement.ContentURL
+	})
+	c.Data(200, hls.ContentType, []byte(stream.String()))
+}
+
+// StitchStream interleaves videos and ad breaks into an HLS media playlist. adURI returns the URI of the
+// advertisement at a break position and 1-based sequence, which lets callers route ad requests through their own tracking;
+// initialization sections are read from the advertisement's ContentURL so that loading them fires no beacon.
+// Only files probed as fragmented MP4 make valid segments, see hls.MediaPlaylist.
+func StitchStream(videos []models.Video, breaks []models.AdBreak, adURI func(position, sequence int, advertisement models.Advertisement) string) *hls.MediaPlaylist {
+	stream := &hls.MediaPlaylist{VOD: true}
+	breakAt := make(map[int]models.AdPod, len(breaks))
+	for _, adBreak := range breaks {
+		if len(adBreak.Pod.Advertisements) > 0 {
+			breakAt[adBreak.Position] = adBreak.Pod
+		}
+	}
+
+	inBreak := false
+	for i, video := range videos {
+		if pod, ok := breakAt[i]; ok {
+			for sequence, advertisement := range pod.Advertisements {
+				segment := mediaSegment(adURI(i, sequence+1, advertisement), advertisement.ContentURL, advertisement.Probe)
+				segment.Duration = float64(advertisement.Duration)
+				segment.Title = advertisement.Title
+				segment.Discontinuity = true
+				if sequence == 0 {
+					segment.CueOut = float64(pod.Duration)
+				}
+				stream.Append(segment)
+			}
+			inBreak = true
+		}
+
+		segment := mediaSegment(video.URL, video.URL, video.Probe)
+		segment.Duration = float64(video.PlaybackDuration())
+		segment.Title = video.Title
+		segment.Discontinuity = i > 0
+		segment.CueIn = inBreak
+		stream.Append(segment)
+		inBreak = false
+	}
+
+	return stream
+}
+
+// mediaSegment returns the segment playing a media file from uri. A file probed as fragmented MP4 is served
+// by byte range after its initialization section, read from contentURL; any other file is listed whole.
+func mediaSegment(uri, contentURL string, probe models.MediaProbe) hls.Segment {
+	segment := hls.Segment{URI: uri}
+	if probe.Fragmented() {
+		segment.Map = &hls.Map{URI: contentURL, ByteRange: hls.ByteRange{Length: probe.FragmentOffset}}
+		segment.ByteRange = hls.ByteRange{Length: probe.Size - probe.FragmentOffset, Offset: probe.FragmentOffset}
+	}
+	return segment
+}
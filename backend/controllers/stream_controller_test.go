@@ -0,0 +1,67 @@
+// backend/controllers/stream_controller_test.go
+
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestGetPlaylistStreamTargetsVideoCategories(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	sports := models.Category{Name: "Sports", Slug: "sports"}
+	if err := db.Create(&sports).Error; err != nil {
+		t.Fatalf("creating category: %v", err)
+	}
+	playlist := models.Playlist{Title: "playlist", IsPublic: true, IsPlayable: true, AdBreakInterval: 60}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	// The break after the first video is targeted at its category
+	for i, title := range []string{"match", "interview"} {
+		video := models.Video{Title: title, PlaylistID: playlist.ID, URL: "https://cdn.example.com/" + title + ".mp4", Duration: 60, Order: i}
+		if i == 0 {
+			video.CategoryID = sports.ID
+		}
+		if err := db.Create(&video).Error; err != nil {
+			t.Fatalf("creating video: %v", err)
+		}
+	}
+	targeted := models.Advertisement{Title: "sports drink", PlaylistID: playlist.ID, IsPublic: true, Duration: 15,
+		ContentURL: "https://cdn.example.com/drink.mp4", Targeting: "category = sports", ScheduledAt: now.Add(-time.Hour)}
+	mismatched := models.Advertisement{Title: "cookbook", PlaylistID: playlist.ID, IsPublic: true, Duration: 15,
+		ContentURL: "https://cdn.example.com/cookbook.mp4", Targeting: "category = cooking", ScheduledAt: now.Add(-time.Hour)}
+	for _, advertisement := range []*models.Advertisement{&targeted, &mismatched} {
+		if err := db.Create(advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+	}
+
+	streamController := controllers.NewStreamController(db)
+	streamController.AdvertisementModel.Clock = models.ClockFunc(func() time.Time { return now })
+	router := gin.New()
+	router.GET("/playlists/:id/stream.m3u8", streamController.GetPlaylistStream)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/playlists/%d/stream.m3u8", playlist.ID), nil))
+	if recorder.Code != 200 {
+		t.Fatalf("stream got status %d, want 200", recorder.Code)
+	}
+
+	manifest := recorder.Body.String()
+	if !strings.Contains(manifest, targeted.ContentURL+"\n") {
+		t.Errorf("stream does not stitch the advertisement targeting the video's category:\n%s", manifest)
+	}
+	if strings.Contains(manifest, mismatched.ContentURL) {
+		t.Errorf("stream stitches an advertisement targeting another category:\n%s", manifest)
+	}
+}
@@ -0,0 +1,134 @@
+// backend/controllers/vast_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"github.com/shuttlersit/ads-player/backend/vast"
+	"gorm.io/gorm"
+)
+
+// VASTController serves ad breaks to VAST-capable players
+type VASTController struct {
+	DB                 *gorm.DB
+	PlaylistModel      *models.PlaylistModel
+	AdvertisementModel *models.AdvertisementModel
+	AccessModel        *models.AccessModel
+}
+
+// NewVASTController creates a new VASTController
+func NewVASTController(db *gorm.DB) *VASTController {
+	return &VASTController{
+		DB:                 db,
+		PlaylistModel:      models.NewPlaylistModel(db),
+		AdvertisementModel: models.NewAdvertisementModel(db),
+		AccessModel:        models.NewAccessModel(db),
+	}
+}
+
+// GetPlaylistVAST returns the next ad break of a playlist as a VAST ad pod.
+// Optional query parameters: videoId for the video the break plays around,
+// and lat, lng, country, region and city for the location of the requesting device.
+// Embedded players only get the break when the playlist allows embedding on their site,
+// and only with the advertisements that do too.
+func (vc *VASTController) GetPlaylistVAST(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Params.ByName("id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+	if _, ok := requestVisible(c, vc.AccessModel, models.ContentPlaylist, uint(id)); !ok {
+		return
+	}
+	playlist, err := vc.PlaylistModel.GetPlaylistForSelection(uint(id))
+	if err != nil {
+		c.AbortWithStatus(404)
+		return
+	}
+	origin := requestEmbedOrigin(c)
+	if !playlist.Access.AllowsEmbedding(origin) {
+		abortWithAccessError(c, models.ErrEmbeddingNotAllowed)
+		return
+	}
+
+	selection := models.SelectionContext{
+		Playlist: playlist,
+		Location: requestLocation(c),
+		Time:     vc.AdvertisementModel.Clock.Now(),
+		Origin:   origin,
+	}
+	if videoID := c.Query("videoId"); videoID != "" {
+		var video models.Video
+		if err := vc.DB.Preload("Category").Where("playlist_id = ?", playlist.ID).First(&video, videoID).Error; err != nil {
+			c.AbortWithStatus(404)
+			return
+		}
+		selection.Video = &video
+	}
+
+	pod, err := vc.AdvertisementModel.GetAdPodForPlaylist(selection)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+
+	baseURL := requestBaseURL(c)
+	body, err := vast.NewAdPod(pod, func(advertisement models.Advertisement) string {
+		return fmt.Sprintf("%s/playlists/%d/ads/%d/impression", baseURL, playlist.ID, advertisement.ID)
+	}).Marshal()
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	c.Data(200, "application/xml; charset=utf-8", body)
+}
+
+// TrackImpression records the impression beacon of an advertisement served in a playlist's VAST ad pod
+func (vc *VASTController) TrackImpression(c *gin.Context) {
+	playlistID, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	advertisementID, ok := paramID(c, "adId")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+
+	if err := vc.AdvertisementModel.RecordImpression(advertisementID, playlistID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.AbortWithStatus(404)
+			return
+		}
+		c.AbortWithStatus(500)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Status(204)
+}
+
+// requestLocation reads the requesting device's location from the query string, if any
+func requestLocation(c *gin.Context) *models.Location {
+	latitude, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	longitude, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	location := models.Location{
+		Country: c.Query("country"),
+		Region:  c.Query("region"),
+		City:    c.Query("city"),
+	}
+	if latErr == nil && lngErr == nil {
+		location.Latitude = latitude
+		location.Longitude = longitude
+	}
+
+	if location == (models.Location{}) {
+		return nil
+	}
+	return &location
+}
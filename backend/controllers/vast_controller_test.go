@@ -0,0 +1,102 @@
+// backend/controllers/vast_controller_test.go
+
+package controllers_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"github.com/shuttlersit/ads-player/backend/vast"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestGetPlaylistVASTDayparts(t *testing.T) {
+	for _, test := range daypartCases {
+		t.Run(test.name, func(t *testing.T) {
+			db := newTestDB(t)
+			playlist, advertisement := createDaypartPlaylist(t, db, test)
+
+			vastController := controllers.NewVASTController(db)
+			vastController.AdvertisementModel.Clock = models.ClockFunc(func() time.Time { return test.now })
+			r := gin.New()
+			r.GET("/playlists/:id/vast", vastController.GetPlaylistVAST)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/playlists/%d/vast", playlist.ID), nil))
+			if w.Code != 200 {
+				t.Fatalf("got status %d, want 200", w.Code)
+			}
+			var document vast.VAST
+			if err := xml.Unmarshal(w.Body.Bytes(), &document); err != nil {
+				t.Fatalf("parsing VAST: %v", err)
+			}
+
+			served := len(document.Ads) == 1 && document.Ads[0].ID == strconv.FormatUint(uint64(advertisement.ID), 10)
+			if served != test.want || (!test.want && len(document.Ads) > 0) {
+				t.Fatalf("served %d ads, want advertisement %d served: %v", len(document.Ads), advertisement.ID, test.want)
+			}
+		})
+	}
+}
+
+func TestGetPlaylistVASTTracksImpressions(t *testing.T) {
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	db := newTestDB(t)
+	playlist, advertisement := createDaypartPlaylist(t, db, daypartCase{
+		name:     "always",
+		timezone: "UTC",
+		rule:     models.DaypartRule{DayOfWeek: time.Friday, StartTime: "00:00", EndTime: "24:00"},
+		now:      now,
+	})
+
+	vastController := controllers.NewVASTController(db)
+	vastController.AdvertisementModel.Clock = models.ClockFunc(func() time.Time { return now })
+	r := gin.New()
+	r.GET("/playlists/:id/vast", vastController.GetPlaylistVAST)
+	r.GET("/playlists/:id/ads/:adId/impression", vastController.TrackImpression)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("http://ads.example.com/playlists/%d/vast", playlist.ID), nil))
+	var document vast.VAST
+	if err := xml.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatalf("parsing VAST: %v", err)
+	}
+	if len(document.Ads) != 1 || len(document.Ads[0].InLine.Impressions) != 1 {
+		t.Fatalf("got %+v, want one ad with one impression", document.Ads)
+	}
+	impression := document.Ads[0].InLine.Impressions[0].Text
+	want := fmt.Sprintf("http://ads.example.com/playlists/%d/ads/%d/impression", playlist.ID, advertisement.ID)
+	if impression != want {
+		t.Fatalf("impression URL is %q, want %q", impression, want)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", impression, nil))
+	if w.Code != 204 {
+		t.Fatalf("impression got status %d, want 204", w.Code)
+	}
+	var events []models.AdvertisementTrackingEvent
+	if err := db.Find(&events).Error; err != nil {
+		t.Fatalf("reading tracking events: %v", err)
+	}
+	if len(events) != 1 || events[0].AdvertisementID != advertisement.ID || events[0].Event != models.TrackingEventImpression {
+		t.Errorf("got tracking events %+v, want one impression of advertisement %d", events, advertisement.ID)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/playlists/%d/ads/%d/impression", playlist.ID+1, advertisement.ID), nil))
+	if w.Code != 404 {
+		t.Errorf("impression of an advertisement of another playlist got status %d, want 404", w.Code)
+	}
+}
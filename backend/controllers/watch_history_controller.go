@@ -0,0 +1,93 @@
+// backend/controllers/watch_history_controller.go
+
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// WatchHistoryController handles the signed-in user's watch history and resume positions
+type WatchHistoryController struct {
+	WatchHistoryModel *models.WatchHistoryModel
+	AccessModel       *models.AccessModel
+}
+
+// NewWatchHistoryController creates a new WatchHistoryController
+func NewWatchHistoryController(db *gorm.DB) *WatchHistoryController {
+	return &WatchHistoryController{
+		WatchHistoryModel: models.NewWatchHistoryModel(db),
+		AccessModel:       models.NewAccessModel(db),
+	}
+}
+
+// ReportProgress records the signed-in user's progress through a video,
+// with a {"position": 120, "watched": 10, "completed": false} body
+func (wc *WatchHistoryController) ReportProgress(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+	videoID, ok := paramID(c, "id")
+	if !ok {
+		c.AbortWithStatus(400)
+		return
+	}
+	var progress models.WatchProgress
+	if err := c.ShouldBindJSON(&progress); err != nil || progress.Position < 0 || progress.Watched < 0 {
+		c.AbortWithStatus(400)
+		return
+	}
+	if _, ok := requestVisible(c, wc.AccessModel, models.ContentVideo, videoID); !ok {
+		return
+	}
+
+	entry, err := wc.WatchHistoryModel.ReportProgress(userID, videoID, progress)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithStatus(404)
+		return
+	}
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	c.JSON(200, entry)
+}
+
+// GetContinueWatching lists a page of the videos the signed-in user started but did not finish
+func (wc *WatchHistoryController) GetContinueWatching(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+
+	page, pageSize := requestPage(c)
+	entries, total, err := wc.WatchHistoryModel.GetContinueWatching(userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	c.JSON(200, Page{Items: entries, Page: page, PageSize: pageSize, Total: total})
+}
+
+// GetWatchHistory lists a page of the signed-in user's watch history
+func (wc *WatchHistoryController) GetWatchHistory(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		c.AbortWithStatus(401)
+		return
+	}
+
+	page, pageSize := requestPage(c)
+	entries, total, err := wc.WatchHistoryModel.GetWatchHistory(userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+	c.JSON(200, Page{Items: entries, Page: page, PageSize: pageSize, Total: total})
+}
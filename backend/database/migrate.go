@@ -0,0 +1,155 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// Models lists every model whose table is managed by Migrate
+var Models = []interface{}{
+	&models.User{},
+	&models.Category{},
+	&models.Channel{},
+	&models.Playlist{},
+	&models.Video{},
+	&models.Advertisement{},
+	&models.ExternalLink{},
+	&models.AdvertisementMediaAttachment{},
+	&models.AdvertisementHashtag{},
+	&models.RelatedVideo{},
+	&models.RelatedPlaylist{},
+	&models.RelatedAd{},
+	&models.Comment{},
+	&models.CommentReport{},
+	&models.MediaAttachment{},
+	&models.Hashtag{},
+	&models.Emoji{},
+	&models.AdvertisementPlayEvent{},
+	&models.DaypartRule{},
+	&models.GeoTargetRule{},
+	&models.PlaybackSession{},
+	&models.SessionAdInsertion{},
+	&models.AdvertisementTrackingEvent{},
+	&models.PlaylistStats{},
+	&models.WatchHistoryEntry{},
+	&models.AccessGrant{},
+	&models.PlaylistInvitation{},
+	&models.PlaylistEdit{},
+}
+
+// Migrate creates or updates the tables of every model and backfills changed columns
+func Migrate(db *gorm.DB) error {
+	if err := migrateCategorySlugs(db); err != nil {
+		return err
+	}
+	if err := migrateLegacyCounts(db); err != nil {
+		return err
+	}
+	// Contributors carry who invited them and when they joined
+	if err := db.SetupJoinTable(&models.Playlist{}, "Contributors", &models.UserPlaylistContributors{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(Models...); err != nil {
+		return err
+	}
+	if err := migrateCommentOwners(db); err != nil {
+		return err
+	}
+	return migrateWatchHistory(db)
+}
+
+// migrateCommentOwners clears the video ID of comments created before it became nullable,
+// when comments on playlists and advertisements were stored with a video ID of 0
+func migrateCommentOwners(db *gorm.DB) error {
+	return db.Model(&models.Comment{}).Where("video_id = 0").Update("video_id", nil).Error
+}
+
+// migrateWatchHistory moves the videos of the old user_watch_history join table, which had no
+// position or timestamps, into watch_history and drops the join table
+func migrateWatchHistory(db *gorm.DB) error {
+	if !db.Migrator().HasTable("user_watch_history") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Exec(`INSERT INTO watch_history (user_id, video_id, position, watched_seconds, completed, views, current_seconds, view_counted, created_at, updated_at)
+			SELECT DISTINCT user_id, video_id, 0, 0, ?, 0, 0, ?, ?, ? FROM user_watch_history
+			WHERE NOT EXISTS (SELECT 1 FROM watch_history WHERE watch_history.user_id = user_watch_history.user_id AND watch_history.video_id = user_watch_history.video_id)`,
+			false, false, now, now).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable("user_watch_history")
+	})
+}
+
+// migrateLegacyCounts adds the legacy view and play count columns to videos and playlists created before them,
+// filled with the counts the watch history does not account for, so RollUpWatchHistory keeps them
+func migrateLegacyCounts(db *gorm.DB) error {
+	historyViews := "0"
+	if db.Migrator().HasTable(&models.WatchHistoryEntry{}) {
+		historyViews = "(SELECT COALESCE(SUM(watch_history.views), 0) FROM watch_history WHERE watch_history.video_id = videos.id)"
+	}
+	legacy := []struct {
+		model  interface{}
+		field  string
+		column string
+		count  string
+	}{
+		{&models.Video{}, "LegacyViews", "legacy_views", "views - " + historyViews},
+		{&models.Playlist{}, "LegacyPlayCount", "legacy_play_count",
+			"play_count - (SELECT COALESCE(SUM(" + historyViews + "), 0) FROM videos WHERE videos.playlist_id = playlists.id)"},
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, counts := range legacy {
+			if !tx.Migrator().HasTable(counts.model) || tx.Migrator().HasColumn(counts.model, counts.field) {
+				continue
+			}
+			if err := tx.Migrator().AddColumn(counts.model, counts.field); err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(counts.model).Where("1 = 1").UpdateColumn(counts.column,
+				gorm.Expr("CASE WHEN "+counts.count+" > 0 THEN "+counts.count+" ELSE 0 END")).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateCategorySlugs adds the slug column to categories created before it existed and fills it
+// from their names, before AutoMigrate adds its unique index
+func migrateCategorySlugs(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Category{}) || db.Migrator().HasColumn(&models.Category{}, "Slug") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&models.Category{}, "Slug"); err != nil {
+			return err
+		}
+		var categories []models.Category
+		if err := tx.Unscoped().Select("id", "name").Order("id").Find(&categories).Error; err != nil {
+			return err
+		}
+		taken := map[string]bool{}
+		for _, category := range categories {
+			slug := models.Slugify(category.Name)
+			if strings.IndexFunc(slug, unicode.IsLetter) < 0 {
+				slug = strings.Trim("category-"+slug, "-")
+			}
+			if taken[slug] {
+				slug += "-" + strconv.FormatUint(uint64(category.ID), 10)
+			}
+			taken[slug] = true
+			if err := tx.Unscoped().Model(&category).UpdateColumn("slug", slug).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
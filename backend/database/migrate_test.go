@@ -0,0 +1,181 @@
+// backend/database/migrate_test.go
+
+package database_test
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/shuttlersit/ads-player/backend/database"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// legacyComment is the comments table before comments got one nullable owner column: video_id was
+// not nullable, with a foreign key to videos, so comments on playlists and advertisements stored 0
+type legacyComment struct {
+	gorm.Model
+	UserID          uint
+	VideoID         uint
+	Video           models.Video
+	PlaylistID      *uint `gorm:"index"`
+	AdvertisementID *uint `gorm:"index"`
+	Text            string
+	ParentCommentID uint
+	IsHidden        bool `gorm:"default:false"`
+	IsDeleted       bool `gorm:"default:false"`
+}
+
+// TableName implements schema.Tabler
+func (legacyComment) TableName() string {
+	return "comments"
+}
+
+// openTestDB opens an empty in-memory SQLite database that lives as long as the test
+func openTestDB(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+tb.Name()+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		tb.Fatalf("opening database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		tb.Fatalf("opening database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	tb.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestMigrateCommentOwners(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&models.Video{}, &models.Playlist{}, &models.Advertisement{}, &legacyComment{}); err != nil {
+		t.Fatalf("creating legacy tables: %v", err)
+	}
+	statements := []string{
+		"INSERT INTO playlists (id, title, is_public) VALUES (1, 'playlist', 1)",
+		"INSERT INTO videos (id, title, playlist_id) VALUES (1, 'video', 1)",
+		"INSERT INTO advertisements (id, title, playlist_id, is_public) VALUES (1, 'advertisement', 1, 1)",
+		// Comments on playlists and advertisements point at the missing video 0
+		"INSERT INTO comments (id, text, video_id, playlist_id, advertisement_id, parent_comment_id, created_at) VALUES " +
+			"(1, 'on video', 1, NULL, NULL, 0, '2026-03-06 12:00:00'), " +
+			"(2, 'on playlist', 0, 1, NULL, 0, '2026-03-06 12:01:00'), " +
+			"(3, 'on advertisement', 0, NULL, 1, 0, '2026-03-06 12:02:00')",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("seeding legacy rows: %v", err)
+		}
+	}
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	var orphans int64
+	if err := db.Model(&models.Comment{}).Where("video_id = 0").Count(&orphans).Error; err != nil {
+		t.Fatalf("counting comments: %v", err)
+	}
+	if orphans != 0 {
+		t.Errorf("%d comments still reference video 0", orphans)
+	}
+
+	video, err := models.NewVideoModel(db).GetVideoWithComments(1)
+	if err != nil {
+		t.Fatalf("GetVideoWithComments: %v", err)
+	}
+	playlist, err := models.NewPlaylistModel(db).GetPlaylistWithComments(1)
+	if err != nil {
+		t.Fatalf("GetPlaylistWithComments: %v", err)
+	}
+	advertisement, err := models.NewAdvertisementModel(db).GetAdvertisementByID(1)
+	if err != nil {
+		t.Fatalf("GetAdvertisementByID: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		comments []models.Comment
+		owner    models.Commentable
+		text     string
+	}{
+		{"video", video.Comments, models.Commentable{Type: models.CommentableVideo, ID: 1}, "on video"},
+		{"playlist", playlist.Comments, models.Commentable{Type: models.CommentablePlaylist, ID: 1}, "on playlist"},
+		{"advertisement", advertisement.Comments, models.Commentable{Type: models.CommentableAdvertisement, ID: 1}, "on advertisement"},
+	}
+	for _, test := range tests {
+		if len(test.comments) != 1 || test.comments[0].Text != test.text {
+			t.Errorf("%s comments are %+v, want only %q", test.name, test.comments, test.text)
+			continue
+		}
+		if owner, ok := test.comments[0].Commentable(); !ok || owner != test.owner {
+			t.Errorf("%s comment belongs to %+v (exactly one: %v), want %+v", test.name, owner, ok, test.owner)
+		}
+	}
+
+	// New comments are stored with a single owner after the migration
+	playlistID := uint(1)
+	if err := db.Create(&models.Comment{Text: "new", PlaylistID: &playlistID}).Error; err != nil {
+		t.Fatalf("creating comment: %v", err)
+	}
+	var stored models.Comment
+	if err := db.Where("text = ?", "new").First(&stored).Error; err != nil {
+		t.Fatalf("reading comment: %v", err)
+	}
+	if stored.VideoID != nil {
+		t.Errorf("new playlist comment has video ID %d, want none", *stored.VideoID)
+	}
+}
+
+func TestMigrateLegacyCounts(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&models.Playlist{}, &models.Video{}, &models.WatchHistoryEntry{}); err != nil {
+		t.Fatalf("creating tables: %v", err)
+	}
+	for _, legacy := range []struct {
+		model interface{}
+		field string
+	}{{&models.Video{}, "LegacyViews"}, {&models.Playlist{}, "LegacyPlayCount"}} {
+		if err := db.Migrator().DropColumn(legacy.model, legacy.field); err != nil {
+			t.Fatalf("dropping %s: %v", legacy.field, err)
+		}
+	}
+	statements := []string{
+		"INSERT INTO playlists (id, title, is_public, play_count) VALUES (1, 'playlist', 1, 12)",
+		// Video 1 has 2 of its 10 views in the history, video 2 more history than views after drift
+		"INSERT INTO videos (id, title, playlist_id, views) VALUES (1, 'counted before', 1, 10), (2, 'drifted', 1, 2)",
+		"INSERT INTO watch_history (user_id, video_id, views) VALUES (1, 1, 1), (2, 1, 1), (1, 2, 3)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("seeding legacy rows: %v", err)
+		}
+	}
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if err := models.NewWatchHistoryModel(db).RollUpWatchHistory(); err != nil {
+		t.Fatalf("RollUpWatchHistory: %v", err)
+	}
+
+	var videos []models.Video
+	if err := db.Order("id").Find(&videos).Error; err != nil {
+		t.Fatalf("reading videos: %v", err)
+	}
+	for i, want := range []struct{ legacy, views uint }{{8, 10}, {0, 3}} {
+		if videos[i].LegacyViews != want.legacy || videos[i].Views != want.views {
+			t.Errorf("video %q has %d legacy views and %d views, want %d and %d",
+				videos[i].Title, videos[i].LegacyViews, videos[i].Views, want.legacy, want.views)
+		}
+	}
+	var playlist models.Playlist
+	if err := db.First(&playlist, 1).Error; err != nil {
+		t.Fatalf("reading playlist: %v", err)
+	}
+	if playlist.LegacyPlayCount != 7 || playlist.PlayCount != 12 {
+		t.Errorf("playlist has %d legacy plays and %d plays, want 7 and 12", playlist.LegacyPlayCount, playlist.PlayCount)
+	}
+}
@@ -0,0 +1,199 @@
+// backend/feed/feed.go
+
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Item kinds
+const (
+	KindVideo         = "video"
+	KindAdvertisement = "advertisement"
+)
+
+// Feed is a playlist in the form signage players ingest
+type Feed struct {
+	Title       string
+	Description string
+	Link        string // URL of the feed itself
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item is a video or advertisement in playback order
+type Item struct {
+	ID              string
+	Kind            string
+	Title           string
+	Description     string
+	URL             string
+	ContentType     string
+	ThumbnailURL    string
+	Duration        int // Seconds
+	ClickThroughURL string
+}
+
+// rss is the root of a Media RSS document
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Media   string     `xml:"xmlns:media,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string          `xml:"title"`
+	Description string          `xml:"description,omitempty"`
+	Link        string          `xml:"link,omitempty"`
+	GUID        rssGUID         `xml:"guid"`
+	Category    string          `xml:"category"`
+	Content     mediaContent    `xml:"media:content"`
+	Thumbnail   *mediaThumbnail `xml:"media:thumbnail,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type mediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr,omitempty"`
+	Medium   string `xml:"medium,attr"`
+	Duration int    `xml:"duration,attr,omitempty"`
+}
+
+type mediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+// RSS renders the feed as Media RSS (http://search.yahoo.com/mrss/)
+func (f Feed) RSS() ([]byte, error) {
+	document := rss{
+		Version: "2.0",
+		Media:   "http://search.yahoo.com/mrss/",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Description,
+			Items:       []rssItem{},
+		},
+	}
+	if !f.Updated.IsZero() {
+		document.Channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, item := range f.Items {
+		entry := rssItem{
+			Title:       item.Title,
+			Description: item.Description,
+			Link:        item.ClickThroughURL,
+			GUID:        rssGUID{Value: item.ID},
+			Category:    item.Kind,
+			Content: mediaContent{
+				URL:      item.URL,
+				Type:     item.ContentType,
+				Medium:   "video",
+				Duration: item.Duration,
+			},
+		}
+		if item.ThumbnailURL != "" {
+			entry.Thumbnail = &mediaThumbnail{URL: item.ThumbnailURL}
+		}
+		document.Channel.Items = append(document.Channel.Items, entry)
+	}
+
+	body, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// jsonFeed is a JSON Feed 1.1 document (https://jsonfeed.org/version/1.1)
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	Title         string               `json:"title,omitempty"`
+	ContentText   string               `json:"content_text"`
+	ExternalURL   string               `json:"external_url,omitempty"`
+	Image         string               `json:"image,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments"`
+	AdsPlayerInfo jsonFeedExtension    `json:"_ads_player"`
+}
+
+type jsonFeedAttachment struct {
+	URL               string `json:"url"`
+	MimeType          string `json:"mime_type"`
+	DurationInSeconds int    `json:"duration_in_seconds,omitempty"`
+}
+
+// jsonFeedExtension carries fields JSON Feed has no place for
+type jsonFeedExtension struct {
+	Kind string `json:"kind"`
+}
+
+// JSON renders the feed as JSON Feed 1.1
+func (f Feed) JSON() ([]byte, error) {
+	document := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		Description: f.Description,
+		FeedURL:     f.Link,
+		Items:       []jsonFeedItem{},
+	}
+	for _, item := range f.Items {
+		contentType := item.ContentType
+		if contentType == "" {
+			contentType = "video/mp4"
+		}
+		document.Items = append(document.Items, jsonFeedItem{
+			ID:            item.ID,
+			Title:         item.Title,
+			ContentText:   item.Description,
+			ExternalURL:   item.ClickThroughURL,
+			Image:         item.ThumbnailURL,
+			Attachments:   []jsonFeedAttachment{{URL: item.URL, MimeType: contentType, DurationInSeconds: item.Duration}},
+			AdsPlayerInfo: jsonFeedExtension{Kind: item.Kind},
+		})
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// NotModified reports whether a conditional request already has the current version,
+// checking If-None-Match first and If-Modified-Since only when no ETag was sent
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+// backend/hls/playlist.go
+
+package hls
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ContentType is the MIME type of HLS playlists
+const ContentType = "application/vnd.apple.mpegurl"
+
+// ByteRange is part of a resource, Length bytes from Offset
+type ByteRange struct {
+	Length int64
+	Offset int64
+}
+
+// String formats the range as in EXT-X-BYTERANGE, <length>@<offset>
+func (r ByteRange) String() string {
+	return fmt.Sprintf("%d@%d", r.Length, r.Offset)
+}
+
+// Map is the fMP4 initialization section that media segments need before they can be decoded
+type Map struct {
+	URI       string
+	ByteRange ByteRange
+}
+
+// Segment is one media segment of a media playlist
+type Segment struct {
+	URI      string
+	Duration float64 // Seconds
+	Title    string
+	// ByteRange limits the segment to part of URI; a zero Length uses the whole resource
+	ByteRange ByteRange
+	// Map is the initialization section of an fMP4 segment, written as EXT-X-MAP when it changes
+	Map *Map
+	// Discontinuity marks a change of encoding, timestamps or source before this segment
+	Discontinuity bool
+	// CueOut, when positive, starts an ad break of that many seconds before this segment
+	CueOut float64
+	// CueIn ends the ad break before this segment
+	CueIn bool
+}
+
+// MediaPlaylist is an HLS media playlist (RFC 8216) with SCTE-35 style cue tags for ad breaks.
+// Segments must be MPEG-TS or fMP4 media segments: fMP4 files are served as one segment each, by byte
+// range after the EXT-X-MAP of their initialization section. A progressive MP4 is not a valid segment,
+// it has to be packaged as fMP4 first, so listing one whole only works in players that tolerate it.
+type MediaPlaylist struct {
+	Segments []Segment
+	// VOD marks the playlist as complete: EXT-X-PLAYLIST-TYPE:VOD and EXT-X-ENDLIST are written
+	VOD bool
+}
+
+// Append adds a segment to the playlist
+func (p *MediaPlaylist) Append(segment Segment) {
+	p.Segments = append(p.Segments, segment)
+}
+
+// TargetDuration returns the EXT-X-TARGETDURATION: the longest segment rounded up to whole seconds
+func (p *MediaPlaylist) TargetDuration() int {
+	target := 1
+	for _, segment := range p.Segments {
+		if seconds := int(math.Ceil(segment.Duration)); seconds > target {
+			target = seconds
+		}
+	}
+	return target
+}
+
+// Version returns the EXT-X-VERSION the playlist needs: 7 with fMP4 segments, 4 with byte ranges, 3 otherwise
+func (p *MediaPlaylist) Version() int {
+	version := 3
+	for _, segment := range p.Segments {
+		switch {
+		case segment.Map != nil:
+			return 7
+		case segment.ByteRange.Length > 0:
+			version = 4
+		}
+	}
+	return version
+}
+
+// String renders the playlist
+func (p *MediaPlaylist) String() string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#EXT-X-VERSION:%d\n", p.Version())
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", p.TargetDuration())
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+	if p.VOD {
+		b.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+	}
+
+	var initialization *Map
+	for _, segment := range p.Segments {
+		if segment.CueIn {
+			b.WriteString("#EXT-X-CUE-IN\n")
+		}
+		if segment.Discontinuity {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		if segment.CueOut > 0 {
+			fmt.Fprintf(&b, "#EXT-X-CUE-OUT:DURATION=%s\n", formatSeconds(segment.CueOut))
+		}
+		// EXT-X-MAP applies until the next one, so it is only written when the initialization section changes
+		if segment.Map != nil && (initialization == nil || *segment.Map != *initialization) {
+			fmt.Fprintf(&b, "#EXT-X-MAP:URI=%q", segment.Map.URI)
+			if segment.Map.ByteRange.Length > 0 {
+				fmt.Fprintf(&b, ",BYTERANGE=\"%s\"", segment.Map.ByteRange)
+			}
+			b.WriteString("\n")
+		}
+		initialization = segment.Map
+		fmt.Fprintf(&b, "#EXTINF:%s,%s\n", formatSeconds(segment.Duration), sanitizeTitle(segment.Title))
+		if segment.ByteRange.Length > 0 {
+			fmt.Fprintf(&b, "#EXT-X-BYTERANGE:%s\n", segment.ByteRange)
+		}
+		b.WriteString(segment.URI + "\n")
+	}
+
+	if p.VOD {
+		b.WriteString("#EXT-X-ENDLIST\n")
+	}
+	return b.String()
+}
+
+// formatSeconds formats a duration with up to three decimals
+func formatSeconds(seconds float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", seconds), "0"), ".")
+}
+
+// sanitizeTitle keeps EXTINF titles on a single line
+func sanitizeTitle(title string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(title)
+}
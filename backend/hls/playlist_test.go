@@ -0,0 +1,62 @@
+// backend/hls/playlist_test.go
+
+package hls
+
+import "testing"
+
+func TestMediaPlaylistFragmentedSegments(t *testing.T) {
+	playlist := &MediaPlaylist{VOD: true}
+	initialization := &Map{URI: "https://cdn.example.com/intro.mp4", ByteRange: ByteRange{Length: 1200}}
+	playlist.Append(Segment{URI: "https://cdn.example.com/intro.mp4", Duration: 12.5, Title: "Intro",
+		Map: initialization, ByteRange: ByteRange{Length: 98800, Offset: 1200}})
+	playlist.Append(Segment{URI: "/sessions/abc/ads/1", Duration: 30, Title: "Ad", Discontinuity: true, CueOut: 30,
+		Map: &Map{URI: "https://cdn.example.com/ad.mp4", ByteRange: ByteRange{Length: 800}}, ByteRange: ByteRange{Length: 5000, Offset: 800}})
+	playlist.Append(Segment{URI: "https://cdn.example.com/intro.mp4", Duration: 12.5, Title: "Intro again",
+		Discontinuity: true, CueIn: true, Map: &Map{URI: initialization.URI, ByteRange: initialization.ByteRange},
+		ByteRange: ByteRange{Length: 98800, Offset: 1200}})
+
+	want := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:30
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-MAP:URI="https://cdn.example.com/intro.mp4",BYTERANGE="1200@0"
+#EXTINF:12.5,Intro
+#EXT-X-BYTERANGE:98800@1200
+https://cdn.example.com/intro.mp4
+#EXT-X-DISCONTINUITY
+#EXT-X-CUE-OUT:DURATION=30
+#EXT-X-MAP:URI="https://cdn.example.com/ad.mp4",BYTERANGE="800@0"
+#EXTINF:30,Ad
+#EXT-X-BYTERANGE:5000@800
+/sessions/abc/ads/1
+#EXT-X-CUE-IN
+#EXT-X-DISCONTINUITY
+#EXT-X-MAP:URI="https://cdn.example.com/intro.mp4",BYTERANGE="1200@0"
+#EXTINF:12.5,Intro again
+#EXT-X-BYTERANGE:98800@1200
+https://cdn.example.com/intro.mp4
+#EXT-X-ENDLIST
+`
+	if got := playlist.String(); got != want {
+		t.Errorf("got playlist\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMediaPlaylistVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []Segment
+		want     int
+	}{
+		{"whole files", []Segment{{URI: "a.ts"}}, 3},
+		{"byte ranges", []Segment{{URI: "a.ts", ByteRange: ByteRange{Length: 10}}}, 4},
+		{"fmp4", []Segment{{URI: "a.ts"}, {URI: "b.mp4", Map: &Map{URI: "b.mp4"}}}, 7},
+	}
+	for _, test := range tests {
+		playlist := &MediaPlaylist{Segments: test.segments}
+		if got := playlist.Version(); got != test.want {
+			t.Errorf("%s: version %d, want %d", test.name, got, test.want)
+		}
+	}
+}
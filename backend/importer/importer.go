@@ -0,0 +1,263 @@
+// backend/importer/importer.go
+
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+	"gorm.io/gorm"
+)
+
+// RowError reports a problem with one row of a manifest
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error implements error
+func (e RowError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+	}
+	return fmt.Sprintf("row %d: %s %s", e.Row, e.Field, e.Message)
+}
+
+// ValidationError collects every row error of a manifest
+type ValidationError struct {
+	Errors []RowError
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, rowError := range e.Errors {
+		messages[i] = rowError.Error()
+	}
+	return "invalid manifest: " + strings.Join(messages, "; ")
+}
+
+// Result summarises an import
+type Result struct {
+	DryRun         bool       `json:"dryRun"`
+	Playlists      int        `json:"playlists"`
+	Videos         int        `json:"videos"`
+	Advertisements int        `json:"advertisements"`
+	Errors         []RowError `json:"errors"`
+}
+
+// Importer applies manifests to the database
+type Importer struct {
+	DB          *gorm.DB
+	AccessModel *models.AccessModel
+}
+
+// NewImporter creates a new Importer
+func NewImporter(db *gorm.DB) *Importer {
+	return &Importer{
+		DB:          db,
+		AccessModel: models.NewAccessModel(db),
+	}
+}
+
+// Import validates every row of a manifest and, unless dryRun is set, creates everything in a single
+// transaction through PlaylistModel and AdvertisementModel, on behalf of a user who owns the new
+// playlists and uploaded their videos. Nothing is written if any row is invalid.
+func (im *Importer) Import(manifest *Manifest, userID uint, dryRun bool) (*Result, error) {
+	result := &Result{DryRun: dryRun, Errors: append(append([]RowError{}, manifest.ParseErrors...), im.Validate(manifest, userID)...)}
+	for _, playlist := range manifest.Playlists {
+		result.Playlists++
+		result.Videos += len(playlist.Videos)
+	}
+	result.Advertisements = len(manifest.Advertisements)
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+
+	if len(result.Errors) > 0 {
+		return result, &ValidationError{Errors: result.Errors}
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	err := im.DB.Transaction(func(tx *gorm.DB) error {
+		playlistModel := models.NewPlaylistModel(tx)
+		advertisementModel := models.NewAdvertisementModel(tx)
+
+		playlistIDs := make(map[string]uint, len(manifest.Playlists))
+		for _, row := range manifest.Playlists {
+			playlist := row.playlist(userID)
+			if err := playlistModel.CreatePlaylist(&playlist); err != nil {
+				return RowError{Row: row.Row, Message: err.Error()}
+			}
+			if row.Ref != "" {
+				playlistIDs[row.Ref] = playlist.ID
+			}
+		}
+
+		for _, row := range manifest.Advertisements {
+			advertisement := row.advertisement()
+			if row.Playlist != "" {
+				advertisement.PlaylistID = playlistIDs[row.Playlist]
+			}
+			if err := advertisementModel.CreateAdvertisement(&advertisement); err != nil {
+				return RowError{Row: row.Row, Message: err.Error()}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var rowError RowError
+		if errors.As(err, &rowError) {
+			result.Errors = append(result.Errors, rowError)
+		}
+		return result, err
+	}
+	return result, nil
+}
+
+// Validate checks every row of a manifest imported by a user and returns all problems found. The user must
+// own the channels of the new playlists and the existing playlists advertisements are attached to.
+func (im *Importer) Validate(manifest *Manifest, userID uint) []RowError {
+	rowErrors := []RowError{}
+	refs := map[string]bool{}
+
+	for _, playlist := range manifest.Playlists {
+		if strings.TrimSpace(playlist.Title) == "" {
+			rowErrors = append(rowErrors, RowError{Row: playlist.Row, Field: "title", Message: "is required"})
+		}
+		if playlist.Ref != "" {
+			if refs[playlist.Ref] {
+				rowErrors = append(rowErrors, RowError{Row: playlist.Row, Field: "ref", Message: fmt.Sprintf("%q is used by another playlist", playlist.Ref)})
+			}
+			refs[playlist.Ref] = true
+		}
+		if playlist.ChannelID != 0 {
+			var owned int64
+			if err := im.DB.Model(&models.Channel{}).Where("id = ? AND owner_id = ?", playlist.ChannelID, userID).Count(&owned).Error; err != nil || owned == 0 {
+				rowErrors = append(rowErrors, RowError{Row: playlist.Row, Field: "channelId", Message: fmt.Sprintf("channel %d does not exist or is not yours", playlist.ChannelID)})
+			}
+		}
+
+		orders := map[int]bool{}
+		for _, video := range playlist.Videos {
+			if strings.TrimSpace(video.Title) == "" {
+				rowErrors = append(rowErrors, RowError{Row: video.Row, Field: "title", Message: "is required"})
+			}
+			if !isURL(video.URL) {
+				rowErrors = append(rowErrors, RowError{Row: video.Row, Field: "url", Message: "must be an absolute or root-relative URL"})
+			}
+			if video.Duration < 0 {
+				rowErrors = append(rowErrors, RowError{Row: video.Row, Field: "duration", Message: "must not be negative"})
+			}
+			if orders[video.Order] {
+				rowErrors = append(rowErrors, RowError{Row: video.Row, Field: "order", Message: fmt.Sprintf("%d is used by another video of the playlist", video.Order)})
+			}
+			orders[video.Order] = true
+		}
+	}
+
+	for _, advertisement := range manifest.Advertisements {
+		switch {
+		case advertisement.Playlist != "" && advertisement.PlaylistID != 0:
+			rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "playlist", Message: "set either playlist or playlistId, not both"})
+		case advertisement.Playlist != "":
+			if !refs[advertisement.Playlist] {
+				rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "playlist", Message: fmt.Sprintf("%q is not a playlist of the manifest", advertisement.Playlist)})
+			}
+		case advertisement.PlaylistID != 0:
+			// Existing playlists are looked up so a dry run catches dangling references too
+			err := im.AccessModel.CanManage(models.ContentPlaylist, advertisement.PlaylistID, userID)
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "playlistId", Message: fmt.Sprintf("playlist %d does not exist", advertisement.PlaylistID)})
+			case errors.Is(err, models.ErrNotEditor):
+				rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "playlistId", Message: fmt.Sprintf("playlist %d is not yours", advertisement.PlaylistID)})
+			case err != nil:
+				rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "playlistId", Message: err.Error()})
+			}
+		default:
+			rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "playlist", Message: "is required"})
+		}
+
+		if strings.TrimSpace(advertisement.Title) == "" {
+			rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "title", Message: "is required"})
+		}
+		if !isURL(advertisement.ContentURL) {
+			rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "contentURL", Message: "must be an absolute or root-relative URL"})
+		}
+		if advertisement.Duration <= 0 {
+			rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "duration", Message: "must be positive"})
+		}
+		if advertisement.ClickThroughURL != "" && !isURL(advertisement.ClickThroughURL) {
+			rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Field: "clickThroughURL", Message: "must be an absolute or root-relative URL"})
+		}
+		candidate := advertisement.advertisement()
+		if err := candidate.Validate(); err != nil {
+			rowErrors = append(rowErrors, RowError{Row: advertisement.Row, Message: err.Error()})
+		}
+	}
+
+	return rowErrors
+}
+
+// playlist converts a row into a Playlist with its videos, owned by a user
+func (row PlaylistRow) playlist(ownerID uint) models.Playlist {
+	playlist := models.Playlist{
+		Title:       row.Title,
+		Description: row.Description,
+		ChannelID:   row.ChannelID,
+		OwnerID:     ownerID,
+		IsPublic:    true,
+		IsPlayable:  true,
+	}
+	if row.IsPublic != nil {
+		playlist.IsPublic = *row.IsPublic
+	}
+	for _, video := range row.Videos {
+		playlist.Videos = append(playlist.Videos, models.Video{
+			Title:        video.Title,
+			Description:  video.Description,
+			URL:          video.URL,
+			ThumbnailURL: video.ThumbnailURL,
+			Duration:     video.Duration,
+			Order:        video.Order,
+			ChannelID:    row.ChannelID,
+			UploaderID:   ownerID,
+		})
+		playlist.TotalDuration += video.Duration
+	}
+	return playlist
+}
+
+// advertisement converts a row into an Advertisement
+func (row AdvertisementRow) advertisement() models.Advertisement {
+	return models.Advertisement{
+		PlaylistID:      row.PlaylistID,
+		Title:           row.Title,
+		Description:     row.Description,
+		ContentURL:      row.ContentURL,
+		ThumbnailURL:    row.ThumbnailURL,
+		ClickThroughURL: row.ClickThroughURL,
+		Duration:        row.Duration,
+		ScheduledAt:     row.ScheduledAt,
+		Targeting:       row.Targeting,
+		ExclusionGroup:  row.ExclusionGroup,
+		IsPublic:        true,
+	}
+}
+
+// isURL reports whether value is an absolute http(s) URL or a root-relative path
+func isURL(value string) bool {
+	if strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "//") {
+		return true
+	}
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,232 @@
+// backend/importer/importer_test.go
+
+package importer_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/importer"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestParseCSV(t *testing.T) {
+	manifest, err := importer.Parse(strings.NewReader(`type,ref,playlist,playlist_id,channel_id,title,url,duration,order,is_public,scheduled_at
+playlist,news,,,3,News,,,,false,
+video,,news,,,Morning,https://cdn.example.com/morning.mp4,120,1,,
+video,,news,,,Evening,/videos/evening.mp4,soon,2,,
+advertisement,,news,,,Spring sale,https://cdn.example.com/sale.mp4,15,,,2026-03-01T09:00:00Z
+advertisement,,,7,,Other,https://cdn.example.com/other.mp4,10,,,yesterday
+video,,sports,,,Orphan,https://cdn.example.com/orphan.mp4,30,1,,
+`), "csv")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if len(manifest.Playlists) != 1 {
+		t.Fatalf("parsed %d playlists, want 1", len(manifest.Playlists))
+	}
+	playlist := manifest.Playlists[0]
+	if playlist.Row != 2 || playlist.Ref != "news" || playlist.ChannelID != 3 || playlist.IsPublic == nil || *playlist.IsPublic {
+		t.Errorf("playlist parsed as %+v", playlist)
+	}
+	if len(playlist.Videos) != 2 || playlist.Videos[0].Row != 3 || playlist.Videos[0].Duration != 120 || playlist.Videos[1].Order != 2 {
+		t.Errorf("videos parsed as %+v", playlist.Videos)
+	}
+	if len(manifest.Advertisements) != 2 {
+		t.Fatalf("parsed %d advertisements, want 2", len(manifest.Advertisements))
+	}
+	if ad := manifest.Advertisements[0]; ad.Row != 5 || ad.Playlist != "news" || ad.ContentURL != "https://cdn.example.com/sale.mp4" ||
+		ad.Duration != 15 || ad.ScheduledAt.IsZero() {
+		t.Errorf("advertisement parsed as %+v", ad)
+	}
+	if ad := manifest.Advertisements[1]; ad.PlaylistID != 7 {
+		t.Errorf("advertisement playlist ID parsed as %d, want 7", ad.PlaylistID)
+	}
+
+	want := []importer.RowError{
+		{Row: 4, Field: "duration", Message: "must be a whole number"},
+		{Row: 6, Field: "scheduled_at", Message: "must be an RFC 3339 timestamp"},
+		{Row: 7, Field: "playlist", Message: "must name a playlist defined on an earlier line"},
+	}
+	if !reflect.DeepEqual(manifest.ParseErrors, want) {
+		t.Errorf("parse errors are %+v, want %+v", manifest.ParseErrors, want)
+	}
+
+	for _, header := range []string{"ref,title\n", "type,colour\n"} {
+		if _, err := importer.Parse(strings.NewReader(header), "csv"); err == nil {
+			t.Errorf("Parse accepted the header %q", header)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	manifest, err := importer.Parse(strings.NewReader(`{
+		"playlists": [
+			{"ref": "a", "title": "A", "videos": [{"title": "1", "url": "/1.mp4"}, {"title": "2", "url": "/2.mp4", "order": 1}]},
+			{"ref": "b", "title": "B"}
+		],
+		"advertisements": [{"playlist": "b", "title": "Ad", "contentURL": "/ad.mp4", "duration": 5}]
+	}`), "json")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	rows := []int{manifest.Playlists[0].Row, manifest.Playlists[0].Videos[0].Row, manifest.Playlists[0].Videos[1].Row,
+		manifest.Playlists[1].Row, manifest.Advertisements[0].Row}
+	if !reflect.DeepEqual(rows, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("rows numbered %v, want 1 to 5 in document order", rows)
+	}
+
+	if _, err := importer.Parse(strings.NewReader(`{"playlists": [{"title": "A", "owner": 1}]}`), "json"); err == nil {
+		t.Error("Parse accepted an unknown field")
+	}
+	if _, err := importer.Parse(strings.NewReader(`{}`), "xml"); err == nil {
+		t.Error("Parse accepted an unknown format")
+	}
+}
+
+// testManifest is a valid manifest of one playlist with a video and an advertisement
+func testManifest() *importer.Manifest {
+	return &importer.Manifest{
+		Playlists: []importer.PlaylistRow{{Row: 1, Ref: "news", Title: "News", Videos: []importer.VideoRow{
+			{Row: 2, Title: "Morning", URL: "/morning.mp4", Duration: 60},
+		}}},
+		Advertisements: []importer.AdvertisementRow{{Row: 3, Playlist: "news", Title: "Sale", ContentURL: "/sale.mp4", Duration: 15}},
+	}
+}
+
+// count returns the number of rows of a model
+func count(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(model).Count(&n).Error; err != nil {
+		t.Fatalf("counting: %v", err)
+	}
+	return n
+}
+
+func TestImport(t *testing.T) {
+	db := newTestDB(t)
+
+	result, err := importer.NewImporter(db).Import(testManifest(), 4, false)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Playlists != 1 || result.Videos != 1 || result.Advertisements != 1 || result.DryRun {
+		t.Errorf("result is %+v", result)
+	}
+	var playlist models.Playlist
+	if err := db.Preload("Videos").Preload("Advertisements").First(&playlist).Error; err != nil {
+		t.Fatalf("loading playlist: %v", err)
+	}
+	if playlist.OwnerID != 4 || len(playlist.Videos) != 1 || playlist.Videos[0].UploaderID != 4 || len(playlist.Advertisements) != 1 {
+		t.Errorf("imported playlist owned by %d with %d videos and %d advertisements, want owned by 4 with 1 and 1",
+			playlist.OwnerID, len(playlist.Videos), len(playlist.Advertisements))
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	db := newTestDB(t)
+
+	result, err := importer.NewImporter(db).Import(testManifest(), 4, true)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if !result.DryRun || result.Playlists != 1 || result.Videos != 1 || result.Advertisements != 1 {
+		t.Errorf("result is %+v", result)
+	}
+	if n := count(t, db, &models.Playlist{}) + count(t, db, &models.Video{}) + count(t, db, &models.Advertisement{}); n != 0 {
+		t.Errorf("a dry run wrote %d rows", n)
+	}
+}
+
+func TestImportOwnership(t *testing.T) {
+	db := newTestDB(t)
+	// User 4 owns channel and playlist, user 5 imports
+	channel := models.Channel{Name: "channel", OwnerID: 4}
+	if err := db.Create(&channel).Error; err != nil {
+		t.Fatalf("creating channel: %v", err)
+	}
+	playlist := models.Playlist{Title: "playlist", OwnerID: 4}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+
+	manifest := &importer.Manifest{
+		Playlists: []importer.PlaylistRow{{Row: 1, Title: "Channel playlist", ChannelID: channel.ID}},
+		Advertisements: []importer.AdvertisementRow{
+			{Row: 2, PlaylistID: playlist.ID, Title: "Sale", ContentURL: "/sale.mp4", Duration: 15},
+			{Row: 3, PlaylistID: playlist.ID + 1, Title: "Gone", ContentURL: "/gone.mp4", Duration: 15},
+		},
+	}
+	im := importer.NewImporter(db)
+	result, err := im.Import(manifest, 5, false)
+	var validationError *importer.ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("Import by a stranger got %v, want a validation error", err)
+	}
+	fields := []string{}
+	for _, rowError := range result.Errors {
+		fields = append(fields, rowError.Field)
+	}
+	if !reflect.DeepEqual(fields, []string{"channelId", "playlistId", "playlistId"}) {
+		t.Errorf("errors are %+v, want the channel and both playlists rejected", result.Errors)
+	}
+	if !strings.Contains(result.Errors[1].Message, "not yours") || !strings.Contains(result.Errors[2].Message, "does not exist") {
+		t.Errorf("playlist errors are %+v", result.Errors[1:])
+	}
+
+	manifest.Advertisements = manifest.Advertisements[:1]
+	if _, err := im.Import(manifest, 4, false); err != nil {
+		t.Fatalf("Import by the owner: %v", err)
+	}
+	var imported models.Playlist
+	if err := db.Where("title = ?", "Channel playlist").First(&imported).Error; err != nil {
+		t.Fatalf("loading imported playlist: %v", err)
+	}
+	if imported.ChannelID != channel.ID || imported.OwnerID != 4 {
+		t.Errorf("imported playlist in channel %d owned by %d, want %d and 4", imported.ChannelID, imported.OwnerID, channel.ID)
+	}
+}
+
+func TestImportRollback(t *testing.T) {
+	db := newTestDB(t)
+
+	// An invalid row stops the import before anything is written
+	manifest := testManifest()
+	manifest.Advertisements = append(manifest.Advertisements, importer.AdvertisementRow{Row: 4, Playlist: "news", Title: "Bad", ContentURL: "ftp://ads/bad.mp4"})
+	result, err := importer.NewImporter(db).Import(manifest, 4, false)
+	var validationError *importer.ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("Import got %v, want a validation error", err)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Row != 4 || result.Errors[1].Row != 4 {
+		t.Errorf("errors are %+v, want the url and duration of row 4", result.Errors)
+	}
+	if n := count(t, db, &models.Playlist{}); n != 0 {
+		t.Errorf("an invalid manifest wrote %d playlists", n)
+	}
+
+	// A row failing to be written rolls back the rows written before it
+	if err := db.Callback().Create().Before("gorm:create").Register("test:fail_advertisements", func(tx *gorm.DB) {
+		if tx.Statement.Table == "advertisements" {
+			tx.AddError(errors.New("disk full"))
+		}
+	}); err != nil {
+		t.Fatalf("registering callback: %v", err)
+	}
+	result, err = importer.NewImporter(db).Import(testManifest(), 4, false)
+	if err == nil {
+		t.Fatal("Import succeeded while advertisements could not be written")
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Row != 3 {
+		t.Errorf("errors are %+v, want row 3", result.Errors)
+	}
+	if n := count(t, db, &models.Playlist{}) + count(t, db, &models.Video{}); n != 0 {
+		t.Errorf("a failed import left %d playlists and videos", n)
+	}
+}
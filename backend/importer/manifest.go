@@ -0,0 +1,230 @@
+// backend/importer/manifest.go
+
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Manifest describes playlists, their videos and advertisements to import
+type Manifest struct {
+	Playlists      []PlaylistRow      `json:"playlists"`
+	Advertisements []AdvertisementRow `json:"advertisements"`
+	// ParseErrors holds rows that could not be read; they are reported along with validation errors
+	ParseErrors []RowError `json:"-"`
+}
+
+// PlaylistRow is a playlist to create. Ref names it so that advertisements in the same manifest can refer to it.
+type PlaylistRow struct {
+	Row         int        `json:"-"`
+	Ref         string     `json:"ref"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	ChannelID   uint       `json:"channelId"`
+	IsPublic    *bool      `json:"isPublic"`
+	Videos      []VideoRow `json:"videos"`
+}
+
+// VideoRow is a video of a playlist
+type VideoRow struct {
+	Row          int    `json:"-"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	Duration     int    `json:"duration"`
+	Order        int    `json:"order"`
+}
+
+// AdvertisementRow is an advertisement to create, attached either to a playlist of the manifest (Playlist)
+// or to an existing playlist (PlaylistID)
+type AdvertisementRow struct {
+	Row             int       `json:"-"`
+	Playlist        string    `json:"playlist"`
+	PlaylistID      uint      `json:"playlistId"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	ContentURL      string    `json:"contentURL"`
+	ThumbnailURL    string    `json:"thumbnailURL"`
+	ClickThroughURL string    `json:"clickThroughURL"`
+	Duration        int       `json:"duration"`
+	ScheduledAt     time.Time `json:"scheduledAt"`
+	Targeting       string    `json:"targeting"`
+	ExclusionGroup  string    `json:"exclusionGroup"`
+}
+
+// csvColumns are the columns of a CSV manifest. Each line has a type of "playlist", "video" or "advertisement";
+// videos and advertisements name their playlist's ref in the playlist column.
+var csvColumns = []string{"type", "ref", "playlist", "playlist_id", "channel_id", "title", "description", "url",
+	"thumbnail_url", "click_through_url", "duration", "order", "scheduled_at", "targeting", "exclusion_group", "is_public"}
+
+// ParseJSON reads a JSON manifest. Rows are numbered in document order, playlists and their videos first.
+func ParseJSON(r io.Reader) (*Manifest, error) {
+	var manifest Manifest
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid JSON manifest: %w", err)
+	}
+
+	row := 0
+	for i := range manifest.Playlists {
+		row++
+		manifest.Playlists[i].Row = row
+		for j := range manifest.Playlists[i].Videos {
+			row++
+			manifest.Playlists[i].Videos[j].Row = row
+		}
+	}
+	for i := range manifest.Advertisements {
+		row++
+		manifest.Advertisements[i].Row = row
+	}
+	return &manifest, nil
+}
+
+// ParseCSV reads a CSV manifest with a header line naming the columns in csvColumns.
+// Rows are numbered by their line in the file; rows with unreadable values are recorded in ParseErrors.
+func ParseCSV(r io.Reader) (*Manifest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV manifest: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["type"]; !ok {
+		return nil, fmt.Errorf("invalid CSV manifest: missing \"type\" column")
+	}
+	for name := range columns {
+		if !isCSVColumn(name) {
+			return nil, fmt.Errorf("invalid CSV manifest: unknown column %q", name)
+		}
+	}
+
+	manifest := &Manifest{}
+	playlists := map[string]int{}
+	line := 1
+	var rowErrors []RowError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV manifest: %w", err)
+		}
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		number := func(name string) int {
+			value := get(name)
+			if value == "" {
+				return 0
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				rowErrors = append(rowErrors, RowError{Row: line, Field: name, Message: "must be a whole number"})
+			}
+			return n
+		}
+
+		switch strings.ToLower(get("type")) {
+		case "playlist":
+			playlist := PlaylistRow{
+				Row:         line,
+				Ref:         get("ref"),
+				Title:       get("title"),
+				Description: get("description"),
+				ChannelID:   uint(number("channel_id")),
+			}
+			if value := get("is_public"); value != "" {
+				isPublic, err := strconv.ParseBool(value)
+				if err != nil {
+					rowErrors = append(rowErrors, RowError{Row: line, Field: "is_public", Message: "must be true or false"})
+				}
+				playlist.IsPublic = &isPublic
+			}
+			playlists[playlist.Ref] = len(manifest.Playlists)
+			manifest.Playlists = append(manifest.Playlists, playlist)
+		case "video":
+			index, ok := playlists[get("playlist")]
+			if !ok {
+				rowErrors = append(rowErrors, RowError{Row: line, Field: "playlist", Message: "must name a playlist defined on an earlier line"})
+				continue
+			}
+			manifest.Playlists[index].Videos = append(manifest.Playlists[index].Videos, VideoRow{
+				Row:          line,
+				Title:        get("title"),
+				Description:  get("description"),
+				URL:          get("url"),
+				ThumbnailURL: get("thumbnail_url"),
+				Duration:     number("duration"),
+				Order:        number("order"),
+			})
+		case "advertisement":
+			advertisement := AdvertisementRow{
+				Row:             line,
+				Playlist:        get("playlist"),
+				PlaylistID:      uint(number("playlist_id")),
+				Title:           get("title"),
+				Description:     get("description"),
+				ContentURL:      get("url"),
+				ThumbnailURL:    get("thumbnail_url"),
+				ClickThroughURL: get("click_through_url"),
+				Duration:        number("duration"),
+				Targeting:       get("targeting"),
+				ExclusionGroup:  get("exclusion_group"),
+			}
+			if value := get("scheduled_at"); value != "" {
+				scheduledAt, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					rowErrors = append(rowErrors, RowError{Row: line, Field: "scheduled_at", Message: "must be an RFC 3339 timestamp"})
+				}
+				advertisement.ScheduledAt = scheduledAt
+			}
+			manifest.Advertisements = append(manifest.Advertisements, advertisement)
+		default:
+			rowErrors = append(rowErrors, RowError{Row: line, Field: "type", Message: "must be playlist, video or advertisement"})
+		}
+	}
+
+	manifest.ParseErrors = rowErrors
+	return manifest, nil
+}
+
+// isCSVColumn reports whether name is a known CSV column
+func isCSVColumn(name string) bool {
+	for _, column := range csvColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse reads a manifest in the given format, "csv" or "json"
+func Parse(r io.Reader, format string) (*Manifest, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return ParseCSV(r)
+	case "json":
+		return ParseJSON(r)
+	default:
+		return nil, fmt.Errorf("unsupported manifest format %q, expected csv or json", format)
+	}
+}
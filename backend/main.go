@@ -10,12 +10,16 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/robfig/cron/v3"
 	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/database"
 	"github.com/shuttlersit/ads-player/backend/models"
 	"github.com/shuttlersit/ads-player/backend/routes"
+	"github.com/shuttlersit/ads-player/backend/search"
+	"github.com/shuttlersit/ads-player/backend/storage"
 	"gorm.io/gorm"
 )
 
@@ -23,14 +27,38 @@ var db *gorm.DB
 var err error
 
 func main() {
+	// Connect to the database
+	_, db = database.ConnectMySqlite()
+
+	// Keep the search index in sync with the searchable content, also when run as a command
+	searchEngine, err := search.New(db, models.SearchSources)
+	if err != nil {
+		log.Fatal("Error configuring search: ", err)
+	}
+	models.SearchEngine = searchEngine
+
+	// Run a command-line subcommand instead of the server if one is given
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	// Migrate the schema
-	db.AutoMigrate(&models.Playlist{}, &models.Video{}, &models.Advertisement{}, &models.AdvertisementPlayEvent{})
+	if err := database.Migrate(db); err != nil {
+		log.Println("Error migrating the schema:", err)
+	}
 
 	// Create models
 	playlistModel := models.NewPlaylistModel(db)
 	advertisementModel := models.NewAdvertisementModel(db)
 
+	// Configure creative storage
+	creativeStorage, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal("Error configuring creative storage: ", err)
+	}
+
 	// Create controllers
+	probeService := controllers.NewMediaProbeService(advertisementModel, models.NewVideoModel(db), creativeStorage)
 	playbackService := &controllers.SimplePlaybackService{} // Use your preferred playback service implementation
 	advertisementController := controllers.NewAdvertisementController(playlistModel, advertisementModel, playbackService)
 
@@ -41,7 +69,7 @@ func main() {
 
 	go func() {
 		defer wg.Done()
-		if err := runAdvertisementScheduler(schedulerCtx, advertisementController); err != nil {
+		if err := runAdvertisementScheduler(schedulerCtx, advertisementController, probeService); err != nil {
 			log.Fatal("Error starting Advertisement Scheduler: ", err)
 		}
 	}()
@@ -52,6 +80,39 @@ func main() {
 	// Register playlist routes
 	routes.RegisterPlaylistRoutes(r, db)
 
+	// Register advertisement routes
+	routes.RegisterAdvertisementRoutes(r, db, creativeStorage, probeService)
+
+	// Register import routes
+	routes.RegisterImportRoutes(r, db)
+
+	// Register simulation routes
+	routes.RegisterSimulationRoutes(r, db)
+
+	// Register comment routes
+	routes.RegisterCommentRoutes(r, db)
+
+	// Register like, dislike and follow routes
+	routes.RegisterEngagementRoutes(r, db)
+
+	// Register watch history routes
+	routes.RegisterWatchHistoryRoutes(r, db)
+
+	// Register related content routes
+	routes.RegisterRelatedRoutes(r, db)
+
+	// Register search routes
+	routes.RegisterSearchRoutes(r, searchEngine)
+
+	// Register category routes
+	routes.RegisterCategoryRoutes(r, db)
+
+	// Register password and access token routes
+	routes.RegisterAccessRoutes(r, db)
+
+	// Register playlist contributor and edit log routes
+	routes.RegisterCollaborationRoutes(r, db)
+
 	// Graceful shutdown
 	gracefulShutdown(r, schedulerCancel, &wg)
 
@@ -61,31 +122,74 @@ func main() {
 	}
 }
 
-func runAdvertisementScheduler(ctx context.Context, advertisementController *controllers.AdvertisementController) error {
+func runAdvertisementScheduler(ctx context.Context, advertisementController *controllers.AdvertisementController, probeService *controllers.MediaProbeService) error {
 	c := cron.New()
 
 	_, err := c.AddFunc("0 0 * * *", func() {
 		// Run a daily job to update and refresh advertisements
-		// Add logic to refresh advertisements as needed
+		// Re-probe media files so declared durations that drift from the files are flagged
+		if err := probeService.ReprobeStale(ctx, 7*24*time.Hour); err != nil {
+			fmt.Println("Error re-probing media:", err)
+		}
 	})
 	if err != nil {
 		return err
 	}
 
 	_, err = c.AddFunc("*/5 * * * *", func() {
-		// Get playlists that are eligible for advertisements
-		playlists, err := advertisementController.PlaylistModel.GetPlaylistsForAdvertisements()
-		if err != nil {
+		// Schedule advertisements for every eligible playlist
+		if err := advertisementController.ScheduleEligiblePlaylists(); err != nil {
 			fmt.Println("Error fetching playlists for advertisements:", err)
-			return
 		}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.AddFunc("0 * * * *", func() {
+		// Materialize the decayed playlist popularity used to rank playlists
+		if err := advertisementController.PlaylistModel.RefreshPlaylistStats(advertisementController.AdvertisementModel.Clock.Now()); err != nil {
+			fmt.Println("Error refreshing playlist stats:", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.AddFunc("30 3 * * *", func() {
+		// Recompute like, dislike and follower counters from the per-user join tables
+		if err := models.NewEngagementModel(advertisementController.AdvertisementModel.DB).ReconcileEngagementCounters(); err != nil {
+			fmt.Println("Error reconciling engagement counters:", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.AddFunc("45 3 * * *", func() {
+		// Recompute video views and playlist play counts from the watch history
+		if err := models.NewWatchHistoryModel(advertisementController.AdvertisementModel.DB).RollUpWatchHistory(); err != nil {
+			fmt.Println("Error rolling up watch history:", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.AddFunc("15 4 * * *", func() {
+		// Recompute related videos, playlists and advertisements after the watch history rollup
+		if err := models.NewRelatedModel(advertisementController.AdvertisementModel.DB).RefreshRelatedContent(); err != nil {
+			fmt.Println("Error refreshing related content:", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
 
-		// Loop through playlists and schedule advertisements
-		for _, playlist := range playlists {
-			err := advertisementController.ScheduleAdvertisementForPlaylist(playlist)
-			if err != nil {
-				fmt.Printf("Error scheduling advertisement for playlist %d: %v\n", playlist.ID, err)
-			}
+	_, err = c.AddFunc("30 4 * * *", func() {
+		// Delete the expired access tokens of password protected content
+		if err := models.NewAccessModel(advertisementController.AdvertisementModel.DB).PurgeExpiredAccessGrants(); err != nil {
+			fmt.Println("Error purging expired access tokens:", err)
 		}
 	})
 	if err != nil {
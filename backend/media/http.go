@@ -0,0 +1,172 @@
+// backend/media/http.go
+
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// rangeBlockSize is how much is fetched per range request; box headers are small and clustered
+const rangeBlockSize = 64 << 10
+
+// ErrForbiddenAddress is returned when a URL to probe, or a redirect it leads to, points at
+// a loopback, private or link-local address
+var ErrForbiddenAddress = errors.New("media: refusing to connect to a loopback, private or link-local address")
+
+// allowDial reports whether ProbeURL may connect to a resolved "ip:port" address
+var allowDial = func(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// guardClient returns a copy of client that only follows http(s) URLs and only connects to public addresses.
+// Addresses are checked once resolved, when dialing, so redirects and DNS answers cannot reach internal hosts.
+func guardClient(client *http.Client) (*http.Client, error) {
+	var transport *http.Transport
+	switch base := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = base.Clone()
+	default:
+		return nil, fmt.Errorf("media: cannot guard the connections of a %T", base)
+	}
+	// A proxy would be dialed instead of the host, so the host could not be checked
+	transport.Proxy = nil
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if !allowDial(address) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			return nil
+		},
+	}
+	transport.DialContext = dialer.DialContext
+
+	guarded := *client
+	guarded.Transport = transport
+	guarded.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("media: refusing to follow a redirect to %s", req.URL.Redacted())
+		}
+		if client.CheckRedirect != nil {
+			return client.CheckRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("media: stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &guarded, nil
+}
+
+// ProbeURL probes a remote http(s) file with HTTP range requests, fetching only the blocks holding box headers.
+// It never connects to loopback, private or link-local addresses, whatever client is given.
+func ProbeURL(ctx context.Context, client *http.Client, rawURL string) (*Info, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("media: cannot probe %q: not an http(s) URL", rawURL)
+	}
+	client, err = guardClient(client)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("media: HEAD %s returned %s", rawURL, resp.Status)
+	}
+	if resp.ContentLength <= 0 {
+		return nil, errors.New("media: server did not report a content length")
+	}
+
+	reader := &httpReaderAt{ctx: ctx, client: client, url: rawURL, size: resp.ContentLength}
+	return Probe(io.NewSectionReader(reader, 0, resp.ContentLength))
+}
+
+// httpReaderAt reads a remote file with range requests, caching the last block fetched
+type httpReaderAt struct {
+	ctx    context.Context
+	client *http.Client
+	url    string
+	size   int64
+
+	blockStart int64
+	block      []byte
+}
+
+// ReadAt implements io.ReaderAt
+func (h *httpReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	if offset >= h.size {
+		return 0, io.EOF
+	}
+	if offset < h.blockStart || offset+int64(len(p)) > h.blockStart+int64(len(h.block)) {
+		if err := h.fetch(offset, int64(len(p))); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, h.block[offset-h.blockStart:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fetch loads the block starting at offset
+func (h *httpReaderAt) fetch(offset, length int64) error {
+	if length < rangeBlockSize {
+		length = rangeBlockSize
+	}
+	end := offset + length - 1
+	if end >= h.size {
+		end = h.size - 1
+	}
+
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, end))
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("media: range request to %s returned %s", h.url, resp.Status)
+	}
+
+	block, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	h.blockStart, h.block = offset, block
+	return nil
+}
@@ -0,0 +1,83 @@
+// backend/media/http_test.go
+
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// serveMovie starts a server answering HEAD and range requests for a small movie
+func serveMovie(t *testing.T) *httptest.Server {
+	t.Helper()
+	file := bytes.Join([][]byte{testBox("ftyp", []byte("isom"), make([]byte, 4)), testMovie(12), testBox("mdat", make([]byte, 256))}, nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "movie.mp4", time.Time{}, bytes.NewReader(file))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// allowDialing lets ProbeURL connect to the given test servers only, for the duration of a test
+func allowDialing(t *testing.T, servers ...*httptest.Server) {
+	allowed := allowDial
+	allowDial = func(address string) bool {
+		for _, server := range servers {
+			if server.Listener.Addr().String() == address {
+				return true
+			}
+		}
+		return false
+	}
+	t.Cleanup(func() { allowDial = allowed })
+}
+
+func TestProbeURL(t *testing.T) {
+	server := serveMovie(t)
+	allowDialing(t, server)
+
+	info, err := ProbeURL(context.Background(), &http.Client{}, server.URL+"/movie.mp4")
+	if err != nil {
+		t.Fatalf("ProbeURL: %v", err)
+	}
+	if info.Duration != 12 || info.Container != "isom" {
+		t.Errorf("probed %+v, want a 12s isom movie", info)
+	}
+}
+
+func TestProbeURLRefusesInternalAddresses(t *testing.T) {
+	server := serveMovie(t)
+	ctx := context.Background()
+
+	for _, url := range []string{"file:///etc/passwd", "gopher://example.com/movie.mp4", "http:///movie.mp4"} {
+		if _, err := ProbeURL(ctx, &http.Client{}, url); err == nil {
+			t.Errorf("ProbeURL accepted %q", url)
+		}
+	}
+
+	// The test server listens on loopback
+	if _, err := ProbeURL(ctx, &http.Client{}, server.URL+"/movie.mp4"); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("ProbeURL of a loopback address got %v, want ErrForbiddenAddress", err)
+	}
+	for _, address := range []string{"127.0.0.1:80", "[::1]:80", "10.1.2.3:80", "192.168.0.1:443", "169.254.169.254:80", "[fe80::1]:80", "0.0.0.0:80"} {
+		if allowDial(address) {
+			t.Errorf("allowDial(%q) is true", address)
+		}
+	}
+	if !allowDial("93.184.216.34:443") {
+		t.Error("allowDial refused a public address")
+	}
+
+	// Redirects are checked as they are followed
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL+"/movie.mp4", http.StatusFound))
+	defer redirect.Close()
+	allowDialing(t, redirect)
+	if _, err := ProbeURL(ctx, &http.Client{}, redirect.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("ProbeURL redirected to a loopback address got %v, want ErrForbiddenAddress", err)
+	}
+}
@@ -0,0 +1,325 @@
+// backend/media/mp4.go
+
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNoMovie is returned when a file has no moov box, i.e. it is not an MP4/MOV file or is truncated
+var ErrNoMovie = errors.New("media: no moov box found")
+
+// maxTrackDepth bounds how deep boxes may nest inside a trak box. Real files nest stsd three levels
+// down (mdia > minf > stbl); crafted files repeating those boxes are rejected.
+const maxTrackDepth = 8
+
+// Info describes a media file as read from its container headers
+type Info struct {
+	Container  string  `json:"container"` // Major brand from ftyp, e.g. "isom" or "qt"
+	Duration   float64 `json:"duration"`  // Seconds
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	VideoCodec string  `json:"videoCodec"` // Sample entry fourcc, e.g. "avc1", "hvc1"
+	AudioCodec string  `json:"audioCodec"` // Sample entry fourcc, e.g. "mp4a"
+	SampleRate int     `json:"sampleRate"` // Hz
+	Channels   int     `json:"channels"`
+	Bitrate    int64   `json:"bitrate"` // Average bits per second over the whole file
+	Size       int64   `json:"size"`    // Bytes
+	// FragmentOffset is the offset of the first moof box of a fragmented MP4, 0 otherwise. The bytes before
+	// it are the initialization section and the rest the movie fragments, which HLS can serve by byte range.
+	FragmentOffset int64 `json:"fragmentOffset"`
+}
+
+// HasVideo reports whether the file has a video track
+func (i Info) HasVideo() bool {
+	return i.VideoCodec != ""
+}
+
+// VideoQuality returns a label such as "1080p" for the video track
+func (i Info) VideoQuality() string {
+	if i.Height == 0 {
+		return ""
+	}
+	for _, standard := range []int{2160, 1440, 1080, 720, 480, 360, 240} {
+		if i.Height >= standard {
+			return fmt.Sprintf("%dp", standard)
+		}
+	}
+	return fmt.Sprintf("%dp", i.Height)
+}
+
+// AudioQuality returns a label such as "mp4a 48kHz stereo" for the audio track
+func (i Info) AudioQuality() string {
+	if i.AudioCodec == "" {
+		return ""
+	}
+	label := i.AudioCodec
+	if i.SampleRate > 0 {
+		label += fmt.Sprintf(" %gkHz", float64(i.SampleRate)/1000)
+	}
+	switch i.Channels {
+	case 0:
+	case 1:
+		label += " mono"
+	case 2:
+		label += " stereo"
+	default:
+		label += fmt.Sprintf(" %dch", i.Channels)
+	}
+	return label
+}
+
+// track collects what a trak box says about one track
+type track struct {
+	handler    string
+	codec      string
+	width      int
+	height     int
+	sampleRate int
+	channels   int
+}
+
+// box is an ISO base media file format box located in the file
+type box struct {
+	kind        string
+	offset      int64 // Offset of the box header
+	payload     int64 // Offset of the box payload
+	payloadSize int64
+}
+
+// Probe reads the ftyp and moov boxes of an MP4/MOV file. Only headers are read, so
+// the moov box may sit at either end of the file.
+func Probe(r io.ReadSeeker) (*Info, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{Size: size}
+	foundMovie := false
+	err = walkBoxes(r, 0, size, func(b box) error {
+		switch b.kind {
+		case "ftyp":
+			brand, err := readPayload(r, b, 4)
+			if err != nil {
+				return err
+			}
+			if len(brand) == 4 {
+				info.Container = strings.TrimSpace(string(brand))
+			}
+		case "moov":
+			foundMovie = true
+			return parseMovie(r, b, info)
+		case "moof":
+			if info.FragmentOffset == 0 {
+				info.FragmentOffset = b.offset
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !foundMovie {
+		return nil, ErrNoMovie
+	}
+
+	if info.Duration > 0 {
+		info.Bitrate = int64(float64(size*8) / info.Duration)
+	}
+	return info, nil
+}
+
+// parseMovie reads the movie header and the tracks of a moov box
+func parseMovie(r io.ReadSeeker, moov box, info *Info) error {
+	var tracks []track
+	err := walkBoxes(r, moov.payload, moov.payload+moov.payloadSize, func(b box) error {
+		switch b.kind {
+		case "mvhd":
+			timescale, duration, err := readMediaTimes(r, b)
+			if err != nil {
+				return err
+			}
+			if timescale > 0 {
+				info.Duration = float64(duration) / float64(timescale)
+			}
+		case "trak":
+			var t track
+			if err := parseTrack(r, b, &t, 0); err != nil {
+				return err
+			}
+			tracks = append(tracks, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tracks {
+		switch {
+		case t.handler == "vide" && info.VideoCodec == "":
+			info.VideoCodec, info.Width, info.Height = t.codec, t.width, t.height
+		case t.handler == "soun" && info.AudioCodec == "":
+			info.AudioCodec, info.SampleRate, info.Channels = t.codec, t.sampleRate, t.channels
+		}
+	}
+	return nil
+}
+
+// parseTrack descends trak > tkhd, mdia > hdlr, mdia > minf > stbl > stsd; depth is how many boxes
+// down the trak box b is
+func parseTrack(r io.ReadSeeker, b box, t *track, depth int) error {
+	if depth > maxTrackDepth {
+		return fmt.Errorf("media: boxes nested more than %d deep in a trak box", maxTrackDepth)
+	}
+	return walkBoxes(r, b.payload, b.payload+b.payloadSize, func(child box) error {
+		switch child.kind {
+		case "tkhd":
+			// Width and height are the last two 16.16 fixed point fields
+			if child.payloadSize < 8 {
+				return nil
+			}
+			tail := box{kind: child.kind, payload: child.payload + child.payloadSize - 8, payloadSize: 8}
+			data, err := readPayload(r, tail, 8)
+			if err != nil {
+				return err
+			}
+			if t.width == 0 && t.height == 0 {
+				t.width = int(binary.BigEndian.Uint32(data[0:4]) >> 16)
+				t.height = int(binary.BigEndian.Uint32(data[4:8]) >> 16)
+			}
+		case "hdlr":
+			data, err := readPayload(r, child, 12)
+			if err != nil {
+				return err
+			}
+			// QuickTime also has a data handler in minf; the media handler in mdia comes first
+			if len(data) == 12 && t.handler == "" {
+				t.handler = string(data[8:12])
+			}
+		case "mdia", "minf", "stbl":
+			return parseTrack(r, child, t, depth+1)
+		case "stsd":
+			return parseSampleDescription(r, child, t)
+		}
+		return nil
+	})
+}
+
+// parseSampleDescription reads the codec and format of the first sample entry
+func parseSampleDescription(r io.ReadSeeker, b box, t *track) error {
+	data, err := readPayload(r, b, 8+36)
+	if err != nil {
+		return err
+	}
+	if len(data) < 16 {
+		return nil
+	}
+	entry := data[8:] // Skip version, flags and entry count
+	t.codec = string(entry[4:8])
+
+	// Sample entries start with 6 reserved bytes and a data reference index
+	switch t.handler {
+	case "vide":
+		if len(entry) >= 36 {
+			t.width = int(binary.BigEndian.Uint16(entry[32:34]))
+			t.height = int(binary.BigEndian.Uint16(entry[34:36]))
+		}
+	case "soun":
+		if len(entry) >= 36 {
+			t.channels = int(binary.BigEndian.Uint16(entry[24:26]))
+			t.sampleRate = int(binary.BigEndian.Uint32(entry[32:36]) >> 16)
+		}
+	}
+	return nil
+}
+
+// readMediaTimes reads the timescale and duration of an mvhd or mdhd box
+func readMediaTimes(r io.ReadSeeker, b box) (uint32, uint64, error) {
+	data, err := readPayload(r, b, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(data) < 4 {
+		return 0, 0, fmt.Errorf("media: %s box too short", b.kind)
+	}
+
+	if data[0] == 1 {
+		// Version 1: 64-bit creation and modification times and duration
+		if len(data) < 32 {
+			return 0, 0, fmt.Errorf("media: %s box too short", b.kind)
+		}
+		return binary.BigEndian.Uint32(data[20:24]), binary.BigEndian.Uint64(data[24:32]), nil
+	}
+	if len(data) < 20 {
+		return 0, 0, fmt.Errorf("media: %s box too short", b.kind)
+	}
+	return binary.BigEndian.Uint32(data[12:16]), uint64(binary.BigEndian.Uint32(data[16:20])), nil
+}
+
+// walkBoxes calls fn for each box between start and end
+func walkBoxes(r io.ReadSeeker, start, end int64, fn func(box) error) error {
+	for offset := start; offset+8 <= end; {
+		b, size, err := readBoxHeader(r, offset, end)
+		if err != nil {
+			return err
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+		offset += size
+	}
+	return nil
+}
+
+// readBoxHeader reads the box at offset and returns it with its total size
+func readBoxHeader(r io.ReadSeeker, offset, end int64) (box, int64, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return box{}, 0, err
+	}
+	var header [16]byte
+	if _, err := io.ReadFull(r, header[:8]); err != nil {
+		return box{}, 0, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[0:4]))
+	kind := string(header[4:8])
+	headerSize := int64(8)
+	switch size {
+	case 0:
+		// The box extends to the end of the enclosing space
+		size = end - offset
+	case 1:
+		// A 64-bit size follows the type
+		if _, err := io.ReadFull(r, header[8:16]); err != nil {
+			return box{}, 0, err
+		}
+		size = int64(binary.BigEndian.Uint64(header[8:16]))
+		headerSize = 16
+	}
+	if size < headerSize || offset+size > end {
+		return box{}, 0, fmt.Errorf("media: invalid size %d for %q box at offset %d", size, kind, offset)
+	}
+
+	return box{kind: kind, offset: offset, payload: offset + headerSize, payloadSize: size - headerSize}, size, nil
+}
+
+// readPayload reads up to limit bytes from the start of a box payload
+func readPayload(r io.ReadSeeker, b box, limit int64) ([]byte, error) {
+	if b.payloadSize < limit {
+		limit = b.payloadSize
+	}
+	if _, err := r.Seek(b.payload, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, limit)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,139 @@
+// backend/media/mp4_test.go
+
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// testBox encodes an MP4 box with the given payload
+func testBox(kind string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(8+len(body)))
+	copy(header[4:], kind)
+	return append(header, body...)
+}
+
+// testMovie encodes a moov box with a movie header of the given duration in seconds
+func testMovie(seconds uint32) []byte {
+	mvhd := make([]byte, 20)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)         // Timescale
+	binary.BigEndian.PutUint32(mvhd[16:], seconds*1000) // Duration
+	return testBox("moov", testBox("mvhd", mvhd))
+}
+
+func TestProbeFragmentOffset(t *testing.T) {
+	ftyp := testBox("ftyp", []byte("iso5"), make([]byte, 4))
+	moov := testMovie(10)
+	fragment := append(testBox("moof", make([]byte, 16)), testBox("mdat", make([]byte, 64))...)
+
+	tests := []struct {
+		name string
+		file []byte
+		want int64
+	}{
+		{"progressive", bytes.Join([][]byte{ftyp, moov, testBox("mdat", make([]byte, 64))}, nil), 0},
+		{"fragmented", bytes.Join([][]byte{ftyp, moov, fragment, fragment}, nil), int64(len(ftyp) + len(moov))},
+	}
+	for _, test := range tests {
+		info, err := Probe(bytes.NewReader(test.file))
+		if err != nil {
+			t.Fatalf("%s: Probe: %v", test.name, err)
+		}
+		if info.FragmentOffset != test.want || info.Size != int64(len(test.file)) || info.Duration != 10 {
+			t.Errorf("%s: fragments at %d in %d bytes lasting %gs, want %d in %d bytes lasting 10s",
+				test.name, info.FragmentOffset, info.Size, info.Duration, test.want, len(test.file))
+		}
+	}
+}
+
+// testTrack encodes a trak box of the given handler whose tkhd and stsd boxes hold the given payloads
+func testTrack(handler string, tkhd, sampleEntry []byte) []byte {
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:], handler)
+	stsd := append([]byte{0, 0, 0, 0, 0, 0, 0, 1}, testBox(string(sampleEntry[:4]), sampleEntry[4:])...)
+	stbl := testBox("stbl", testBox("stsd", stsd))
+	return testBox("trak", testBox("tkhd", tkhd), testBox("mdia", testBox("hdlr", hdlr), testBox("minf", stbl)))
+}
+
+func TestProbeHeaders(t *testing.T) {
+	ftyp := testBox("ftyp", []byte("isom"), make([]byte, 4))
+
+	mvhd0 := make([]byte, 20)
+	binary.BigEndian.PutUint32(mvhd0[12:], 600)    // Timescale
+	binary.BigEndian.PutUint32(mvhd0[16:], 30*600) // Duration
+	mvhd1 := make([]byte, 32)
+	mvhd1[0] = 1
+	binary.BigEndian.PutUint32(mvhd1[20:], 90000)                   // Timescale
+	binary.BigEndian.PutUint64(mvhd1[24:], uint64(90000)*6_000_000) // Duration beyond 32 bits
+
+	// Width and height come from tkhd when the sample entry is too short to hold them
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], 1280<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], 720<<16)
+	video := testTrack("vide", tkhd, append([]byte("hvc1"), make([]byte, 8)...))
+
+	audioEntry := append([]byte("mp4a"), make([]byte, 32)...)
+	binary.BigEndian.PutUint16(audioEntry[20:], 2)         // Channels, 24 bytes into the entry with its size
+	binary.BigEndian.PutUint32(audioEntry[28:], 48000<<16) // Sample rate, 32 bytes into the entry
+	audio := testTrack("soun", make([]byte, 84), audioEntry)
+
+	// A video sample entry carries its own dimensions, which win over tkhd
+	sized := append([]byte("avc1"), make([]byte, 32)...)
+	binary.BigEndian.PutUint16(sized[28:], 1920)
+	binary.BigEndian.PutUint16(sized[30:], 1080)
+	sizedVideo := testTrack("vide", tkhd, sized)
+
+	mdat := testBox("mdat", make([]byte, 4096))
+	tests := []struct {
+		name string
+		file []byte
+		want Info
+	}{
+		{"version 0 movie header", bytes.Join([][]byte{ftyp, testBox("moov", testBox("mvhd", mvhd0)), mdat}, nil),
+			Info{Container: "isom", Duration: 30}},
+		{"version 1 movie header", bytes.Join([][]byte{ftyp, testBox("moov", testBox("mvhd", mvhd1)), mdat}, nil),
+			Info{Container: "isom", Duration: 6_000_000}},
+		{"tracks", bytes.Join([][]byte{ftyp, testBox("moov", testBox("mvhd", mvhd0), video, audio), mdat}, nil),
+			Info{Container: "isom", Duration: 30, VideoCodec: "hvc1", Width: 1280, Height: 720, AudioCodec: "mp4a", SampleRate: 48000, Channels: 2}},
+		{"sample entry dimensions", bytes.Join([][]byte{ftyp, testBox("moov", testBox("mvhd", mvhd0), sizedVideo), mdat}, nil),
+			Info{Container: "isom", Duration: 30, VideoCodec: "avc1", Width: 1920, Height: 1080}},
+		{"moov after mdat", bytes.Join([][]byte{ftyp, mdat, testBox("moov", testBox("mvhd", mvhd0), video)}, nil),
+			Info{Container: "isom", Duration: 30, VideoCodec: "hvc1", Width: 1280, Height: 720}},
+	}
+	for _, test := range tests {
+		info, err := Probe(bytes.NewReader(test.file))
+		if err != nil {
+			t.Errorf("%s: Probe: %v", test.name, err)
+			continue
+		}
+		test.want.Size = int64(len(test.file))
+		test.want.Bitrate = int64(float64(len(test.file)*8) / test.want.Duration)
+		if *info != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, *info, test.want)
+		}
+	}
+
+	if _, err := Probe(bytes.NewReader(bytes.Join([][]byte{ftyp, mdat}, nil))); err != ErrNoMovie {
+		t.Errorf("Probe without a moov box got %v, want ErrNoMovie", err)
+	}
+}
+
+func TestProbeNestingLimit(t *testing.T) {
+	nested := testBox("stbl")
+	for i := 0; i < maxTrackDepth+2; i++ {
+		nested = testBox("minf", nested)
+	}
+	file := testBox("moov", testBox("trak", nested))
+	if _, err := Probe(bytes.NewReader(file)); err == nil {
+		t.Error("Probe accepted boxes nested deeper than maxTrackDepth")
+	}
+
+	shallow := testBox("moov", testBox("trak", testBox("mdia", testBox("minf", testBox("stbl")))))
+	if _, err := Probe(bytes.NewReader(shallow)); err != nil {
+		t.Errorf("Probe of a regular trak box: %v", err)
+	}
+}
@@ -0,0 +1,419 @@
+// backend/models/access.go
+
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// AccessTokenLifetime is how long the access token unlocking password protected content stays valid
+const AccessTokenLifetime = 24 * time.Hour
+
+var (
+	// ErrPasswordRequired is returned when reading password protected content without a valid access token
+	ErrPasswordRequired = errors.New("content is password protected")
+	// ErrWrongPassword is returned when unlocking content with the wrong password
+	ErrWrongPassword = errors.New("wrong password")
+	// ErrNotPasswordProtected is returned when unlocking content that has no password
+	ErrNotPasswordProtected = errors.New("content is not password protected")
+	// ErrNotEditor is returned when a user may not change content
+	ErrNotEditor = errors.New("user cannot edit this content")
+	// ErrEmbeddingNotAllowed is returned when content is requested from a site it may not be embedded in
+	ErrEmbeddingNotAllowed = errors.New("content cannot be embedded on this site")
+)
+
+// AccessSetting controls who can reach private or password protected content and where it may be embedded.
+// Private content, and content with a password, is left out of listings and search results.
+type AccessSetting struct {
+	PasswordHash   string   `json:"-" gorm:"default:''"` // bcrypt hash, empty when the content has no password
+	AllowDownloads bool     `json:"allowDownloads" gorm:"default:true"`
+	AllowEmbedding bool     `json:"allowEmbedding" gorm:"default:true"`
+	EmbedDomains   []string `json:"embedDomains" gorm:"serializer:json"` // Sites allowed to embed the content, any when empty
+}
+
+// AllowsEmbedding reports whether the content may be embedded on a site. An empty origin is a request
+// from the API's own site or from a player that is not a web page, which is always allowed.
+func (s AccessSetting) AllowsEmbedding(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	if !s.AllowEmbedding {
+		return false
+	}
+	if len(s.EmbedDomains) == 0 {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, domain := range s.EmbedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))
+		if domain != "" && (origin == domain || strings.HasSuffix(origin, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
+
+// EmbedOrigin returns the host of the site embedding a request, from its Origin or Referer header,
+// or an empty string when the request comes from host itself or from outside a web page
+func EmbedOrigin(origin, referer, host string) string {
+	value := origin
+	if value == "" || value == "null" {
+		value = referer
+	}
+	if value == "" {
+		return ""
+	}
+	if i := strings.Index(value, "://"); i >= 0 {
+		value = value[i+3:]
+	}
+	if i := strings.IndexAny(value, "/?#"); i >= 0 {
+		value = value[:i]
+	}
+	if i := strings.LastIndex(value, "@"); i >= 0 {
+		value = value[i+1:]
+	}
+	if hostname, _, err := net.SplitHostPort(value); err == nil {
+		value = hostname
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	value = strings.ToLower(value)
+	if value == strings.ToLower(host) {
+		return ""
+	}
+	return value
+}
+
+// AccessGrant is an access token unlocking one password protected item, handed out for its password
+type AccessGrant struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	Token       string    `json:"accessToken" gorm:"uniqueIndex;size:64"`
+	ContentType string    `json:"contentType" gorm:"index:idx_access_grants_content,priority:1;size:32"`
+	ContentID   uint      `json:"contentId" gorm:"index:idx_access_grants_content,priority:2"`
+	ExpiresAt   time.Time `json:"expiresAt" gorm:"index"`
+	CreatedAt   time.Time `json:"-"`
+}
+
+// Viewer is who is reading content: the signed-in user, if any, and the content their access tokens unlocked
+type Viewer struct {
+	UserID   uint              // 0 for anonymous viewers
+	Unlocked map[string][]uint // IDs of password protected content, by content type
+}
+
+// accessTables describes where a type of protected content keeps its owners and contributors
+type accessTables struct {
+	table        string
+	contributors string // Join table of the contributors
+	key          string // Column of the join table referencing the content
+	owned        string // Query of the IDs of the content a user owns, the user ID bound twice
+}
+
+// accessControlled holds the tables of the content with privacy and access settings
+var accessControlled = map[string]accessTables{
+	ContentPlaylist: {
+		table:        "playlists",
+		contributors: "user_playlist_contributors",
+		key:          "playlist_id",
+		owned: "SELECT playlists.id FROM playlists WHERE playlists.owner_id = ? " +
+			"OR playlists.channel_id IN (SELECT channels.id FROM channels WHERE channels.owner_id = ?)",
+	},
+	ContentAdvertisement: {
+		table:        "advertisements",
+		contributors: "user_advertisement_contributors",
+		key:          "advertisement_id",
+		owned: "SELECT advertisements.id FROM advertisements JOIN playlists ON playlists.id = advertisements.playlist_id " +
+			"WHERE playlists.owner_id = ? OR playlists.channel_id IN (SELECT channels.id FROM channels WHERE channels.owner_id = ?)",
+	},
+}
+
+// AccessModel handles privacy checks, passwords and access tokens
+type AccessModel struct {
+	DB    *gorm.DB
+	Clock Clock
+}
+
+// NewAccessModel creates a new instance of AccessModel
+func NewAccessModel(db *gorm.DB) *AccessModel {
+	return &AccessModel{
+		DB:    db,
+		Clock: SystemClock{},
+	}
+}
+
+// accessTablesFor returns the tables of a type of protected content
+func accessTablesFor(contentType string) (accessTables, error) {
+	tables, ok := accessControlled[contentType]
+	if !ok {
+		return accessTables{}, fmt.Errorf("unknown content type %q", contentType)
+	}
+	return tables, nil
+}
+
+// visibleCondition returns the condition selecting the rows of a table a viewer can read: public content
+// without a password, content unlocked by the viewer and content the viewer owns or contributes to
+func visibleCondition(contentType string, tables accessTables, viewer Viewer) (string, []interface{}) {
+	t := tables.table
+	condition := "(" + t + ".is_public = ? AND COALESCE(" + t + ".password_hash, '') = '')"
+	args := []interface{}{true}
+	if unlocked := viewer.Unlocked[contentType]; len(unlocked) > 0 {
+		condition += " OR " + t + ".id IN ?"
+		args = append(args, unlocked)
+	}
+	if viewer.UserID != 0 {
+		condition += " OR " + t + ".id IN (" + tables.owned + ") OR " + t + ".id IN (SELECT " + tables.key +
+			" FROM " + tables.contributors + " WHERE user_id = ?)"
+		args = append(args, viewer.UserID, viewer.UserID, viewer.UserID)
+	}
+	return "(" + condition + ")", args
+}
+
+// VisiblePlaylists restricts a query to the playlists a viewer can read
+func VisiblePlaylists(viewer Viewer) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		condition, args := visibleCondition(ContentPlaylist, accessControlled[ContentPlaylist], viewer)
+		return db.Where(condition, args...)
+	}
+}
+
+// VisibleAdvertisements restricts a query to the advertisements a viewer can read
+func VisibleAdvertisements(viewer Viewer) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		condition, args := visibleCondition(ContentAdvertisement, accessControlled[ContentAdvertisement], viewer)
+		return db.Where(condition, args...)
+	}
+}
+
+// VisibleVideos restricts a query to the videos a viewer can read: those outside any playlist,
+// those in a playlist the viewer can read and the viewer's own uploads
+func VisibleVideos(viewer Viewer) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		playlists := db.Session(&gorm.Session{NewDB: true}).Model(&Playlist{}).Select("playlists.id").
+			Scopes(VisiblePlaylists(viewer))
+		if viewer.UserID != 0 {
+			return db.Where("(videos.playlist_id = 0 OR videos.playlist_id IN (?) OR videos.uploader_id = ?)", playlists, viewer.UserID)
+		}
+		return db.Where("(videos.playlist_id = 0 OR videos.playlist_id IN (?))", playlists)
+	}
+}
+
+// ResolveViewer returns the viewer with a user ID, unlocking the content of the valid access tokens
+func (am *AccessModel) ResolveViewer(userID uint, tokens []string) (Viewer, error) {
+	viewer := Viewer{UserID: userID, Unlocked: map[string][]uint{}}
+	if len(tokens) == 0 {
+		return viewer, nil
+	}
+	var grants []AccessGrant
+	if err := am.DB.Where("token IN ? AND expires_at > ?", tokens, am.Clock.Now()).Find(&grants).Error; err != nil {
+		return viewer, err
+	}
+	for _, grant := range grants {
+		viewer.Unlocked[grant.ContentType] = append(viewer.Unlocked[grant.ContentType], grant.ContentID)
+	}
+	return viewer, nil
+}
+
+// CanView returns nil if a viewer can read a video, playlist or advertisement, ErrPasswordRequired if it
+// is password protected and gorm.ErrRecordNotFound if it does not exist or is private, so as not to reveal it
+func (am *AccessModel) CanView(contentType string, contentID uint, viewer Viewer) error {
+	if contentType == ContentVideo {
+		var video Video
+		if err := am.DB.Select("id", "playlist_id", "uploader_id").First(&video, contentID).Error; err != nil {
+			return err
+		}
+		if video.PlaylistID == 0 || (viewer.UserID != 0 && video.UploaderID == viewer.UserID) {
+			return nil
+		}
+		return am.CanView(ContentPlaylist, video.PlaylistID, viewer)
+	}
+
+	tables, err := accessTablesFor(contentType)
+	if err != nil {
+		return err
+	}
+	condition, args := visibleCondition(contentType, tables, viewer)
+	var visible int64
+	if err := am.DB.Table(tables.table).Where("id = ? AND deleted_at IS NULL", contentID).
+		Where(condition, args...).Count(&visible).Error; err != nil {
+		return err
+	}
+	if visible > 0 {
+		return nil
+	}
+
+	var row struct{ PasswordHash string }
+	if err := am.DB.Table(tables.table).Select("COALESCE(password_hash, '') AS password_hash").
+		Where("id = ? AND deleted_at IS NULL", contentID).Take(&row).Error; err != nil {
+		return err
+	}
+	if row.PasswordHash != "" {
+		return ErrPasswordRequired
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// CanManage returns nil if a user owns a video, playlist or advertisement, directly or through its channel.
+// Only owners change privacy and access settings and delete content.
+func (am *AccessModel) CanManage(contentType string, contentID, userID uint) error {
+	if contentType == ContentVideo {
+		return am.canManageVideo(contentID, userID)
+	}
+
+	tables, err := accessTablesFor(contentType)
+	if err != nil {
+		return err
+	}
+	if err := am.DB.Table(tables.table).Select("id").Where("id = ? AND deleted_at IS NULL", contentID).
+		Take(&struct{ ID uint }{}).Error; err != nil {
+		return err
+	}
+	if userID == 0 {
+		return ErrNotEditor
+	}
+	var owned int64
+	if err := am.DB.Raw("SELECT COUNT(*) FROM ("+tables.owned+") AS owned WHERE owned.id = ?",
+		userID, userID, contentID).Scan(&owned).Error; err != nil {
+		return err
+	}
+	if owned == 0 {
+		return ErrNotEditor
+	}
+	return nil
+}
+
+// canManageVideo returns nil if a user uploaded a video, owns its channel or owns its playlist
+func (am *AccessModel) canManageVideo(videoID, userID uint) error {
+	var video Video
+	if err := am.DB.Select("id", "playlist_id", "channel_id", "uploader_id").First(&video, videoID).Error; err != nil {
+		return err
+	}
+	if userID == 0 {
+		return ErrNotEditor
+	}
+	if video.UploaderID == userID {
+		return nil
+	}
+	if video.ChannelID != 0 {
+		var owned int64
+		if err := am.DB.Model(&Channel{}).Where("id = ? AND owner_id = ?", video.ChannelID, userID).Count(&owned).Error; err != nil {
+			return err
+		}
+		if owned > 0 {
+			return nil
+		}
+	}
+	if video.PlaylistID != 0 {
+		err := am.CanManage(ContentPlaylist, video.PlaylistID, userID)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	return ErrNotEditor
+}
+
+// CanEdit returns nil if a user may change a playlist or advertisement: its owners always can,
+// its contributors when it is collaborative
+func (am *AccessModel) CanEdit(contentType string, contentID, userID uint) error {
+	err := am.CanManage(contentType, contentID, userID)
+	if !errors.Is(err, ErrNotEditor) || userID == 0 {
+		return err
+	}
+
+	tables := accessControlled[contentType]
+	var contributor int64
+	if err := am.DB.Table(tables.table).
+		Joins("JOIN "+tables.contributors+" ON "+tables.contributors+"."+tables.key+" = "+tables.table+".id").
+		Where(tables.table+".id = ? AND "+tables.contributors+".user_id = ? AND "+tables.table+".is_collaborative = ?", contentID, userID, true).
+		Count(&contributor).Error; err != nil {
+		return err
+	}
+	if contributor == 0 {
+		return ErrNotEditor
+	}
+	return nil
+}
+
+// SetPassword protects a playlist or advertisement with a password, or removes the protection when the
+// password is empty. The access tokens handed out for the previous password stop working.
+func (am *AccessModel) SetPassword(contentType string, contentID uint, password string) error {
+	tables, err := accessTablesFor(contentType)
+	if err != nil {
+		return err
+	}
+	hash := ""
+	if password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		hash = string(hashed)
+	}
+
+	return am.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(tables.table).Where("id = ? AND deleted_at IS NULL", contentID).UpdateColumn("password_hash", hash)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("content_type = ? AND content_id = ?", contentType, contentID).Delete(&AccessGrant{}).Error
+	})
+}
+
+// Unlock checks the password of a playlist or advertisement and hands out an access token for it
+func (am *AccessModel) Unlock(contentType string, contentID uint, password string) (*AccessGrant, error) {
+	tables, err := accessTablesFor(contentType)
+	if err != nil {
+		return nil, err
+	}
+	var row struct{ PasswordHash string }
+	if err := am.DB.Table(tables.table).Select("COALESCE(password_hash, '') AS password_hash").
+		Where("id = ? AND deleted_at IS NULL", contentID).Take(&row).Error; err != nil {
+		return nil, err
+	}
+	if row.PasswordHash == "" {
+		return nil, ErrNotPasswordProtected
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(row.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrWrongPassword
+	}
+
+	token, err := newAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	grant := AccessGrant{
+		Token:       token,
+		ContentType: contentType,
+		ContentID:   contentID,
+		ExpiresAt:   am.Clock.Now().Add(AccessTokenLifetime),
+	}
+	if err := am.DB.Create(&grant).Error; err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// PurgeExpiredAccessGrants deletes the access tokens that expired
+func (am *AccessModel) PurgeExpiredAccessGrants() error {
+	return am.DB.Where("expires_at <= ?", am.Clock.Now()).Delete(&AccessGrant{}).Error
+}
+
+// newAccessToken returns a random, unguessable access token
+func newAccessToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
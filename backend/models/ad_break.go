@@ -0,0 +1,94 @@
+// backend/models/ad_break.go
+
+package models
+
+import (
+	"gorm.io/gorm/clause"
+)
+
+// DefaultAdBreakInterval is the number of seconds of content between ad breaks
+const DefaultAdBreakInterval = 600
+
+// AdBreak is an ad pod that plays before the video at Position in the playlist's video order
+type AdBreak struct {
+	Position int   `json:"position"`
+	Pod      AdPod `json:"pod"`
+}
+
+// AdBreakIntervalSeconds returns the seconds of content between a playlist's ad breaks
+func (p Playlist) AdBreakIntervalSeconds() int {
+	if p.AdBreakInterval > 0 {
+		return p.AdBreakInterval
+	}
+	return DefaultAdBreakInterval
+}
+
+// PlaybackDuration returns the video's duration in seconds, preferring the declared value over the probed one
+func (v Video) PlaybackDuration() int {
+	if v.Duration > 0 {
+		return v.Duration
+	}
+	return v.Probe.Duration
+}
+
+// GetPlaylistVideos fetches the videos of a playlist in playback order
+func (vm *VideoModel) GetPlaylistVideos(playlistID uint) ([]Video, error) {
+	var videos []Video
+	if err := vm.DB.Preload("Category").Where("playlist_id = ?", playlistID).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "order"}}).Order("id").
+		Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// BreakPositions returns where ad breaks go in a sequence of videos: a break is placed before
+// the next video once at least interval seconds of content have played since the last break.
+// Breaks are never placed before the first video or after the last one.
+func BreakPositions(videos []Video, interval int) []int {
+	positions := []int{}
+	elapsed := 0
+	for i := 0; i < len(videos)-1; i++ {
+		elapsed += videos[i].PlaybackDuration()
+		if elapsed >= interval {
+			positions = append(positions, i+1)
+			elapsed = 0
+		}
+	}
+	return positions
+}
+
+// PlanAdBreaks fills an ad pod for every break position in a playlist's videos. Each break is targeted
+// at the video before it, and advertisements already used in earlier breaks are avoided where possible.
+func (am *AdvertisementModel) PlanAdBreaks(selection SelectionContext, videos []Video) ([]AdBreak, error) {
+	breaks := []AdBreak{}
+	used := map[uint]bool{}
+	options := selection.Playlist.PodOptions()
+
+	for _, position := range BreakPositions(videos, selection.Playlist.AdBreakIntervalSeconds()) {
+		breakSelection := selection
+		breakSelection.Video = &videos[position-1]
+		candidates, err := am.GetEligibleAdvertisements(breakSelection)
+		if err != nil {
+			return nil, err
+		}
+
+		fresh := make([]Advertisement, 0, len(candidates))
+		for _, candidate := range candidates {
+			if !used[candidate.ID] {
+				fresh = append(fresh, candidate)
+			}
+		}
+		pod := BuildAdPod(fresh, options)
+		if len(pod.Advertisements) == 0 {
+			pod = BuildAdPod(candidates, options)
+		}
+
+		for _, advertisement := range pod.Advertisements {
+			used[advertisement.ID] = true
+		}
+		breaks = append(breaks, AdBreak{Position: position, Pod: pod})
+	}
+
+	return breaks, nil
+}
@@ -0,0 +1,87 @@
+// backend/models/ad_pod.go
+
+package models
+
+import "strings"
+
+const (
+	// DefaultAdBreakDuration is the target length of an ad break in seconds
+	DefaultAdBreakDuration = 120
+	// DefaultMaxAdsPerBreak is the maximum number of advertisements in one ad break
+	DefaultMaxAdsPerBreak = 4
+)
+
+// PodOptions controls how an ad pod is filled
+type PodOptions struct {
+	TargetDuration int // Seconds available in the break
+	MaxAds         int // Maximum number of advertisements in the pod
+}
+
+// AdPod is an ordered set of advertisements that play back to back in one break
+type AdPod struct {
+	Advertisements []Advertisement `json:"advertisements"`
+	Duration       int             `json:"duration"` // Total duration in seconds
+}
+
+// PodOptions returns the ad break settings of a playlist, falling back to the defaults
+func (p Playlist) PodOptions() PodOptions {
+	options := PodOptions{TargetDuration: p.AdBreakDuration, MaxAds: p.MaxAdsPerBreak}
+	if options.TargetDuration <= 0 {
+		options.TargetDuration = DefaultAdBreakDuration
+	}
+	if options.MaxAds <= 0 {
+		options.MaxAds = DefaultMaxAdsPerBreak
+	}
+	return options
+}
+
+// BuildAdPod fills a break from candidates, which should be in priority order.
+// Each slot takes the highest priority candidate that still fits the remaining time,
+// is not already in the pod and is not in the same exclusion group as the previous slot.
+// Candidates without a duration are skipped since they cannot be planned into a break.
+func BuildAdPod(candidates []Advertisement, options PodOptions) AdPod {
+	pod := AdPod{Advertisements: []Advertisement{}}
+	used := make(map[uint]bool, len(candidates))
+
+	for len(pod.Advertisements) < options.MaxAds {
+		var previous *Advertisement
+		if len(pod.Advertisements) > 0 {
+			previous = &pod.Advertisements[len(pod.Advertisements)-1]
+		}
+
+		next := -1
+		for i, candidate := range candidates {
+			if used[candidate.ID] || candidate.Duration <= 0 || pod.Duration+candidate.Duration > options.TargetDuration {
+				continue
+			}
+			if previous != nil && competes(*previous, candidate) {
+				continue
+			}
+			next = i
+			break
+		}
+		if next < 0 {
+			break
+		}
+
+		used[candidates[next].ID] = true
+		pod.Advertisements = append(pod.Advertisements, candidates[next])
+		pod.Duration += candidates[next].Duration
+	}
+
+	return pod
+}
+
+// competes reports whether two advertisements must not play back to back
+func competes(a, b Advertisement) bool {
+	return a.ExclusionGroup != "" && strings.EqualFold(a.ExclusionGroup, b.ExclusionGroup)
+}
+
+// GetAdPodForPlaylist selects the eligible advertisements for a placement and fills an ad break with them
+func (am *AdvertisementModel) GetAdPodForPlaylist(selection SelectionContext) (AdPod, error) {
+	candidates, err := am.GetEligibleAdvertisements(selection)
+	if err != nil {
+		return AdPod{}, err
+	}
+	return BuildAdPod(candidates, selection.Playlist.PodOptions()), nil
+}
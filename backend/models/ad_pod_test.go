@@ -0,0 +1,129 @@
+// backend/models/ad_pod_test.go
+
+package models_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// podInput is a random BuildAdPod call. Candidates repeat IDs, share exclusion groups in different
+// cases and include advertisements without a duration, so every rule of BuildAdPod gets exercised.
+type podInput struct {
+	Candidates []models.Advertisement
+	Options    models.PodOptions
+}
+
+// exclusionGroups are the groups random candidates pick from, the empty group competing with nothing
+var exclusionGroups = []string{"", "", "automotive", "Automotive", "beverages", "telecom"}
+
+// Generate implements quick.Generator
+func (podInput) Generate(r *rand.Rand, size int) reflect.Value {
+	input := podInput{
+		Candidates: make([]models.Advertisement, r.Intn(size+1)),
+		Options:    models.PodOptions{TargetDuration: r.Intn(180), MaxAds: r.Intn(7)},
+	}
+	for i := range input.Candidates {
+		advertisement := &input.Candidates[i]
+		advertisement.ID = uint(r.Intn(size+1) + 1)
+		advertisement.Duration = r.Intn(70) - 5
+		advertisement.ExclusionGroup = exclusionGroups[r.Intn(len(exclusionGroups))]
+	}
+	return reflect.ValueOf(input)
+}
+
+// checkPod runs a BuildAdPod property over random inputs
+func checkPod(t *testing.T, property func(input podInput, pod models.AdPod) bool) {
+	t.Helper()
+	check := func(input podInput) bool {
+		return property(input, models.BuildAdPod(input.Candidates, input.Options))
+	}
+	if err := quick.Check(check, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBuildAdPodHasNoDuplicateAds(t *testing.T) {
+	checkPod(t, func(input podInput, pod models.AdPod) bool {
+		seen := map[uint]bool{}
+		for _, advertisement := range pod.Advertisements {
+			if seen[advertisement.ID] {
+				return false
+			}
+			seen[advertisement.ID] = true
+		}
+		return true
+	})
+}
+
+func TestBuildAdPodSeparatesExclusionGroups(t *testing.T) {
+	checkPod(t, func(input podInput, pod models.AdPod) bool {
+		for i := 1; i < len(pod.Advertisements); i++ {
+			previous, current := pod.Advertisements[i-1].ExclusionGroup, pod.Advertisements[i].ExclusionGroup
+			if previous != "" && strings.EqualFold(previous, current) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestBuildAdPodFitsTargetDuration(t *testing.T) {
+	checkPod(t, func(input podInput, pod models.AdPod) bool {
+		total := 0
+		for _, advertisement := range pod.Advertisements {
+			if advertisement.Duration <= 0 {
+				return false
+			}
+			total += advertisement.Duration
+		}
+		return total == pod.Duration && pod.Duration <= input.Options.TargetDuration
+	})
+}
+
+func TestBuildAdPodRespectsMaxAds(t *testing.T) {
+	checkPod(t, func(input podInput, pod models.AdPod) bool {
+		return len(pod.Advertisements) <= input.Options.MaxAds
+	})
+}
+
+func TestBuildAdPodOnlyUsesCandidates(t *testing.T) {
+	checkPod(t, func(input podInput, pod models.AdPod) bool {
+		for _, advertisement := range pod.Advertisements {
+			found := false
+			for _, candidate := range input.Candidates {
+				found = found || reflect.DeepEqual(candidate, advertisement)
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestBuildAdPodFillsInPriorityOrder(t *testing.T) {
+	candidates := []models.Advertisement{
+		{Model: gorm.Model{ID: 1}, Duration: 30, ExclusionGroup: "automotive"},
+		{Model: gorm.Model{ID: 2}, Duration: 30, ExclusionGroup: "automotive"},
+		{Model: gorm.Model{ID: 3}, Duration: 90},
+		{Model: gorm.Model{ID: 4}, Duration: 15, ExclusionGroup: "beverages"},
+	}
+	pod := models.BuildAdPod(candidates, models.PodOptions{TargetDuration: 90, MaxAds: 4})
+
+	var ids []uint
+	for _, advertisement := range pod.Advertisements {
+		ids = append(ids, advertisement.ID)
+	}
+	// The second automotive ad waits for the beverages ad, and the 90 second one never fits
+	if want := []uint{1, 4, 2}; !reflect.DeepEqual(ids, want) || pod.Duration != 75 {
+		t.Errorf("got ads %v lasting %ds, want %v lasting 75s", ids, pod.Duration, want)
+	}
+}
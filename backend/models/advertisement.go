@@ -5,45 +5,59 @@ package models
 import (
 	"time"
 
+	"github.com/shuttlersit/ads-player/backend/targeting"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Advertisement model
 type Advertisement struct {
 	gorm.Model
-	PlaylistID       uint                   `json:"-"`
-	Playlist         Playlist               `json:"playlist"`
-	ContentURL       string                 `json:"contentURL"`
-	Title            string                 `json:"title"`
-	Description      string                 `json:"description"`
-	Duration         int                    `json:"duration"` // Duration in seconds
-	ScheduledAt      time.Time              `json:"scheduledAt"`
-	Played           bool                   `json:"played" gorm:"default:false"`
-	ClickThroughURL  string                 `json:"clickThroughURL"`
-	Analytics        AdvertisementAnalytics `json:"analytics" gorm:"embedded"`
-	IsFeatured       bool                   `json:"isFeatured" gorm:"default:false"`
-	IsPublic         bool                   `json:"isPublic" gorm:"default:true"`
-	Tags             []string               `json:"tags" gorm:"type:varchar(255)[]"`
-	LikeCount        uint                   `json:"likeCount" gorm:"default:0"`
-	DislikeCount     uint                   `json:"dislikeCount" gorm:"default:0"`
-	Comments         []Comment              `gorm:"foreignKey:AdvertisementID"`
-	ShareCount       uint                   `json:"shareCount" gorm:"default:0"`
-	Followers        []User                 `gorm:"many2many:user_advertisement_followers;"`
-	Contributors     []User                 `gorm:"many2many:user_advertisement_contributors;"`
-	RelatedAds       []RelatedAd            `json:"relatedAds" gorm:"foreignKey:AdvertisementID"`
-	LastModified     int                    `json:"lastModified" gorm:"autoUpdateTime"`
-	PrivacySetting   PrivacySetting         `json:"privacySetting" gorm:"embedded"`
-	Location         Location               `json:"location" gorm:"embedded"`
-	VideoQuality     string                 `json:"videoQuality"`
-	AudioQuality     string                 `json:"audioQuality"`
-	Caption          string                 `json:"caption"`
-	Language         string                 `json:"language"`
-	TargetAudience   string                 `json:"targetAudience"`
-	MatureContent    bool                   `json:"matureContent"`
-	ThumbnailURL     string                 `json:"thumbnailURL"`
-	ExternalLinks    []ExternalLink         `json:"externalLinks" gorm:"foreignKey:AdvertisementID"`
-	MediaAttachments []MediaAttachment      `json:"mediaAttachments" gorm:"foreignKey:AdvertisementID"`
-	Hashtags         []Hashtag              `json:"hashtags" gorm:"foreignKey:AdvertisementID"`
+	PlaylistID        uint                           `json:"-" gorm:"index:idx_advertisements_eligibility,priority:1"`
+	Playlist          Playlist                       `json:"playlist"`
+	ContentURL        string                         `json:"contentURL"`
+	Creative          CreativeFile                   `json:"creative" gorm:"embedded"`
+	Probe             MediaProbe                     `json:"probe" gorm:"embedded;embeddedPrefix:probe_"`
+	Title             string                         `json:"title"`
+	Description       string                         `json:"description"`
+	Duration          int                            `json:"duration"` // Duration in seconds
+	ScheduledAt       time.Time                      `json:"scheduledAt" gorm:"index:idx_advertisements_eligibility,priority:3"`
+	ExclusionGroup    string                         `json:"exclusionGroup" gorm:"index"` // Competing brands, e.g. "automotive", never play back to back
+	Dayparts          []DaypartRule                  `json:"dayparts" gorm:"foreignKey:AdvertisementID"`
+	GeoTargets        []GeoTargetRule                `json:"geoTargets" gorm:"foreignKey:AdvertisementID"`
+	Played            bool                           `json:"played" gorm:"default:false;index:idx_advertisements_eligibility,priority:2"`
+	IsPaused          bool                           `json:"isPaused" gorm:"default:false"`
+	ClickThroughURL   string                         `json:"clickThroughURL"`
+	Analytics         AdvertisementAnalytics         `json:"analytics" gorm:"embedded"`
+	IsFeatured        bool                           `json:"isFeatured" gorm:"default:false"`
+	IsPublic          bool                           `json:"isPublic" gorm:"default:true"`
+	Tags              []string                       `json:"tags" gorm:"serializer:json"`
+	CategoryID        uint                           `json:"categoryId" gorm:"index"` // What is advertised, 0 when uncategorized
+	Category          *Category                      `json:"category,omitempty"`
+	IncludeCategories []Category                     `json:"includeCategories" gorm:"many2many:advertisement_include_categories;"` // Only play in these placement categories, when set
+	ExcludeCategories []Category                     `json:"excludeCategories" gorm:"many2many:advertisement_exclude_categories;"` // Never play in these placement categories
+	LikeCount         uint                           `json:"likeCount" gorm:"default:0"`
+	DislikeCount      uint                           `json:"dislikeCount" gorm:"default:0"`
+	Comments          []Comment                      `gorm:"foreignKey:AdvertisementID"`
+	ShareCount        uint                           `json:"shareCount" gorm:"default:0"`
+	Followers         []User                         `gorm:"many2many:user_advertisement_followers;"`
+	Contributors      []User                         `gorm:"many2many:user_advertisement_contributors;"`
+	RelatedAds        []RelatedAd                    `json:"relatedAds" gorm:"foreignKey:AdvertisementID"`
+	LastModified      int                            `json:"lastModified" gorm:"autoUpdateTime"`
+	PrivacySetting    PrivacySetting                 `json:"privacySetting" gorm:"embedded"`
+	Access            AccessSetting                  `json:"access" gorm:"embedded"`
+	Location          Location                       `json:"location" gorm:"embedded"`
+	VideoQuality      string                         `json:"videoQuality"`
+	AudioQuality      string                         `json:"audioQuality"`
+	Caption           string                         `json:"caption"`
+	Language          string                         `json:"language"`
+	TargetAudience    string                         `json:"targetAudience"`
+	Targeting         string                         `json:"targeting"` // Expression such as `language in ("en","fr") AND tag:sports`
+	MatureContent     bool                           `json:"matureContent"`
+	ThumbnailURL      string                         `json:"thumbnailURL"`
+	ExternalLinks     []ExternalLink                 `json:"externalLinks" gorm:"foreignKey:AdvertisementID"`
+	MediaAttachments  []AdvertisementMediaAttachment `json:"mediaAttachments" gorm:"foreignKey:AdvertisementID"`
+	Hashtags          []AdvertisementHashtag         `json:"hashtags" gorm:"foreignKey:AdvertisementID"`
 }
 
 // AdvertisementAnalytics struct for tracking advertisement analytics
@@ -62,13 +76,12 @@ type AdvertisementAnalytics struct {
 	// Add more analytics fields as needed
 }
 
-// PrivacySetting struct for advertisement privacy settings
-type AdvertisementPrivacySetting struct {
-	IsPublic       bool   `json:"isPublic" gorm:"default:true"`
-	Password       string `json:"password,omitempty"`
-	AllowComments  bool   `json:"allowComments" gorm:"default:true"`
-	AllowDownloads bool   `json:"allowDownloads" gorm:"default:true"`
-	AllowEmbedding bool   `json:"allowEmbedding" gorm:"default:true"`
+// CreativeFile struct for the uploaded media file behind ContentURL
+type CreativeFile struct {
+	ContentKey      string `json:"contentKey"` // Storage key of the file
+	ContentType     string `json:"contentType"`
+	ContentSize     int64  `json:"contentSize"`     // Size in bytes
+	ContentChecksum string `json:"contentChecksum"` // Hex encoded SHA-256
 }
 
 // Location struct for advertisement location details
@@ -115,34 +128,60 @@ type AdvertisementHashtag struct {
 	// Add more fields as needed
 }
 
-// RelatedAd struct for storing related advertisements
+// RelatedAd struct for storing related advertisements, computed by RefreshRelatedContent or pinned by hand
 type RelatedAd struct {
 	gorm.Model
-	AdvertisementID        uint `json:"-"`
-	RelatedAdvertisementID uint `json:"relatedAdvertisementID"`
-	Order                  int  `json:"order" gorm:"default:0"`
+	AdvertisementID        uint    `json:"-" gorm:"index"`
+	RelatedAdvertisementID uint    `json:"relatedAdvertisementID"`
+	Score                  float64 `json:"score"`
+	Pinned                 bool    `json:"pinned" gorm:"default:false"`
+	Order                  int     `json:"order" gorm:"default:0"` // Position among the pinned entries
+}
+
+// Validate checks the advertisement's targeting rules
+func (a *Advertisement) Validate() error {
+	if err := ValidateDayparts(a.Dayparts); err != nil {
+		return err
+	}
+	if err := ValidateGeoTargets(a.GeoTargets); err != nil {
+		return err
+	}
+	if err := targeting.Validate(a.Targeting); err != nil {
+		return err
+	}
+	return nil
 }
 
 // AdvertisementModel handles database operations for Advertisement
 type AdvertisementModel struct {
-	DB *gorm.DB
+	DB    *gorm.DB
+	Clock Clock
 }
 
 // NewAdvertisementModel creates a new instance of AdvertisementModel
 func NewAdvertisementModel(db *gorm.DB) *AdvertisementModel {
 	return &AdvertisementModel{
-		DB: db,
+		DB:    db,
+		Clock: SystemClock{},
 	}
 }
 
 // GetNextAdvertisementForPlaylist fetches the next advertisement to play for a playlist
 func (am *AdvertisementModel) GetNextAdvertisementForPlaylist(playlistID uint) (*Advertisement, error) {
-	var advertisement Advertisement
-	if err := am.DB.Where("playlist_id = ? AND scheduled_at <= ? AND played = ?", playlistID, time.Now(), false).Order("scheduled_at").First(&advertisement).Error; err != nil {
+	var playlist Playlist
+	if err := am.DB.Preload("Channel.Categories").First(&playlist, playlistID).Error; err != nil {
 		return nil, err
 	}
 
-	return &advertisement, nil
+	advertisements, err := am.GetEligibleAdvertisements(SelectionContext{Playlist: &playlist, Time: am.Clock.Now()})
+	if err != nil {
+		return nil, err
+	}
+	if len(advertisements) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &advertisements[0], nil
 }
 
 // MarkAdvertisementAsPlayed marks an advertisement as played (update status or other relevant fields)
@@ -165,7 +204,7 @@ func (am *AdvertisementModel) MarkAdvertisementAsPlayed(advertisementID uint) er
 // GetAdvertisementByID fetches an advertisement by its ID
 func (am *AdvertisementModel) GetAdvertisementByID(advertisementID uint) (*Advertisement, error) {
 	var advertisement Advertisement
-	if err := am.DB.Preload("Playlist").Preload("Comments").Preload("Followers").Preload("Contributors").Preload("RelatedAds").First(&advertisement, advertisementID).Error; err != nil {
+	if err := am.DB.Preload("Playlist").Preload("Dayparts").Preload("GeoTargets").Scopes(WithComments).Preload("Followers").Preload("Contributors").Preload("RelatedAds").First(&advertisement, advertisementID).Error; err != nil {
 		return nil, err
 	}
 	return &advertisement, nil
@@ -174,7 +213,7 @@ func (am *AdvertisementModel) GetAdvertisementByID(advertisementID uint) (*Adver
 // GetAllAdvertisements fetches all advertisements
 func (am *AdvertisementModel) GetAllAdvertisements() ([]Advertisement, error) {
 	var advertisements []Advertisement
-	if err := am.DB.Preload("Playlist").Preload("Comments").Preload("Followers").Preload("Contributors").Preload("RelatedAds").Find(&advertisements).Error; err != nil {
+	if err := am.DB.Preload("Playlist").Preload("Dayparts").Preload("GeoTargets").Scopes(WithComments).Preload("Followers").Preload("Contributors").Preload("RelatedAds").Find(&advertisements).Error; err != nil {
 		return nil, err
 	}
 	return advertisements, nil
@@ -182,18 +221,51 @@ func (am *AdvertisementModel) GetAllAdvertisements() ([]Advertisement, error) {
 
 // CreateAdvertisement creates a new advertisement
 func (am *AdvertisementModel) CreateAdvertisement(advertisement *Advertisement) error {
+	if err := advertisement.Validate(); err != nil {
+		return err
+	}
 	if err := am.DB.Create(advertisement).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
-// UpdateAdvertisement updates an existing advertisement
+// UpdateAdvertisement updates an existing advertisement. Its dayparts and geo targets are replaced by
+// the ones it holds, in the same transaction, so that removed rules stop applying.
 func (am *AdvertisementModel) UpdateAdvertisement(advertisement *Advertisement) error {
-	if err := am.DB.Save(advertisement).Error; err != nil {
+	if err := advertisement.Validate(); err != nil {
 		return err
 	}
-	return nil
+	return am.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(advertisement).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("advertisement_id = ?", advertisement.ID).Delete(&DaypartRule{}).Error; err != nil {
+			return err
+		}
+		for i := range advertisement.Dayparts {
+			advertisement.Dayparts[i].Model = gorm.Model{}
+			advertisement.Dayparts[i].AdvertisementID = advertisement.ID
+		}
+		if len(advertisement.Dayparts) > 0 {
+			if err := tx.Create(&advertisement.Dayparts).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Where("advertisement_id = ?", advertisement.ID).Delete(&GeoTargetRule{}).Error; err != nil {
+			return err
+		}
+		for i := range advertisement.GeoTargets {
+			advertisement.GeoTargets[i].Model = gorm.Model{}
+			advertisement.GeoTargets[i].AdvertisementID = advertisement.ID
+		}
+		if len(advertisement.GeoTargets) > 0 {
+			return tx.Create(&advertisement.GeoTargets).Error
+		}
+		return nil
+	})
 }
 
 // DeleteAdvertisement deletes an advertisement by its ID
@@ -204,10 +276,44 @@ func (am *AdvertisementModel) DeleteAdvertisement(advertisementID uint) error {
 	return nil
 }
 
+// SetPaused pauses or resumes an advertisement; paused advertisements are never selected
+func (am *AdvertisementModel) SetPaused(advertisementID uint, paused bool) error {
+	result := am.DB.Model(&Advertisement{}).Where("id = ?", advertisementID).Update("is_paused", paused)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateCreative records an uploaded creative file on an advertisement
+func (am *AdvertisementModel) UpdateCreative(advertisementID uint, contentURL string, creative CreativeFile) error {
+	if err := am.DB.Model(&Advertisement{}).Where("id = ?", advertisementID).Updates(map[string]interface{}{
+		"content_url":      contentURL,
+		"content_key":      creative.ContentKey,
+		"content_type":     creative.ContentType,
+		"content_size":     creative.ContentSize,
+		"content_checksum": creative.ContentChecksum,
+	}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateThumbnail sets the thumbnail URL of an advertisement
+func (am *AdvertisementModel) UpdateThumbnail(advertisementID uint, thumbnailURL string) error {
+	if err := am.DB.Model(&Advertisement{}).Where("id = ?", advertisementID).Update("thumbnail_url", thumbnailURL).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
 // GetAdvertisementsByPlaylistID fetches all advertisements for a specific playlist
 func (am *AdvertisementModel) GetAdvertisementsByPlaylistID(playlistID uint) ([]Advertisement, error) {
 	var advertisements []Advertisement
-	if err := am.DB.Where("playlist_id = ?", playlistID).Preload("Playlist").Preload("Comments").Preload("Followers").Preload("Contributors").Preload("RelatedAds").Find(&advertisements).Error; err != nil {
+	if err := am.DB.Where("playlist_id = ?", playlistID).Preload("Playlist").Preload("Dayparts").Preload("GeoTargets").Scopes(WithComments).Preload("Followers").Preload("Contributors").Preload("RelatedAds").Find(&advertisements).Error; err != nil {
 		return nil, err
 	}
 	return advertisements, nil
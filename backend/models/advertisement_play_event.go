@@ -4,6 +4,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // AdvertisementPlayEvent represents an event when an advertisement is played
@@ -11,7 +13,7 @@ type AdvertisementPlayEvent struct {
 	ID              uint `gorm:"primaryKey"`
 	AdvertisementID uint
 	PlaylistID      uint
-	PlayTime        time.Time
+	PlayTime        time.Time `gorm:"index"`
 }
 
 // LogAdvertisementPlayEvent logs an event when an advertisement is played
@@ -19,7 +21,7 @@ func (am *AdvertisementModel) LogAdvertisementPlayEvent(advertisementID, playlis
 	playEvent := AdvertisementPlayEvent{
 		AdvertisementID: advertisementID,
 		PlaylistID:      playlistID,
-		PlayTime:        time.Now(),
+		PlayTime:        am.Clock.Now(),
 	}
 
 	if err := am.DB.Create(&playEvent).Error; err != nil {
@@ -28,3 +30,48 @@ func (am *AdvertisementModel) LogAdvertisementPlayEvent(advertisementID, playlis
 
 	return nil
 }
+
+// RecordImpression records the impression beacon a player fired for an advertisement of a playlist,
+// counting it as a play
+func (am *AdvertisementModel) RecordImpression(advertisementID, playlistID uint) error {
+	return am.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").Where("playlist_id = ?", playlistID).First(&Advertisement{}, advertisementID).Error; err != nil {
+			return err
+		}
+		now := am.Clock.Now()
+		if err := tx.Create(&AdvertisementTrackingEvent{
+			AdvertisementID: advertisementID,
+			PlaylistID:      playlistID,
+			Event:           TrackingEventImpression,
+			OccurredAt:      now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&AdvertisementPlayEvent{
+			AdvertisementID: advertisementID,
+			PlaylistID:      playlistID,
+			PlayTime:        now,
+		}).Error
+	})
+}
+
+// GetPlayEventsAfter fetches up to limit play events with an ID greater than afterID, oldest first
+func (am *AdvertisementModel) GetPlayEventsAfter(afterID uint, limit int) ([]AdvertisementPlayEvent, error) {
+	var events []AdvertisementPlayEvent
+	if err := am.DB.Where("id > ?", afterID).Order("id").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetLatestPlayEvents fetches the most recent play events, oldest first
+func (am *AdvertisementModel) GetLatestPlayEvents(limit int) ([]AdvertisementPlayEvent, error) {
+	var events []AdvertisementPlayEvent
+	if err := am.DB.Order("id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
@@ -0,0 +1,159 @@
+// backend/models/advertisement_selection.go
+
+package models
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/targeting"
+)
+
+// KidsCategory is the slug of the category that mature advertisements are never served into, nor into its subcategories
+const KidsCategory = "kids"
+
+// SelectionContext describes where and when advertisements are about to play
+type SelectionContext struct {
+	Playlist *Playlist // Channel and Channel.Categories should be loaded for dayparting and targeting
+	Video    *Video    // Video the advertisement plays around, if known
+	Location *Location // Location of the requesting device, defaults to the playlist's location
+	Time     time.Time
+	Played   map[uint]bool // Advertisements to treat as played in addition to the stored Played flag, used by simulations
+	Origin   string        // Site embedding the player, see EmbedOrigin, empty when it is not embedded
+}
+
+// location returns the location advertisements are targeted against
+func (sc SelectionContext) location() Location {
+	if sc.Location != nil {
+		return *sc.Location
+	}
+	return sc.Playlist.Location
+}
+
+// targetingContext collects the placement attributes that targeting expressions are evaluated against.
+// Categories are matched by slug, which unlike names are unique and stable.
+func (sc SelectionContext) targetingContext() targeting.Context {
+	attributes := targeting.Context{}
+	attributes.Add("language", sc.Playlist.Language)
+	attributes.Add("tag", sc.Playlist.Tags...)
+	for _, category := range sc.Playlist.Channel.Categories {
+		attributes.Add("category", category.Slug)
+	}
+	if sc.Video != nil {
+		attributes.Add("language", sc.Video.Language)
+		attributes.Add("tag", sc.Video.Tags...)
+		if sc.Video.Category != nil {
+			attributes.Add("category", sc.Video.Category.Slug)
+		}
+	}
+
+	location := sc.location()
+	attributes.Add("country", location.Country)
+	attributes.Add("region", location.Region, location.State)
+	attributes.Add("city", location.City)
+	return attributes
+}
+
+// isKidsPlacement reports whether the placement categories, with their ancestors, include the kids category
+func (am *AdvertisementModel) isKidsPlacement(placement map[uint]bool) (bool, error) {
+	if len(placement) == 0 {
+		return false, nil
+	}
+	var kids Category
+	err := am.DB.Select("id").Where("slug = ?", KidsCategory).Take(&kids).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return placement[kids.ID], nil
+}
+
+// placementCategories returns the categories of the playlist's channel and of the video, with their ancestors.
+// The category tree is only read when a candidate has category rules or mature content.
+func (am *AdvertisementModel) placementCategories(selection SelectionContext, candidates []Advertisement) (map[uint]bool, error) {
+	needed := false
+	for _, advertisement := range candidates {
+		if len(advertisement.IncludeCategories) > 0 || len(advertisement.ExcludeCategories) > 0 || advertisement.MatureContent {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return map[uint]bool{}, nil
+	}
+
+	var ids []uint
+	for _, category := range selection.Playlist.Channel.Categories {
+		ids = append(ids, category.ID)
+	}
+	if selection.Video != nil && selection.Video.CategoryID != 0 {
+		ids = append(ids, selection.Video.CategoryID)
+	}
+	return withAncestors(am.DB, ids)
+}
+
+// GetEligibleAdvertisements returns the unplayed, unpaused advertisements of a playlist that may play in the given context,
+// ordered by their scheduled time
+func (am *AdvertisementModel) GetEligibleAdvertisements(selection SelectionContext) ([]Advertisement, error) {
+	location := selection.location()
+
+	var candidates []Advertisement
+	if err := am.DB.Preload("Dayparts").Preload("GeoTargets").Preload("IncludeCategories").Preload("ExcludeCategories").
+		Where("playlist_id = ? AND scheduled_at <= ? AND played = ? AND is_paused = ?", selection.Playlist.ID, selection.Time, false, false).
+		Scopes(geoTargetScope(location)).
+		Order("scheduled_at").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	// Dayparts are evaluated in the channel's local time
+	localTime := selection.Time.In(selection.Playlist.Channel.TimeLocation())
+
+	attributes := selection.targetingContext()
+	placement, err := am.placementCategories(selection, candidates)
+	if err != nil {
+		return nil, err
+	}
+	kidsPlacement, err := am.isKidsPlacement(placement)
+	if err != nil {
+		return nil, err
+	}
+
+	eligible := make([]Advertisement, 0, len(candidates))
+	for _, advertisement := range candidates {
+		if selection.Played[advertisement.ID] {
+			continue
+		}
+		// Mature advertisements never play in kids categories, whatever their targeting says
+		if advertisement.MatureContent && kidsPlacement {
+			continue
+		}
+		if !DaypartsAllow(advertisement.Dayparts, localTime) {
+			continue
+		}
+		if !GeoTargetsAllow(advertisement.GeoTargets, location) {
+			continue
+		}
+		if !CategoriesAllow(advertisement.IncludeCategories, advertisement.ExcludeCategories, placement) {
+			continue
+		}
+		if !advertisement.Access.AllowsEmbedding(selection.Origin) {
+			continue
+		}
+		matched, err := targeting.Match(advertisement.Targeting, attributes)
+		if err != nil {
+			log.Printf("Skipping advertisement %d with invalid targeting expression: %v", advertisement.ID, err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+		eligible = append(eligible, advertisement)
+	}
+
+	return eligible, nil
+}
@@ -0,0 +1,186 @@
+// backend/models/advertisement_selection_test.go
+
+package models_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestMatureAdvertisementsSkipKidsSubcategories(t *testing.T) {
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name            string
+		channelCategory bool // Whether the placement category is on the channel rather than the video
+		kidsParent      bool // Whether the placement category sits under the kids category
+		want            bool // Whether the mature advertisement is eligible
+	}{
+		{"channel in kids subcategory", true, true, false},
+		{"video in kids subcategory", false, true, false},
+		{"channel outside kids", true, false, true},
+		{"video outside kids", false, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := newTestDB(t)
+			// The kids category is found by its slug, whatever it is called
+			kids := models.Category{Name: "Children & Family", Slug: models.KidsCategory}
+			other := models.Category{Name: "Music", Slug: "music"}
+			for _, category := range []*models.Category{&kids, &other} {
+				if err := db.Create(category).Error; err != nil {
+					t.Fatalf("creating category: %v", err)
+				}
+			}
+			parent := other.ID
+			if test.kidsParent {
+				parent = kids.ID
+			}
+			subcategory := models.Category{Name: "Cartoons", Slug: "cartoons", ParentID: &parent}
+			if err := db.Create(&subcategory).Error; err != nil {
+				t.Fatalf("creating subcategory: %v", err)
+			}
+
+			channel := models.Channel{Name: test.name}
+			if test.channelCategory {
+				channel.Categories = []models.Category{subcategory}
+			}
+			if err := db.Create(&channel).Error; err != nil {
+				t.Fatalf("creating channel: %v", err)
+			}
+			playlist := models.Playlist{Title: test.name, ChannelID: channel.ID, IsPublic: true, IsPlayable: true}
+			if err := db.Create(&playlist).Error; err != nil {
+				t.Fatalf("creating playlist: %v", err)
+			}
+			video := models.Video{Title: test.name, PlaylistID: playlist.ID}
+			if !test.channelCategory {
+				video.CategoryID = subcategory.ID
+			}
+			if err := db.Create(&video).Error; err != nil {
+				t.Fatalf("creating video: %v", err)
+			}
+			advertisement := models.Advertisement{PlaylistID: playlist.ID, Title: "mature", Duration: 30, IsPublic: true,
+				MatureContent: true, ScheduledAt: now.Add(-time.Hour)}
+			if err := db.Create(&advertisement).Error; err != nil {
+				t.Fatalf("creating advertisement: %v", err)
+			}
+
+			selected, err := models.NewPlaylistModel(db).GetPlaylistForSelection(playlist.ID)
+			if err != nil {
+				t.Fatalf("GetPlaylistForSelection: %v", err)
+			}
+			eligible, err := models.NewAdvertisementModel(db).GetEligibleAdvertisements(models.SelectionContext{
+				Playlist: selected,
+				Video:    &video,
+				Time:     now,
+			})
+			if err != nil {
+				t.Fatalf("GetEligibleAdvertisements: %v", err)
+			}
+			if got := len(eligible) == 1; got != test.want {
+				t.Errorf("mature advertisement eligible = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTargetingMatchesCategorySlugs(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	news := models.Category{Name: "News & Politics", Slug: "news"}
+	if err := db.Create(&news).Error; err != nil {
+		t.Fatalf("creating category: %v", err)
+	}
+	channel := models.Channel{Name: "channel", Categories: []models.Category{news}}
+	if err := db.Create(&channel).Error; err != nil {
+		t.Fatalf("creating channel: %v", err)
+	}
+	playlist := models.Playlist{Title: "playlist", ChannelID: channel.ID, IsPublic: true, IsPlayable: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	bySlug := models.Advertisement{PlaylistID: playlist.ID, Title: "by slug", Duration: 30, IsPublic: true,
+		Targeting: "category = news", ScheduledAt: now.Add(-time.Hour)}
+	byName := models.Advertisement{PlaylistID: playlist.ID, Title: "by name", Duration: 30, IsPublic: true,
+		Targeting: `category = "News & Politics"`, ScheduledAt: now.Add(-time.Hour)}
+	for _, advertisement := range []*models.Advertisement{&bySlug, &byName} {
+		if err := db.Create(advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+	}
+
+	selected, err := models.NewPlaylistModel(db).GetPlaylistForSelection(playlist.ID)
+	if err != nil {
+		t.Fatalf("GetPlaylistForSelection: %v", err)
+	}
+	eligible, err := models.NewAdvertisementModel(db).GetEligibleAdvertisements(models.SelectionContext{Playlist: selected, Time: now})
+	if err != nil {
+		t.Fatalf("GetEligibleAdvertisements: %v", err)
+	}
+	if len(eligible) != 1 || eligible[0].ID != bySlug.ID {
+		t.Errorf("got %d eligible advertisements, want only the one targeting the category slug", len(eligible))
+	}
+}
+
+func TestGeoTargetedSelection(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	paris := models.Location{Latitude: 48.8566, Longitude: 2.3522, City: "Paris", Country: "FR"}
+	lyon := models.Location{Latitude: 45.7640, Longitude: 4.8357, City: "Lyon", Country: "FR"}
+	playlist := models.Playlist{Title: "playlist", IsPublic: true, IsPlayable: true, Location: paris}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	targets := []struct {
+		title string
+		rules []models.GeoTargetRule
+	}{
+		{"everywhere", nil},
+		{"france", []models.GeoTargetRule{{Country: "FR"}}},
+		{"france but paris", []models.GeoTargetRule{{Country: "FR"}, {City: "Paris", Exclude: true}}},
+		{"near paris", []models.GeoTargetRule{{Latitude: paris.Latitude, Longitude: paris.Longitude, RadiusKm: 25}}},
+		{"near paris outside france", []models.GeoTargetRule{{Latitude: paris.Latitude, Longitude: paris.Longitude, RadiusKm: 600}, {Country: "FR", Exclude: true}}},
+		{"germany", []models.GeoTargetRule{{Country: "DE"}}},
+	}
+	for _, target := range targets {
+		advertisement := models.Advertisement{PlaylistID: playlist.ID, Title: target.title, Duration: 30, IsPublic: true,
+			GeoTargets: target.rules, ScheduledAt: now.Add(-time.Hour)}
+		if err := db.Create(&advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+	}
+
+	selected, err := models.NewPlaylistModel(db).GetPlaylistForSelection(playlist.ID)
+	if err != nil {
+		t.Fatalf("GetPlaylistForSelection: %v", err)
+	}
+	brussels := models.Location{Latitude: 50.8503, Longitude: 4.3517, City: "Brussels", Country: "BE"}
+	tests := []struct {
+		name     string
+		location *models.Location // Device location, nil for the playlist's
+		want     string
+	}{
+		{"playlist in Paris", nil, "everywhere, france, near paris"},
+		{"device in Lyon", &lyon, "everywhere, france, france but paris"},
+		{"device in Brussels", &brussels, "everywhere, near paris outside france"},
+	}
+	for _, test := range tests {
+		eligible, err := models.NewAdvertisementModel(db).GetEligibleAdvertisements(models.SelectionContext{
+			Playlist: selected,
+			Location: test.location,
+			Time:     now,
+		})
+		if err != nil {
+			t.Fatalf("%s: GetEligibleAdvertisements: %v", test.name, err)
+		}
+		titles := make([]string, 0, len(eligible))
+		for _, advertisement := range eligible {
+			titles = append(titles, advertisement.Title)
+		}
+		if got := strings.Join(titles, ", "); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// backend/models/advertisement_test.go
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestUpdateAdvertisementReplacesRules(t *testing.T) {
+	db := newTestDB(t)
+	advertisement := models.Advertisement{
+		Title:    "advertisement",
+		Duration: 30,
+		Dayparts: []models.DaypartRule{
+			{DayOfWeek: time.Monday, StartTime: "06:00", EndTime: "10:00"},
+			{DayOfWeek: time.Friday, StartTime: "18:00", EndTime: "24:00"},
+		},
+		GeoTargets: []models.GeoTargetRule{{Country: "FR"}, {Country: "DE"}},
+	}
+	advertisementModel := models.NewAdvertisementModel(db)
+	if err := advertisementModel.CreateAdvertisement(&advertisement); err != nil {
+		t.Fatalf("CreateAdvertisement: %v", err)
+	}
+
+	stored, err := advertisementModel.GetAdvertisementByID(advertisement.ID)
+	if err != nil {
+		t.Fatalf("GetAdvertisementByID: %v", err)
+	}
+	stored.Dayparts = stored.Dayparts[:1]
+	stored.Dayparts[0].EndTime = "12:00"
+	stored.GeoTargets = []models.GeoTargetRule{{Country: "ES"}}
+	if err := advertisementModel.UpdateAdvertisement(stored); err != nil {
+		t.Fatalf("UpdateAdvertisement: %v", err)
+	}
+
+	updated, err := advertisementModel.GetAdvertisementByID(advertisement.ID)
+	if err != nil {
+		t.Fatalf("GetAdvertisementByID: %v", err)
+	}
+	if len(updated.Dayparts) != 1 || updated.Dayparts[0].DayOfWeek != time.Monday || updated.Dayparts[0].EndTime != "12:00" {
+		t.Errorf("dayparts are %+v, want only Monday 06:00-12:00", updated.Dayparts)
+	}
+	if len(updated.GeoTargets) != 1 || updated.GeoTargets[0].Country != "ES" {
+		t.Errorf("geo targets are %+v, want only ES", updated.GeoTargets)
+	}
+
+	var dayparts, geoTargets int64
+	if err := db.Unscoped().Model(&models.DaypartRule{}).Count(&dayparts).Error; err != nil {
+		t.Fatalf("counting dayparts: %v", err)
+	}
+	if err := db.Unscoped().Model(&models.GeoTargetRule{}).Count(&geoTargets).Error; err != nil {
+		t.Fatalf("counting geo targets: %v", err)
+	}
+	if dayparts != 1 || geoTargets != 1 {
+		t.Errorf("%d daypart and %d geo target rows left, want 1 and 1", dayparts, geoTargets)
+	}
+
+	// Clearing the rules removes them all
+	updated.Dayparts, updated.GeoTargets = nil, nil
+	if err := advertisementModel.UpdateAdvertisement(updated); err != nil {
+		t.Fatalf("UpdateAdvertisement: %v", err)
+	}
+	cleared, err := advertisementModel.GetAdvertisementByID(advertisement.ID)
+	if err != nil {
+		t.Fatalf("GetAdvertisementByID: %v", err)
+	}
+	if len(cleared.Dayparts) != 0 || len(cleared.GeoTargets) != 0 {
+		t.Errorf("clearing left %d dayparts and %d geo targets", len(cleared.Dayparts), len(cleared.GeoTargets))
+	}
+}
@@ -0,0 +1,436 @@
+// backend/models/category.go
+
+package models
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvalidCategory is returned when a category has no name or an invalid slug
+	ErrInvalidCategory = errors.New("category needs a name and a slug of lowercase letters, digits and dashes with at least one letter")
+	// ErrCategorySlugTaken is returned when another category already uses a slug
+	ErrCategorySlugTaken = errors.New("category slug is already taken")
+	// ErrCategoryCycle is returned when a category would become its own ancestor
+	ErrCategoryCycle = errors.New("category cannot be its own ancestor")
+	// ErrCategoryHasChildren is returned when deleting a category that still has subcategories
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+)
+
+// slugPattern matches valid category slugs. Slugs also need a letter, so they are never mistaken for IDs.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// Category is a node of the category taxonomy. Videos and advertisements have one category,
+// channels any number, and a category covers the content of its subcategories.
+type Category struct {
+	gorm.Model
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug" gorm:"size:100;uniqueIndex"`
+	Description string     `json:"description"`
+	ParentID    *uint      `json:"parentId" gorm:"index"` // nil for top-level categories
+	Children    []Category `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+	Channels    []Channel  `json:"channels,omitempty" gorm:"many2many:channel_categories;"`
+}
+
+// CategoryModel handles database operations for Category
+type CategoryModel struct {
+	DB *gorm.DB
+}
+
+// NewCategoryModel creates a new instance of CategoryModel
+func NewCategoryModel(db *gorm.DB) *CategoryModel {
+	return &CategoryModel{
+		DB: db,
+	}
+}
+
+// Slugify derives a slug from a category name, e.g. "Rock & Roll" becomes "rock-roll"
+func Slugify(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// GetCategoryTree fetches every category, nested under their parents, each level sorted by name
+func (cm *CategoryModel) GetCategoryTree() ([]Category, error) {
+	var categories []Category
+	if err := cm.DB.Order("name, id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	children := map[uint][]Category{}
+	var roots []Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var attach func(nodes []Category) []Category
+	attach = func(nodes []Category) []Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+	tree := attach(roots)
+	if tree == nil {
+		tree = []Category{}
+	}
+	return tree, nil
+}
+
+// GetCategory fetches a category by ID or slug, along with its direct subcategories
+func (cm *CategoryModel) GetCategory(ref string) (*Category, error) {
+	query := cm.DB.Preload("Children", func(db *gorm.DB) *gorm.DB { return db.Order("name, id") })
+	var category Category
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("slug = ?", ref)
+	}
+	if err := query.First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// CreateCategory validates and creates a category, deriving its slug from its name when missing
+func (cm *CategoryModel) CreateCategory(category *Category) error {
+	return cm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateCategory(tx, category); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(category).Error
+	})
+}
+
+// UpdateCategory validates and saves the name, slug, description and parent of a category
+func (cm *CategoryModel) UpdateCategory(category *Category) error {
+	return cm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&Category{}, category.ID).Error; err != nil {
+			return err
+		}
+		if err := validateCategory(tx, category); err != nil {
+			return err
+		}
+		return tx.Model(category).Select("name", "slug", "description", "parent_id").Updates(category).Error
+	})
+}
+
+// validateCategory checks the name, slug and parent of a category
+func validateCategory(tx *gorm.DB, category *Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Slug == "" {
+		category.Slug = Slugify(category.Name)
+	}
+	if category.Name == "" || !slugPattern.MatchString(category.Slug) || strings.IndexFunc(category.Slug, unicode.IsLetter) < 0 {
+		return ErrInvalidCategory
+	}
+
+	var taken int64
+	if err := tx.Model(&Category{}).Where("slug = ? AND id <> ?", category.Slug, category.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrCategorySlugTaken
+	}
+
+	if category.ParentID == nil {
+		return nil
+	}
+	parents, err := categoryParents(tx)
+	if err != nil {
+		return err
+	}
+	if _, ok := parents[*category.ParentID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	// Walk up from the new parent; reaching the category itself means a cycle
+	for id, steps := category.ParentID, 0; id != nil && steps <= len(parents); id, steps = parents[*id], steps+1 {
+		if category.ID != 0 && *id == category.ID {
+			return ErrCategoryCycle
+		}
+	}
+	return nil
+}
+
+// DeleteCategory deletes a category without subcategories. Videos and advertisements in it become
+// uncategorized, and it is removed from channels and advertisement placement rules.
+func (cm *CategoryModel) DeleteCategory(categoryID uint) error {
+	return cm.DB.Transaction(func(tx *gorm.DB) error {
+		var category Category
+		if err := tx.First(&category, categoryID).Error; err != nil {
+			return err
+		}
+		var children int64
+		if err := tx.Model(&Category{}).Where("parent_id = ?", categoryID).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return ErrCategoryHasChildren
+		}
+
+		if err := tx.Model(&Video{}).Where("category_id = ?", categoryID).UpdateColumn("category_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Advertisement{}).Where("category_id = ?", categoryID).UpdateColumn("category_id", 0).Error; err != nil {
+			return err
+		}
+		for _, table := range []string{"channel_categories", "advertisement_include_categories", "advertisement_exclude_categories"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE category_id = ?", categoryID).Error; err != nil {
+				return err
+			}
+		}
+		// Deleted for good, so the slug can be used again
+		return tx.Unscoped().Delete(&category).Error
+	})
+}
+
+// SetChannelCategories replaces the categories of a channel
+func (cm *CategoryModel) SetChannelCategories(channelID uint, categoryIDs []uint) (*Channel, error) {
+	var channel Channel
+	err := cm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&channel, channelID).Error; err != nil {
+			return err
+		}
+		categories, err := findCategories(tx, categoryIDs)
+		if err != nil {
+			return err
+		}
+		channel.Categories = categories
+		return tx.Model(&channel).Association("Categories").Replace(categories)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// findCategories fetches categories by ID, failing with gorm.ErrRecordNotFound if any is missing
+func findCategories(tx *gorm.DB, ids []uint) ([]Category, error) {
+	categories := []Category{}
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	if err := tx.Where("id IN ?", ids).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	distinct := map[uint]bool{}
+	for _, id := range ids {
+		distinct[id] = true
+	}
+	if len(categories) != len(distinct) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return categories, nil
+}
+
+// categoryParents maps every category ID to its parent ID
+func categoryParents(tx *gorm.DB) (map[uint]*uint, error) {
+	var categories []Category
+	if err := tx.Select("id", "parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	parents := make(map[uint]*uint, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+	return parents, nil
+}
+
+// CategorySubtree returns the ID of a category and of all its descendants
+func (cm *CategoryModel) CategorySubtree(categoryID uint) ([]uint, error) {
+	parents, err := categoryParents(cm.DB)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := parents[categoryID]; !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	children := map[uint][]uint{}
+	for id, parent := range parents {
+		if parent != nil {
+			children[*parent] = append(children[*parent], id)
+		}
+	}
+	subtree := []uint{categoryID}
+	for i := 0; i < len(subtree); i++ {
+		subtree = append(subtree, children[subtree[i]]...)
+	}
+	sort.Slice(subtree, func(i, j int) bool { return subtree[i] < subtree[j] })
+	return subtree, nil
+}
+
+// withAncestors returns the given category IDs along with all their ancestors
+func withAncestors(tx *gorm.DB, ids []uint) (map[uint]bool, error) {
+	expanded := map[uint]bool{}
+	if len(ids) == 0 {
+		return expanded, nil
+	}
+	parents, err := categoryParents(tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		for current := &id; current != nil && !expanded[*current]; current = parents[*current] {
+			expanded[*current] = true
+		}
+	}
+	return expanded, nil
+}
+
+// VideosInCategories restricts a video query to the given categories
+func VideosInCategories(categoryIDs []uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("videos.category_id IN ?", categoryIDs)
+	}
+}
+
+// PlaylistsInCategories restricts a playlist query to playlists with a video in the given categories
+// or belonging to a channel in them
+func PlaylistsInCategories(categoryIDs []uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("EXISTS (SELECT 1 FROM videos WHERE videos.playlist_id = playlists.id AND videos.deleted_at IS NULL AND videos.category_id IN ?)"+
+			" OR playlists.channel_id IN (SELECT channel_id FROM channel_categories WHERE category_id IN ?)", categoryIDs, categoryIDs)
+	}
+}
+
+// AdvertisementsInCategories restricts an advertisement query to the given categories
+func AdvertisementsInCategories(categoryIDs []uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("advertisements.category_id IN ?", categoryIDs)
+	}
+}
+
+// CategoriesAllow reports whether an advertisement with the given include and exclude rules may play in a
+// placement. The placement categories must include their ancestors, so including a category allows its
+// subcategories and excluding one excludes them.
+func CategoriesAllow(include, exclude []Category, placement map[uint]bool) bool {
+	for _, category := range exclude {
+		if placement[category.ID] {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, category := range include {
+		if placement[category.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCategoryVideos fetches a page of the videos a viewer can read in a category or its subcategories, newest first
+func (cm *CategoryModel) GetCategoryVideos(categoryID uint, viewer Viewer, offset, limit int) ([]Video, int64, error) {
+	subtree, err := cm.CategorySubtree(categoryID)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := cm.DB.Model(&Video{}).Scopes(VideosInCategories(subtree), VisibleVideos(viewer))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	videos := []Video{}
+	if err := query.Preload("Category").Order("videos.id DESC").Offset(offset).Limit(limit).Find(&videos).Error; err != nil {
+		return nil, 0, err
+	}
+	return videos, total, nil
+}
+
+// GetCategoryPlaylists fetches a page of the playlists a viewer can read in a category or its subcategories, newest first
+func (cm *CategoryModel) GetCategoryPlaylists(categoryID uint, viewer Viewer, offset, limit int) ([]Playlist, int64, error) {
+	subtree, err := cm.CategorySubtree(categoryID)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := cm.DB.Model(&Playlist{}).Scopes(PlaylistsInCategories(subtree), VisiblePlaylists(viewer))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	playlists := []Playlist{}
+	if err := query.Order("playlists.id DESC").Offset(offset).Limit(limit).Find(&playlists).Error; err != nil {
+		return nil, 0, err
+	}
+	return playlists, total, nil
+}
+
+// GetCategoryAdvertisements fetches a page of the advertisements a viewer can read in a category or its subcategories, newest first
+func (cm *CategoryModel) GetCategoryAdvertisements(categoryID uint, viewer Viewer, offset, limit int) ([]Advertisement, int64, error) {
+	subtree, err := cm.CategorySubtree(categoryID)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := cm.DB.Model(&Advertisement{}).Scopes(AdvertisementsInCategories(subtree), VisibleAdvertisements(viewer))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	advertisements := []Advertisement{}
+	if err := query.Preload("Category").Preload("IncludeCategories").Preload("ExcludeCategories").
+		Order("advertisements.id DESC").Offset(offset).Limit(limit).Find(&advertisements).Error; err != nil {
+		return nil, 0, err
+	}
+	return advertisements, total, nil
+}
+
+// SetAdvertisementCategories sets the category of an advertisement and the placement categories
+// it is limited to and kept out of
+func (cm *CategoryModel) SetAdvertisementCategories(advertisementID, categoryID uint, include, exclude []uint) (*Advertisement, error) {
+	var advertisement Advertisement
+	err := cm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&advertisement, advertisementID).Error; err != nil {
+			return err
+		}
+		if categoryID != 0 {
+			if _, err := findCategories(tx, []uint{categoryID}); err != nil {
+				return err
+			}
+		}
+		included, err := findCategories(tx, include)
+		if err != nil {
+			return err
+		}
+		excluded, err := findCategories(tx, exclude)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&advertisement).UpdateColumn("category_id", categoryID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&advertisement).Association("IncludeCategories").Replace(included); err != nil {
+			return err
+		}
+		if err := tx.Model(&advertisement).Association("ExcludeCategories").Replace(excluded); err != nil {
+			return err
+		}
+		advertisement.CategoryID = categoryID
+		advertisement.IncludeCategories = included
+		advertisement.ExcludeCategories = excluded
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &advertisement, nil
+}
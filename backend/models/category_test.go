@@ -0,0 +1,228 @@
+// backend/models/category_test.go
+
+package models_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// categoryNames flattens a category tree into "parent/child" paths, depth first
+func categoryNames(prefix string, categories []models.Category) []string {
+	names := []string{}
+	for _, category := range categories {
+		path := prefix + category.Slug
+		names = append(names, path)
+		names = append(names, categoryNames(path+"/", category.Children)...)
+	}
+	return names
+}
+
+func TestCategoryTree(t *testing.T) {
+	db := newTestDB(t)
+	categoryModel := models.NewCategoryModel(db)
+	create := func(name string, parent *models.Category) *models.Category {
+		t.Helper()
+		category := &models.Category{Name: name}
+		if parent != nil {
+			category.ParentID = &parent.ID
+		}
+		if err := categoryModel.CreateCategory(category); err != nil {
+			t.Fatalf("CreateCategory(%q): %v", name, err)
+		}
+		return category
+	}
+	news := create("News", nil)
+	music := create("Music", nil)
+	rock := create("Rock", music)
+	rockAndRoll := create("  Rock & Roll ", rock)
+	create("Jazz", music)
+
+	if rockAndRoll.Name != "Rock & Roll" || rockAndRoll.Slug != "rock-roll" {
+		t.Errorf("created %q with slug %q, want the trimmed name and slug rock-roll", rockAndRoll.Name, rockAndRoll.Slug)
+	}
+	tree, err := categoryModel.GetCategoryTree()
+	if err != nil {
+		t.Fatalf("GetCategoryTree: %v", err)
+	}
+	want := []string{"music", "music/jazz", "music/rock", "music/rock/rock-roll", "news"}
+	if got := categoryNames("", tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("tree is %q, want %q", got, want)
+	}
+	subtree, err := categoryModel.CategorySubtree(music.ID)
+	if err != nil {
+		t.Fatalf("CategorySubtree: %v", err)
+	}
+	if len(subtree) != 4 || subtree[0] != music.ID {
+		t.Errorf("music subtree is %v, want music and its 3 descendants", subtree)
+	}
+	for _, ref := range []string{"rock", strconv.FormatUint(uint64(rock.ID), 10)} {
+		category, err := categoryModel.GetCategory(ref)
+		if err != nil || category.ID != rock.ID || len(category.Children) != 1 {
+			t.Errorf("GetCategory(%q) got %+v, %v, want rock with its child", ref, category, err)
+		}
+	}
+
+	missing := uint(999)
+	invalid := []struct {
+		name     string
+		category models.Category
+		want     error
+	}{
+		{"blank name", models.Category{Name: "  "}, models.ErrInvalidCategory},
+		{"numeric slug", models.Category{Name: "2026", Slug: "2026"}, models.ErrInvalidCategory},
+		{"uppercase slug", models.Category{Name: "Pop", Slug: "Pop"}, models.ErrInvalidCategory},
+		{"taken slug", models.Category{Name: "Rock music", Slug: "rock"}, models.ErrCategorySlugTaken},
+		{"missing parent", models.Category{Name: "Orphan", ParentID: &missing}, gorm.ErrRecordNotFound},
+	}
+	for _, test := range invalid {
+		if err := categoryModel.CreateCategory(&test.category); !errors.Is(err, test.want) {
+			t.Errorf("creating with %s got %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	// Moving a category under itself or its descendants would make a cycle
+	for _, parent := range []*models.Category{music, rockAndRoll} {
+		moved := *music
+		moved.ParentID = &parent.ID
+		if err := categoryModel.UpdateCategory(&moved); !errors.Is(err, models.ErrCategoryCycle) {
+			t.Errorf("moving music under %s got %v, want ErrCategoryCycle", parent.Slug, err)
+		}
+	}
+	moved := *rock
+	moved.ParentID = &news.ID
+	if err := categoryModel.UpdateCategory(&moved); err != nil {
+		t.Fatalf("moving rock under news: %v", err)
+	}
+	moved.ParentID = &music.ID
+	if err := categoryModel.UpdateCategory(&moved); err != nil {
+		t.Fatalf("moving rock back under music: %v", err)
+	}
+
+	if err := categoryModel.DeleteCategory(rock.ID); !errors.Is(err, models.ErrCategoryHasChildren) {
+		t.Errorf("deleting rock with a subcategory got %v, want ErrCategoryHasChildren", err)
+	}
+	video := models.Video{Title: "video", CategoryID: rockAndRoll.ID}
+	if err := db.Create(&video).Error; err != nil {
+		t.Fatalf("creating video: %v", err)
+	}
+	if err := categoryModel.DeleteCategory(rockAndRoll.ID); err != nil {
+		t.Fatalf("DeleteCategory: %v", err)
+	}
+	if err := db.First(&video, video.ID).Error; err != nil || video.CategoryID != 0 {
+		t.Errorf("video of the deleted category has category %d, %v, want none", video.CategoryID, err)
+	}
+	// The slug of a deleted category is free again
+	create("Rock and roll", rock)
+}
+
+func TestCategoryPlacement(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	categoryModel := models.NewCategoryModel(db)
+	categories := map[string]*models.Category{}
+	for _, category := range []struct{ name, parent string }{
+		{"Music", ""}, {"Rock", "Music"}, {"Punk", "Rock"}, {"News", ""},
+	} {
+		created := &models.Category{Name: category.name}
+		if category.parent != "" {
+			created.ParentID = &categories[category.parent].ID
+		}
+		if err := categoryModel.CreateCategory(created); err != nil {
+			t.Fatalf("CreateCategory: %v", err)
+		}
+		categories[category.name] = created
+	}
+	ids := func(names ...string) []uint {
+		list := []uint{}
+		for _, name := range names {
+			list = append(list, categories[name].ID)
+		}
+		return list
+	}
+
+	channel := models.Channel{Name: "channel"}
+	if err := db.Create(&channel).Error; err != nil {
+		t.Fatalf("creating channel: %v", err)
+	}
+	if _, err := categoryModel.SetChannelCategories(channel.ID, ids("Rock")); err != nil {
+		t.Fatalf("SetChannelCategories: %v", err)
+	}
+	playlist := models.Playlist{Title: "playlist", ChannelID: channel.ID, IsPublic: true, IsPlayable: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	punkVideo := models.Video{Title: "punk", PlaylistID: playlist.ID, CategoryID: categories["Punk"].ID}
+	if err := db.Create(&punkVideo).Error; err != nil {
+		t.Fatalf("creating video: %v", err)
+	}
+
+	rules := []struct {
+		title            string
+		include, exclude []string
+		onChannel        bool // Eligible on the channel alone, whose category is Rock
+		onPunkVideo      bool // Eligible on a Punk video of the channel
+	}{
+		{"anywhere", nil, nil, true, true},
+		{"in music", []string{"Music"}, nil, true, true},
+		{"in rock", []string{"Rock"}, nil, true, true},
+		{"in punk", []string{"Punk"}, nil, false, true},
+		{"in news", []string{"News"}, nil, false, false},
+		{"in news or punk", []string{"News", "Punk"}, nil, false, true},
+		{"not in music", nil, []string{"Music"}, false, false},
+		{"not in punk", nil, []string{"Punk"}, true, false},
+		{"in rock but not punk", []string{"Rock"}, []string{"Punk"}, true, false},
+		{"in and out of rock", []string{"Rock"}, []string{"Rock"}, false, false},
+	}
+	for _, rule := range rules {
+		advertisement := models.Advertisement{PlaylistID: playlist.ID, Title: rule.title, Duration: 30, IsPublic: true, ScheduledAt: now.Add(-time.Hour)}
+		if err := db.Create(&advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+		if _, err := categoryModel.SetAdvertisementCategories(advertisement.ID, 0, ids(rule.include...), ids(rule.exclude...)); err != nil {
+			t.Fatalf("SetAdvertisementCategories: %v", err)
+		}
+	}
+
+	selected, err := models.NewPlaylistModel(db).GetPlaylistForSelection(playlist.ID)
+	if err != nil {
+		t.Fatalf("GetPlaylistForSelection: %v", err)
+	}
+	for _, placement := range []struct {
+		name  string
+		video *models.Video
+	}{{"channel", nil}, {"punk video", &punkVideo}} {
+		eligible, err := models.NewAdvertisementModel(db).GetEligibleAdvertisements(models.SelectionContext{
+			Playlist: selected,
+			Video:    placement.video,
+			Time:     now,
+		})
+		if err != nil {
+			t.Fatalf("GetEligibleAdvertisements: %v", err)
+		}
+		got := map[string]bool{}
+		for _, advertisement := range eligible {
+			got[advertisement.Title] = true
+		}
+		for _, rule := range rules {
+			want := rule.onChannel
+			if placement.video != nil {
+				want = rule.onPunkVideo
+			}
+			if got[rule.title] != want {
+				t.Errorf("%s: advertisement %q eligible = %v, want %v", placement.name, rule.title, got[rule.title], want)
+			}
+		}
+	}
+
+	if _, err := categoryModel.SetAdvertisementCategories(1, 0, []uint{999}, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("including a missing category got %v, want gorm.ErrRecordNotFound", err)
+	}
+}
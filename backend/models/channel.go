@@ -20,7 +20,7 @@ type Channel struct {
 	Subscribers        []User             `gorm:"many2many:user_subscriptions;"`
 	Playlists          []Playlist         `json:"playlists"`
 	FeaturedVideos     []Video            `json:"featuredVideos" gorm:"many2many:channel_featured_videos;"`
-	Uploads            []Video            `json:"uploads"`
+	Uploads            []Video            `json:"uploads" gorm:"foreignKey:ChannelID"`
 	Categories         []Category         `json:"categories" gorm:"many2many:channel_categories;"`
 	SocialMediaLinks   SocialMediaLinks   `json:"socialMediaLinks" gorm:"embedded"`
 	ContactInformation ContactInformation `json:"contactInformation" gorm:"embedded"`
@@ -32,6 +32,14 @@ type Channel struct {
 	JoinDate           time.Time          `json:"joinDate"`
 	LastUploadDate     time.Time          `json:"lastUploadDate"`
 	MonetarySupportURL string             `json:"monetarySupportURL"`
+	Timezone           string             `json:"timezone"`        // IANA name used for dayparting, e.g. "Europe/London"
+	PlaylistFilters    string             `json:"playlistFilters"` // Comma-separated filters of playlists eligible for ads, see ParsePlaylistSelector
+	PlaylistRanking    string             `json:"playlistRanking"` // Comma-separated rankers ordering eligible playlists
+}
+
+// TimeLocation returns the channel's timezone, defaulting to UTC
+func (c Channel) TimeLocation() *time.Location {
+	return loadTimezone(c.Timezone)
 }
 
 // SocialMediaLinks model
@@ -50,11 +58,3 @@ type ContactInformation struct {
 	Address string `json:"address"`
 	// Add more contact information fields as needed
 }
-
-// Category model
-type Category struct {
-	gorm.Model
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Channels    []Channel `gorm:"many2many:channel_categories;"`
-}
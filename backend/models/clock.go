@@ -0,0 +1,26 @@
+// backend/models/clock.go
+
+package models
+
+import "time"
+
+// Clock abstracts the current time so scheduling decisions can be driven by a fake clock
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock backed by the wall clock
+type SystemClock struct{}
+
+// Now returns the current wall clock time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ClockFunc adapts an ordinary function to the Clock interface
+type ClockFunc func() time.Time
+
+// Now calls f()
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
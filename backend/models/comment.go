@@ -2,15 +2,25 @@
 
 package models
 
-import "gorm.io/gorm"
+import (
+	"errors"
+	"fmt"
+	"time"
 
-// Comment model
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Comment model. A comment belongs to exactly one video, playlist or advertisement,
+// referenced by the one non-null column of VideoID, PlaylistID and AdvertisementID.
 type Comment struct {
 	gorm.Model
 	UserID           uint              `json:"-"`
 	User             User              `json:"user"`
-	VideoID          uint              `json:"-"`
+	VideoID          *uint             `json:"videoId,omitempty" gorm:"index"`
 	Video            Video             `json:"video"`
+	PlaylistID       *uint             `json:"playlistId,omitempty" gorm:"index"`
+	AdvertisementID  *uint             `json:"advertisementId,omitempty" gorm:"index"`
 	Text             string            `json:"text"`
 	Likes            uint              `json:"likes" gorm:"default:0"`
 	Dislikes         uint              `json:"dislikes" gorm:"default:0"`
@@ -18,6 +28,7 @@ type Comment struct {
 	ParentCommentID  uint              `json:"-"`
 	IsReported       bool              `json:"isReported" gorm:"default:false"`
 	ReportsCount     uint              `json:"reportsCount" gorm:"default:0"`
+	IsHidden         bool              `json:"isHidden" gorm:"default:false"` // Hidden after ReportHideThreshold reports, until moderated
 	IsEdited         bool              `json:"isEdited" gorm:"default:false"`
 	EditedTimestamp  int               `json:"editedTimestamp"`
 	IsDeleted        bool              `json:"isDeleted" gorm:"default:false"`
@@ -50,7 +61,7 @@ type MediaAttachment struct {
 // Hashtag model for representing hashtags in comments
 type Hashtag struct {
 	gorm.Model
-	Name     string    `json:"name"`
+	Name     string    `json:"name" gorm:"size:100;uniqueIndex"` // Lowercase, without the leading #
 	Comments []Comment `gorm:"many2many:comment_hashtags;"`
 	// Add more hashtag-related fields as needed
 }
@@ -78,3 +89,316 @@ type Emoji struct {
 	Comments []Comment `gorm:"many2many:comment_emojis;"`
 	// Add more emoji-related fields as needed
 }
+
+// Types of content users can comment on
+const (
+	CommentableVideo         = ContentVideo
+	CommentablePlaylist      = ContentPlaylist
+	CommentableAdvertisement = ContentAdvertisement
+)
+
+// ReportHideThreshold is the number of reports at which a comment is hidden until a moderator reviews it
+const ReportHideThreshold = 3
+
+var (
+	// ErrCommentsDisabled is returned when comments are turned off for the content
+	ErrCommentsDisabled = errors.New("comments are disabled")
+	// ErrInvalidReply is returned when replying to a comment of other content, or one that is not visible
+	ErrInvalidReply = errors.New("invalid parent comment")
+	// ErrNotCommentAuthor is returned when a user changes someone else's comment
+	ErrNotCommentAuthor = errors.New("not the author of the comment")
+	// ErrCommentOwner is returned when saving a comment that does not belong to exactly one video, playlist or advertisement
+	ErrCommentOwner = errors.New("a comment must belong to exactly one video, playlist or advertisement")
+)
+
+// Commentable identifies the video, playlist or advertisement a comment belongs to
+type Commentable struct {
+	Type string
+	ID   uint
+}
+
+// column returns the comments column referencing the content
+func (ct Commentable) column() (string, error) {
+	switch ct.Type {
+	case CommentableVideo:
+		return "video_id", nil
+	case CommentablePlaylist:
+		return "playlist_id", nil
+	case CommentableAdvertisement:
+		return "advertisement_id", nil
+	}
+	return "", fmt.Errorf("unknown commentable type %q", ct.Type)
+}
+
+// assign points a comment at the content, replacing any previous owner
+func (ct Commentable) assign(comment *Comment) {
+	id := ct.ID
+	comment.VideoID, comment.PlaylistID, comment.AdvertisementID = nil, nil, nil
+	switch ct.Type {
+	case CommentableVideo:
+		comment.VideoID = &id
+	case CommentablePlaylist:
+		comment.PlaylistID = &id
+	case CommentableAdvertisement:
+		comment.AdvertisementID = &id
+	}
+}
+
+// owns reports whether a comment belongs to the content
+func (ct Commentable) owns(comment Comment) bool {
+	owner, ok := comment.Commentable()
+	return ok && owner == ct
+}
+
+// Commentable returns the content the comment belongs to, and false unless exactly one owner is set
+func (c Comment) Commentable() (Commentable, bool) {
+	var owner Commentable
+	owners := 0
+	if c.VideoID != nil {
+		owner = Commentable{Type: CommentableVideo, ID: *c.VideoID}
+		owners++
+	}
+	if c.PlaylistID != nil {
+		owner = Commentable{Type: CommentablePlaylist, ID: *c.PlaylistID}
+		owners++
+	}
+	if c.AdvertisementID != nil {
+		owner = Commentable{Type: CommentableAdvertisement, ID: *c.AdvertisementID}
+		owners++
+	}
+	return owner, owners == 1
+}
+
+// BeforeCreate checks that the comment belongs to exactly one video, playlist or advertisement.
+// Owners never change after creation, so updates are not checked.
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	if _, ok := c.Commentable(); !ok {
+		return ErrCommentOwner
+	}
+	return nil
+}
+
+// CommentReport records one user's report of a comment
+type CommentReport struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"commentId" gorm:"uniqueIndex:idx_comment_reports_comment_user"`
+	UserID    uint      `json:"userId" gorm:"uniqueIndex:idx_comment_reports_comment_user"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CommentThread is a comment along with the number of visible replies to it
+type CommentThread struct {
+	Comment
+	ReplyCount int64 `json:"replyCount"`
+}
+
+// CommentModel handles database operations for Comment
+type CommentModel struct {
+	DB    *gorm.DB
+	Clock Clock
+}
+
+// NewCommentModel creates a new instance of CommentModel
+func NewCommentModel(db *gorm.DB) *CommentModel {
+	return &CommentModel{
+		DB:    db,
+		Clock: SystemClock{},
+	}
+}
+
+// WithComments preloads the visible top-level comments of videos, playlists or advertisements, newest first
+func WithComments(db *gorm.DB) *gorm.DB {
+	return db.Preload("Comments", func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(visibleComments).Where("comments.parent_comment_id = 0").
+			Order("comments.created_at DESC, comments.id DESC")
+	})
+}
+
+// visibleComments restricts a query to comments that are neither hidden nor deleted
+func visibleComments(db *gorm.DB) *gorm.DB {
+	return db.Where("comments.is_hidden = ? AND comments.is_deleted = ?", false, false)
+}
+
+// CommentsVisibleTo restricts a query to the comments on content a viewer can read
+func CommentsVisibleTo(viewer Viewer) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		videos := db.Session(&gorm.Session{NewDB: true}).Model(&Video{}).Select("videos.id").Scopes(VisibleVideos(viewer))
+		playlists := db.Session(&gorm.Session{NewDB: true}).Model(&Playlist{}).Select("playlists.id").Scopes(VisiblePlaylists(viewer))
+		advertisements := db.Session(&gorm.Session{NewDB: true}).Model(&Advertisement{}).Select("advertisements.id").
+			Scopes(VisibleAdvertisements(viewer))
+		return db.Where("(comments.video_id IN (?) OR comments.playlist_id IN (?) OR comments.advertisement_id IN (?))",
+			videos, playlists, advertisements)
+	}
+}
+
+// withReplyCount selects comments along with their number of visible replies
+func withReplyCount(db *gorm.DB) *gorm.DB {
+	return db.Select("comments.*, (SELECT COUNT(*) FROM comments AS replies WHERE replies.parent_comment_id = comments.id " +
+		"AND replies.is_hidden = false AND replies.is_deleted = false AND replies.deleted_at IS NULL) AS reply_count")
+}
+
+// CheckCommentsAllowed returns gorm.ErrRecordNotFound if the content does not exist
+// and ErrCommentsDisabled if its privacy settings turn comments off
+func (cm *CommentModel) CheckCommentsAllowed(parent Commentable) error {
+	allowed := false
+	switch parent.Type {
+	case CommentableVideo:
+		var video Video
+		if err := cm.DB.Select("id", "comments_enabled", "allow_comments").First(&video, parent.ID).Error; err != nil {
+			return err
+		}
+		allowed = video.CommentsEnabled && video.PrivacySetting.AllowComments
+	case CommentablePlaylist:
+		var playlist Playlist
+		if err := cm.DB.Select("id", "allow_comments").First(&playlist, parent.ID).Error; err != nil {
+			return err
+		}
+		allowed = playlist.PrivacySetting.AllowComments
+	case CommentableAdvertisement:
+		var advertisement Advertisement
+		if err := cm.DB.Select("id", "allow_comments").First(&advertisement, parent.ID).Error; err != nil {
+			return err
+		}
+		allowed = advertisement.PrivacySetting.AllowComments
+	default:
+		return fmt.Errorf("unknown commentable type %q", parent.Type)
+	}
+
+	if !allowed {
+		return ErrCommentsDisabled
+	}
+	return nil
+}
+
+// CreateComment adds a comment, or a reply when ParentCommentID is set, to the content
+func (cm *CommentModel) CreateComment(parent Commentable, comment *Comment) error {
+	if err := cm.CheckCommentsAllowed(parent); err != nil {
+		return err
+	}
+
+	if comment.ParentCommentID != 0 {
+		var parentComment Comment
+		if err := cm.DB.Scopes(visibleComments).First(&parentComment, comment.ParentCommentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidReply
+			}
+			return err
+		}
+		if !parent.owns(parentComment) {
+			return ErrInvalidReply
+		}
+	}
+
+	parent.assign(comment)
+	return cm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(comment).Error; err != nil {
+			return err
+		}
+		return syncCommentTags(tx, comment)
+	})
+}
+
+// GetComment fetches a comment by ID along with its author
+func (cm *CommentModel) GetComment(commentID uint) (*Comment, error) {
+	var comment Comment
+	if err := cm.DB.Preload("User").First(&comment, commentID).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetComments fetches a page of the visible top-level comments of the content, newest first
+func (cm *CommentModel) GetComments(parent Commentable, offset, limit int) ([]CommentThread, int64, error) {
+	column, err := parent.column()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := cm.DB.Model(&Comment{}).Scopes(visibleComments).
+		Where("comments."+column+" = ? AND comments.parent_comment_id = 0", parent.ID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	threads := []CommentThread{}
+	if err := query.Scopes(withReplyCount).Preload("User").
+		Order("comments.created_at DESC, comments.id DESC").Offset(offset).Limit(limit).Find(&threads).Error; err != nil {
+		return nil, 0, err
+	}
+	return threads, total, nil
+}
+
+// GetReplies fetches a page of the visible replies to a comment, oldest first
+func (cm *CommentModel) GetReplies(commentID uint, offset, limit int) ([]CommentThread, int64, error) {
+	query := cm.DB.Model(&Comment{}).Scopes(visibleComments).Where("comments.parent_comment_id = ?", commentID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	replies := []CommentThread{}
+	if err := query.Scopes(withReplyCount).Preload("User").
+		Order("comments.created_at, comments.id").Offset(offset).Limit(limit).Find(&replies).Error; err != nil {
+		return nil, 0, err
+	}
+	return replies, total, nil
+}
+
+// UpdateCommentText edits the text of a user's own comment
+func (cm *CommentModel) UpdateCommentText(commentID, userID uint, text string) (*Comment, error) {
+	comment, err := cm.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.IsDeleted {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if comment.UserID != userID {
+		return nil, ErrNotCommentAuthor
+	}
+
+	comment.Text = text
+	comment.IsEdited = true
+	comment.EditedTimestamp = int(cm.Clock.Now().Unix())
+	err = cm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(comment).Select("text", "is_edited", "edited_timestamp").Updates(comment).Error; err != nil {
+			return err
+		}
+		return syncCommentTags(tx, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteComment marks a user's own comment as deleted
+func (cm *CommentModel) DeleteComment(commentID, userID uint) error {
+	comment, err := cm.GetComment(commentID)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != userID {
+		return ErrNotCommentAuthor
+	}
+	return cm.markDeleted(commentID)
+}
+
+// markDeleted sets the soft-delete flags of a comment
+func (cm *CommentModel) markDeleted(commentID uint) error {
+	result := cm.DB.Model(&Comment{}).Where("id = ?", commentID).Updates(map[string]interface{}{
+		"is_deleted":        true,
+		"deleted_timestamp": int(cm.Clock.Now().Unix()),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
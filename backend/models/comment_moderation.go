@@ -0,0 +1,122 @@
+// backend/models/comment_moderation.go
+
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotModerator is returned when a user moderates a comment on content they do not own
+var ErrNotModerator = errors.New("user cannot moderate this comment")
+
+// ReportComment records a user's report of a comment. Reporting twice counts once.
+// A comment with ReportHideThreshold reports is hidden until a moderator approves or removes it.
+func (cm *CommentModel) ReportComment(commentID, userID uint, reason string) (*Comment, error) {
+	return cm.updateReports(commentID, func(tx *gorm.DB) error {
+		report := CommentReport{CommentID: commentID, UserID: userID, Reason: reason}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&report).Error
+	})
+}
+
+// UnreportComment withdraws a user's report of a comment
+func (cm *CommentModel) UnreportComment(commentID, userID uint) (*Comment, error) {
+	return cm.updateReports(commentID, func(tx *gorm.DB) error {
+		return tx.Where("comment_id = ? AND user_id = ?", commentID, userID).Delete(&CommentReport{}).Error
+	})
+}
+
+// updateReports changes the reports of a comment and recounts them in one transaction
+func (cm *CommentModel) updateReports(commentID uint, change func(tx *gorm.DB) error) (*Comment, error) {
+	var comment Comment
+	err := cm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(visibleOrHidden).First(&comment, commentID).Error; err != nil {
+			return err
+		}
+		if err := change(tx); err != nil {
+			return err
+		}
+
+		var reports int64
+		if err := tx.Model(&CommentReport{}).Where("comment_id = ?", commentID).Count(&reports).Error; err != nil {
+			return err
+		}
+		comment.ReportsCount = uint(reports)
+		comment.IsReported = reports > 0
+		comment.IsHidden = reports >= ReportHideThreshold
+		return tx.Model(&comment).Select("reports_count", "is_reported", "is_hidden").Updates(&comment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// visibleOrHidden restricts a query to comments that are not deleted, whether hidden or not
+func visibleOrHidden(db *gorm.DB) *gorm.DB {
+	return db.Where("comments.is_deleted = ?", false)
+}
+
+// GetModerationQueue fetches a page of the reported comments a user moderates,
+// hidden ones first, then by number of reports
+func (cm *CommentModel) GetModerationQueue(userID uint, offset, limit int) ([]Comment, int64, error) {
+	query := cm.DB.Model(&Comment{}).Scopes(visibleOrHidden, ModeratedBy(userID)).Where("comments.is_reported = ?", true)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	comments := []Comment{}
+	if err := query.Preload("User").Order("comments.is_hidden DESC, comments.reports_count DESC, comments.id").
+		Offset(offset).Limit(limit).Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+// ApproveComment clears the reports of a comment and shows it again
+func (cm *CommentModel) ApproveComment(commentID uint) (*Comment, error) {
+	return cm.updateReports(commentID, func(tx *gorm.DB) error {
+		return tx.Where("comment_id = ?", commentID).Delete(&CommentReport{}).Error
+	})
+}
+
+// RemoveComment deletes a reported comment on behalf of a moderator
+func (cm *CommentModel) RemoveComment(commentID uint) error {
+	return cm.markDeleted(commentID)
+}
+
+// ModeratedBy restricts a query to the comments a user moderates: those on the videos, playlists and
+// advertisements the user owns, directly or through their channel
+func ModeratedBy(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		playlists := accessControlled[ContentPlaylist].owned
+		advertisements := accessControlled[ContentAdvertisement].owned
+		videos := "SELECT videos.id FROM videos WHERE videos.playlist_id IN (" + playlists + ") " +
+			"OR videos.channel_id IN (SELECT channels.id FROM channels WHERE channels.owner_id = ?)"
+		return db.Where("(comments.playlist_id IN ("+playlists+") OR comments.advertisement_id IN ("+advertisements+
+			") OR comments.video_id IN ("+videos+"))",
+			userID, userID, userID, userID, userID, userID, userID)
+	}
+}
+
+// CanModerate returns nil if a user moderates a comment, ErrNotModerator if the comment is on content
+// the user does not own and gorm.ErrRecordNotFound if it does not exist
+func (cm *CommentModel) CanModerate(commentID, userID uint) error {
+	if err := cm.DB.Model(&Comment{}).Scopes(visibleOrHidden).Select("id").
+		Take(&struct{ ID uint }{}, commentID).Error; err != nil {
+		return err
+	}
+	var moderated int64
+	if err := cm.DB.Model(&Comment{}).Scopes(ModeratedBy(userID)).Where("comments.id = ?", commentID).
+		Count(&moderated).Error; err != nil {
+		return err
+	}
+	if moderated == 0 {
+		return ErrNotModerator
+	}
+	return nil
+}
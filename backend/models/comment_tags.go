@@ -0,0 +1,133 @@
+// backend/models/comment_tags.go
+
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// mentionPattern matches @username not preceded by a word character, so email addresses are skipped
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_.]{1,30})`)
+	// hashtagPattern matches #tag not preceded by a word character or &, so URL fragments and entities are skipped
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_#&])#([\p{L}\p{N}_]{1,100})`)
+)
+
+// TrendingHashtag is a hashtag with the number of comments using it in a time window
+type TrendingHashtag struct {
+	Name     string `json:"name"`
+	Comments int64  `json:"comments"`
+}
+
+// ExtractMentions returns the distinct usernames mentioned as @username in text, in order of appearance
+func ExtractMentions(text string) []string {
+	var usernames []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := strings.TrimRight(match[1], ".")
+		if username == "" || seen[strings.ToLower(username)] {
+			continue
+		}
+		seen[strings.ToLower(username)] = true
+		usernames = append(usernames, username)
+	}
+	return usernames
+}
+
+// ExtractHashtags returns the distinct lowercase hashtags in text, without the #, in order of appearance.
+// Tags need at least one letter, so "#1" is not a hashtag.
+func ExtractHashtags(text string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.ToLower(match[1])
+		if seen[name] || strings.IndexFunc(name, unicode.IsLetter) < 0 {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// syncCommentTags replaces the mentions and hashtags of a comment with the ones in its text.
+// Mentions of unknown usernames are ignored and missing hashtags are created.
+func syncCommentTags(tx *gorm.DB, comment *Comment) error {
+	users := []User{}
+	if usernames := ExtractMentions(comment.Text); len(usernames) > 0 {
+		lowered := make([]string, len(usernames))
+		for i, username := range usernames {
+			lowered[i] = strings.ToLower(username)
+		}
+		if err := tx.Where("LOWER(username) IN ?", lowered).Find(&users).Error; err != nil {
+			return err
+		}
+	}
+
+	hashtags := []Hashtag{}
+	if names := ExtractHashtags(comment.Text); len(names) > 0 {
+		missing := make([]Hashtag, len(names))
+		for i, name := range names {
+			missing[i] = Hashtag{Name: name}
+		}
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+			Omit(clause.Associations).Create(&missing).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("name IN ?", names).Find(&hashtags).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(comment).Association("UserMentions").Replace(users); err != nil {
+		return err
+	}
+	if err := tx.Model(comment).Association("Hashtags").Replace(hashtags); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetHashtagComments fetches a page of the visible comments using a hashtag on content a viewer can read, newest first
+func (cm *CommentModel) GetHashtagComments(name string, viewer Viewer, offset, limit int) ([]Comment, int64, error) {
+	query := cm.DB.Model(&Comment{}).Scopes(visibleComments, CommentsVisibleTo(viewer)).
+		Joins("JOIN comment_hashtags ON comment_hashtags.comment_id = comments.id").
+		Joins("JOIN hashtags ON hashtags.id = comment_hashtags.hashtag_id").
+		Where("hashtags.name = ?", strings.ToLower(strings.TrimPrefix(name, "#")))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	comments := []Comment{}
+	if err := query.Preload("User").Order("comments.created_at DESC, comments.id DESC").
+		Offset(offset).Limit(limit).Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+// GetTrendingHashtags fetches the hashtags used by the most visible comments created since the given time.
+// Only comments on content anyone can read count, so that trending names never reveal private content.
+func (cm *CommentModel) GetTrendingHashtags(since time.Time, limit int) ([]TrendingHashtag, error) {
+	trending := []TrendingHashtag{}
+	if err := cm.DB.Table("comment_hashtags").
+		Select("hashtags.name AS name, COUNT(*) AS comments").
+		Joins("JOIN comments ON comments.id = comment_hashtags.comment_id").
+		Joins("JOIN hashtags ON hashtags.id = comment_hashtags.hashtag_id").
+		Where("comments.created_at >= ? AND comments.deleted_at IS NULL", since).
+		Scopes(visibleComments, CommentsVisibleTo(Viewer{})).
+		Group("hashtags.id, hashtags.name").
+		Order("COUNT(*) DESC, hashtags.name").
+		Limit(limit).Scan(&trending).Error; err != nil {
+		return nil, err
+	}
+	return trending, nil
+}
@@ -0,0 +1,229 @@
+// backend/models/comment_tags_test.go
+
+package models_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"thanks @alice!", []string{"alice"}},
+		{"@bob and @Carol_1, then @bob again", []string{"bob", "Carol_1"}},
+		{"cc @BOB @bob", []string{"BOB"}},
+		{"ask @dave.", []string{"dave"}},
+		{"@first.last knows", []string{"first.last"}},
+		{"write to alice@example.com", nil},
+		{"@@double and @ alone", nil},
+		{"(@zoë)", []string{"zoë"}},
+	}
+	for _, test := range tests {
+		if got := models.ExtractMentions(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ExtractMentions(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"#Go is fun", []string{"go"}},
+		{"#news #News #NEWS", []string{"news"}},
+		{"#summer2026 #2026", []string{"summer2026"}},
+		{"see example.com/page#section", nil},
+		{"it&#x27;s an entity", nil},
+		{"##double", nil},
+		{"(#été), #live_music.", []string{"été", "live_music"}},
+	}
+	for _, test := range tests {
+		if got := models.ExtractHashtags(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ExtractHashtags(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+// commentTags returns the sorted usernames mentioned by a comment and the sorted names of its hashtags
+func commentTags(t *testing.T, commentModel *models.CommentModel, comment *models.Comment) ([]string, []string) {
+	t.Helper()
+	var users []models.User
+	if err := commentModel.DB.Model(comment).Association("UserMentions").Find(&users); err != nil {
+		t.Fatalf("reading mentions: %v", err)
+	}
+	var hashtags []models.Hashtag
+	if err := commentModel.DB.Model(comment).Association("Hashtags").Find(&hashtags); err != nil {
+		t.Fatalf("reading hashtags: %v", err)
+	}
+	usernames, names := []string{}, []string{}
+	for _, user := range users {
+		usernames = append(usernames, user.Username)
+	}
+	for _, hashtag := range hashtags {
+		names = append(names, hashtag.Name)
+	}
+	sort.Strings(usernames)
+	sort.Strings(names)
+	return usernames, names
+}
+
+func TestCommentTagsFollowEdits(t *testing.T) {
+	db := newTestDB(t)
+	video, _, _ := commentParents(t, db)
+	var users []models.User
+	for _, username := range []string{"alice", "Bob", "carol"} {
+		user := models.User{Username: username, Email: username + "@example.com"}
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatalf("creating user: %v", err)
+		}
+		users = append(users, user)
+	}
+
+	commentModel := models.NewCommentModel(db)
+	comment := models.Comment{UserID: users[2].ID, Text: "@alice and @bob, meet @nobody #Go #go #news"}
+	if err := commentModel.CreateComment(models.Commentable{Type: models.CommentableVideo, ID: video.ID}, &comment); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	mentions, hashtags := commentTags(t, commentModel, &comment)
+	if !reflect.DeepEqual(mentions, []string{"Bob", "alice"}) || !reflect.DeepEqual(hashtags, []string{"go", "news"}) {
+		t.Errorf("created comment mentions %q with hashtags %q, want Bob and alice with go and news", mentions, hashtags)
+	}
+
+	edited, err := commentModel.UpdateCommentText(comment.ID, users[2].ID, "only @BOB now #news #weekend")
+	if err != nil {
+		t.Fatalf("UpdateCommentText: %v", err)
+	}
+	mentions, hashtags = commentTags(t, commentModel, edited)
+	if !reflect.DeepEqual(mentions, []string{"Bob"}) || !reflect.DeepEqual(hashtags, []string{"news", "weekend"}) {
+		t.Errorf("edited comment mentions %q with hashtags %q, want Bob with news and weekend", mentions, hashtags)
+	}
+
+	// Hashtags are shared between comments rather than duplicated
+	other := models.Comment{UserID: users[0].ID, Text: "#NEWS again"}
+	if err := commentModel.CreateComment(models.Commentable{Type: models.CommentableVideo, ID: video.ID}, &other); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	var count int64
+	if err := db.Model(&models.Hashtag{}).Where("name = ?", "news").Count(&count).Error; err != nil {
+		t.Fatalf("counting hashtags: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("got %d news hashtags, want 1", count)
+	}
+
+	if _, err := commentModel.UpdateCommentText(comment.ID, users[2].ID, "no tags left"); err != nil {
+		t.Fatalf("UpdateCommentText: %v", err)
+	}
+	if mentions, hashtags = commentTags(t, commentModel, &comment); len(mentions) != 0 || len(hashtags) != 0 {
+		t.Errorf("comment without tags still mentions %q with hashtags %q", mentions, hashtags)
+	}
+}
+
+func TestHashtagQueries(t *testing.T) {
+	db := newTestDB(t)
+	video, _, _ := commentParents(t, db)
+	user := models.User{Username: "viewer", Email: "viewer@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	commentModel := models.NewCommentModel(db)
+	onVideo := models.Commentable{Type: models.CommentableVideo, ID: video.ID}
+	comments := []struct {
+		text  string
+		age   time.Duration
+		state string // "hidden" or "deleted"
+	}{
+		{"#launch day", time.Hour, ""},
+		{"#launch #music", 2 * time.Hour, ""},
+		{"#music", 3 * time.Hour, ""},
+		{"#launch again", 5 * time.Hour, ""},
+		{"#old #launch", 48 * time.Hour, ""},
+		{"#old news", 49 * time.Hour, ""},
+		{"#spam", time.Hour, "hidden"},
+		{"#spam", time.Hour, "deleted"},
+	}
+	for _, test := range comments {
+		comment := models.Comment{UserID: user.ID, Text: test.text}
+		comment.CreatedAt = now.Add(-test.age)
+		if err := commentModel.CreateComment(onVideo, &comment); err != nil {
+			t.Fatalf("CreateComment: %v", err)
+		}
+		if test.state != "" {
+			if err := db.Model(&comment).Update("is_"+test.state, true).Error; err != nil {
+				t.Fatalf("updating comment: %v", err)
+			}
+		}
+	}
+
+	windows := []struct {
+		name  string
+		since time.Duration
+		limit int
+		want  []models.TrendingHashtag
+	}{
+		{"last day", 24 * time.Hour, 10, []models.TrendingHashtag{{Name: "launch", Comments: 3}, {Name: "music", Comments: 2}}},
+		{"last 4 hours", 4 * time.Hour, 10, []models.TrendingHashtag{{Name: "launch", Comments: 2}, {Name: "music", Comments: 2}}},
+		{"top one over three days", 72 * time.Hour, 1, []models.TrendingHashtag{{Name: "launch", Comments: 4}}},
+		{"last minute", time.Minute, 10, []models.TrendingHashtag{}},
+	}
+	for _, window := range windows {
+		trending, err := commentModel.GetTrendingHashtags(now.Add(-window.since), window.limit)
+		if err != nil {
+			t.Fatalf("GetTrendingHashtags: %v", err)
+		}
+		if !reflect.DeepEqual(trending, window.want) {
+			t.Errorf("%s: trending %+v, want %+v", window.name, trending, window.want)
+		}
+	}
+
+	// Comments on a private playlist are only found by those who can read it
+	private := models.Playlist{Title: "private", OwnerID: 9}
+	if err := db.Create(&private).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	if err := db.Model(&private).Update("is_public", false).Error; err != nil {
+		t.Fatalf("making playlist private: %v", err)
+	}
+	secret := models.Comment{UserID: user.ID, Text: "#secret #launch"}
+	secret.CreatedAt = now
+	if err := commentModel.CreateComment(models.Commentable{Type: models.CommentablePlaylist, ID: private.ID}, &secret); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	viewers := []struct {
+		name   string
+		viewer models.Viewer
+		want   int64
+	}{
+		{"anonymous", models.Viewer{}, 4},
+		{"owner of the private playlist", models.Viewer{UserID: 9}, 5},
+	}
+	for _, viewer := range viewers {
+		found, total, err := commentModel.GetHashtagComments("#LAUNCH", viewer.viewer, 0, 2)
+		if err != nil {
+			t.Fatalf("GetHashtagComments: %v", err)
+		}
+		if total != viewer.want || len(found) != 2 || !found[0].CreatedAt.After(found[1].CreatedAt) {
+			t.Errorf("%s: got %d of %d launch comments, want 2 of %d newest first", viewer.name, len(found), total, viewer.want)
+		}
+	}
+
+	// Trending hashtags are shared by everyone, so comments on the private playlist do not count
+	trending, err := commentModel.GetTrendingHashtags(now.Add(-time.Minute), 10)
+	if err != nil {
+		t.Fatalf("GetTrendingHashtags: %v", err)
+	}
+	if len(trending) != 0 {
+		t.Errorf("trending %+v from a comment on a private playlist, want none", trending)
+	}
+}
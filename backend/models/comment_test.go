@@ -0,0 +1,136 @@
+// backend/models/comment_test.go
+
+package models_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// commentParents stores a video, a playlist and an advertisement to comment on
+func commentParents(tb testing.TB, db *gorm.DB) (models.Video, models.Playlist, models.Advertisement) {
+	tb.Helper()
+	playlist := models.Playlist{Title: "playlist", IsPublic: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		tb.Fatalf("creating playlist: %v", err)
+	}
+	video := models.Video{Title: "video", PlaylistID: playlist.ID}
+	if err := db.Create(&video).Error; err != nil {
+		tb.Fatalf("creating video: %v", err)
+	}
+	advertisement := models.Advertisement{Title: "advertisement", PlaylistID: playlist.ID, IsPublic: true}
+	if err := db.Create(&advertisement).Error; err != nil {
+		tb.Fatalf("creating advertisement: %v", err)
+	}
+	return video, playlist, advertisement
+}
+
+// commentIDs returns the IDs of comments, in order
+func commentIDs(comments []models.Comment) []uint {
+	ids := []uint{}
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	return ids
+}
+
+func TestWithCommentsPreloadsEachParent(t *testing.T) {
+	db := newTestDB(t)
+	video, playlist, advertisement := commentParents(t, db)
+	user := models.User{Email: "viewer@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+
+	// Each parent gets two visible top-level comments, a reply, a hidden and a deleted comment
+	parents := []models.Commentable{
+		{Type: models.CommentableVideo, ID: video.ID},
+		{Type: models.CommentablePlaylist, ID: playlist.ID},
+		{Type: models.CommentableAdvertisement, ID: advertisement.ID},
+	}
+	commentModel := models.NewCommentModel(db)
+	start := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	want := map[string][]uint{}
+	for i, parent := range parents {
+		var visible []uint
+		for j, text := range []string{"first", "second", "reply", "hidden", "deleted"} {
+			comment := models.Comment{UserID: user.ID, Text: text}
+			comment.CreatedAt = start.Add(time.Duration(i*10+j) * time.Minute)
+			if text == "reply" {
+				comment.ParentCommentID = visible[0]
+			}
+			if err := commentModel.CreateComment(parent, &comment); err != nil {
+				t.Fatalf("commenting on %s %d: %v", parent.Type, parent.ID, err)
+			}
+			switch text {
+			case "first", "second":
+				visible = append(visible, comment.ID)
+			case "hidden":
+				if err := db.Model(&comment).Update("is_hidden", true).Error; err != nil {
+					t.Fatalf("hiding comment: %v", err)
+				}
+			case "deleted":
+				if err := commentModel.DeleteComment(comment.ID, user.ID); err != nil {
+					t.Fatalf("deleting comment: %v", err)
+				}
+			}
+		}
+		// Newest first
+		want[parent.Type] = []uint{visible[1], visible[0]}
+	}
+
+	withVideo, err := models.NewVideoModel(db).GetVideoWithComments(video.ID)
+	if err != nil {
+		t.Fatalf("GetVideoWithComments: %v", err)
+	}
+	withPlaylist, err := models.NewPlaylistModel(db).GetPlaylistWithComments(playlist.ID)
+	if err != nil {
+		t.Fatalf("GetPlaylistWithComments: %v", err)
+	}
+	withAdvertisement, err := models.NewAdvertisementModel(db).GetAdvertisementByID(advertisement.ID)
+	if err != nil {
+		t.Fatalf("GetAdvertisementByID: %v", err)
+	}
+
+	got := map[string][]models.Comment{
+		models.CommentableVideo:         withVideo.Comments,
+		models.CommentablePlaylist:      withPlaylist.Comments,
+		models.CommentableAdvertisement: withAdvertisement.Comments,
+	}
+	for _, parent := range parents {
+		if ids := commentIDs(got[parent.Type]); !reflect.DeepEqual(ids, want[parent.Type]) {
+			t.Errorf("%s comments are %v, want %v", parent.Type, ids, want[parent.Type])
+		}
+		for _, comment := range got[parent.Type] {
+			if owner, ok := comment.Commentable(); !ok || owner != parent {
+				t.Errorf("comment %d belongs to %+v, want %+v", comment.ID, owner, parent)
+			}
+		}
+	}
+}
+
+func TestCommentNeedsExactlyOneOwner(t *testing.T) {
+	db := newTestDB(t)
+	video, playlist, _ := commentParents(t, db)
+
+	tests := []struct {
+		name    string
+		comment models.Comment
+	}{
+		{"no owner", models.Comment{Text: "orphan"}},
+		{"two owners", models.Comment{Text: "twice", VideoID: &video.ID, PlaylistID: &playlist.ID}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := db.Create(&test.comment).Error; !errors.Is(err, models.ErrCommentOwner) {
+				t.Errorf("got error %v, want %v", err, models.ErrCommentOwner)
+			}
+		})
+	}
+}
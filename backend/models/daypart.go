@@ -0,0 +1,114 @@
+// backend/models/daypart.go
+
+package models
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DaypartRule restricts an advertisement to a weekly time window.
+// StartTime and EndTime are "HH:MM" in the timezone of the playlist's channel.
+// A window whose EndTime is not after its StartTime runs past midnight into the next day.
+type DaypartRule struct {
+	gorm.Model
+	AdvertisementID uint         `json:"-" gorm:"index"`
+	DayOfWeek       time.Weekday `json:"dayOfWeek"` // 0 = Sunday
+	StartTime       string       `json:"startTime"` // Inclusive, e.g. "06:00"
+	EndTime         string       `json:"endTime"`   // Exclusive, e.g. "10:30" or "24:00"
+}
+
+// Validate checks that the rule has a valid day and time range
+func (r DaypartRule) Validate() error {
+	if r.DayOfWeek < time.Sunday || r.DayOfWeek > time.Saturday {
+		return fmt.Errorf("invalid day of week %d", r.DayOfWeek)
+	}
+	start, err := parseClockTime(r.StartTime)
+	if err != nil {
+		return err
+	}
+	end, err := parseClockTime(r.EndTime)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return fmt.Errorf("daypart %s-%s is empty", r.StartTime, r.EndTime)
+	}
+	return nil
+}
+
+// Contains reports whether t, already converted to the channel's timezone, falls inside the window
+func (r DaypartRule) Contains(t time.Time) bool {
+	start, err := parseClockTime(r.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := parseClockTime(r.EndTime)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	if start < end {
+		return t.Weekday() == r.DayOfWeek && minute >= start && minute < end
+	}
+
+	// Overnight window, e.g. Friday 20:00-02:00 also covers early Saturday
+	nextDay := (r.DayOfWeek + 1) % 7
+	return (t.Weekday() == r.DayOfWeek && minute >= start) || (t.Weekday() == nextDay && minute < end)
+}
+
+// DaypartsAllow reports whether an advertisement with the given rules may play at t.
+// An advertisement without rules may play at any time.
+func DaypartsAllow(rules []DaypartRule, t time.Time) bool {
+	if len(rules) == 0 {
+		return true
+	}
+	for _, rule := range rules {
+		if rule.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDayparts validates every daypart rule of an advertisement
+func ValidateDayparts(rules []DaypartRule) error {
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("daypart %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// parseClockTime converts "HH:MM" into minutes since midnight, allowing "24:00" as end of day
+func parseClockTime(value string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hour, &minute); err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	if hour == 24 && minute == 0 {
+		return 24 * 60, nil
+	}
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// loadTimezone resolves an IANA timezone name, falling back to UTC
+func loadTimezone(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Unknown timezone %q, using UTC: %v", name, err)
+		return time.UTC
+	}
+	return location
+}
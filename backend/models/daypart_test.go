@@ -0,0 +1,148 @@
+// backend/models/daypart_test.go
+
+package models_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestDaypartRuleContains(t *testing.T) {
+	// 2026-03-06 is a Friday
+	at := func(day int, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		rule models.DaypartRule
+		time time.Time
+		want bool
+	}{
+		{"inside daytime window", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "06:00", EndTime: "10:30"}, at(6, 8, 0), true},
+		{"start is inclusive", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "06:00", EndTime: "10:30"}, at(6, 6, 0), true},
+		{"end is exclusive", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "06:00", EndTime: "10:30"}, at(6, 10, 30), false},
+		{"other day", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "06:00", EndTime: "10:30"}, at(7, 8, 0), false},
+		{"until end of day", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "18:00", EndTime: "24:00"}, at(6, 23, 59), true},
+		{"end of day stops at midnight", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "18:00", EndTime: "24:00"}, at(7, 0, 0), false},
+		{"overnight before midnight", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, at(6, 20, 0), true},
+		{"overnight after midnight", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, at(7, 1, 59), true},
+		{"overnight ends next day", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, at(7, 2, 0), false},
+		{"overnight before start", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, at(6, 19, 59), false},
+		{"overnight early morning of its own day", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, at(6, 1, 0), false},
+		{"saturday overnight rolls over to sunday", models.DaypartRule{DayOfWeek: time.Saturday, StartTime: "22:00", EndTime: "02:00"}, at(8, 1, 0), true},
+		{"saturday overnight skips monday", models.DaypartRule{DayOfWeek: time.Saturday, StartTime: "22:00", EndTime: "02:00"}, at(9, 1, 0), false},
+		{"invalid time never matches", models.DaypartRule{DayOfWeek: time.Friday, StartTime: "6am", EndTime: "10:30"}, at(6, 8, 0), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.rule.Contains(test.time); got != test.want {
+				t.Errorf("%s %s-%s contains %s = %v, want %v", test.rule.DayOfWeek, test.rule.StartTime, test.rule.EndTime,
+					test.time.Format("Mon 15:04"), got, test.want)
+			}
+		})
+	}
+}
+
+func TestDaypartsAllowWithoutRules(t *testing.T) {
+	if !models.DaypartsAllow(nil, time.Now()) {
+		t.Error("advertisement without dayparts is not allowed")
+	}
+}
+
+// daypartCase is a daypart rule of an advertisement on a channel in a timezone, checked at an instant
+type daypartCase struct {
+	name     string
+	timezone string
+	rule     models.DaypartRule
+	now      time.Time // Time of the clock, in any location
+	want     bool      // Whether the advertisement is eligible
+}
+
+// daypartCases cover overnight windows, day of week rollover, DST transitions and per-channel timezones
+var daypartCases = []daypartCase{
+	// 2026-03-06 is a Friday
+	{"overnight window before midnight", "UTC",
+		models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, time.Date(2026, 3, 6, 21, 0, 0, 0, time.UTC), true},
+	{"overnight window after midnight", "UTC",
+		models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, time.Date(2026, 3, 7, 1, 30, 0, 0, time.UTC), true},
+	{"overnight window over", "UTC",
+		models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "02:00"}, time.Date(2026, 3, 7, 2, 30, 0, 0, time.UTC), false},
+	{"saturday night rolls over to sunday", "UTC",
+		models.DaypartRule{DayOfWeek: time.Saturday, StartTime: "22:00", EndTime: "02:00"}, time.Date(2026, 3, 8, 0, 30, 0, 0, time.UTC), true},
+	{"channel timezone moves the day", "Asia/Tokyo",
+		// Friday 20:00 UTC is Saturday 05:00 in Tokyo
+		models.DaypartRule{DayOfWeek: time.Saturday, StartTime: "05:00", EndTime: "06:00"}, time.Date(2026, 3, 6, 20, 30, 0, 0, time.UTC), true},
+	{"utc day does not apply in channel timezone", "Asia/Tokyo",
+		models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "21:00"}, time.Date(2026, 3, 6, 20, 30, 0, 0, time.UTC), false},
+	{"channel timezone behind utc", "America/Los_Angeles",
+		// Saturday 03:00 UTC is Friday 19:00 in Los Angeles
+		models.DaypartRule{DayOfWeek: time.Friday, StartTime: "18:00", EndTime: "20:00"}, time.Date(2026, 3, 7, 3, 0, 0, 0, time.UTC), true},
+	{"unknown timezone falls back to utc", "Mars/Olympus_Mons",
+		models.DaypartRule{DayOfWeek: time.Friday, StartTime: "20:00", EndTime: "21:00"}, time.Date(2026, 3, 6, 20, 30, 0, 0, time.UTC), true},
+	// New York springs forward at 02:00 on Sunday 2026-03-08, straight to 03:00 EDT
+	{"before spring forward", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "01:00", EndTime: "02:00"}, time.Date(2026, 3, 8, 6, 30, 0, 0, time.UTC), true},
+	{"after spring forward", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "03:00", EndTime: "04:00"}, time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC), true},
+	{"skipped hour of spring forward", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "02:00", EndTime: "03:00"}, time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), false},
+	// New York falls back at 02:00 on Sunday 2026-11-01, so 01:30 happens twice
+	{"first 01:30 of fall back", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "01:00", EndTime: "02:00"}, time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), true},
+	{"second 01:30 of fall back", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "01:00", EndTime: "02:00"}, time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC), true},
+	{"after fall back", "America/New_York",
+		models.DaypartRule{DayOfWeek: time.Sunday, StartTime: "01:00", EndTime: "02:00"}, time.Date(2026, 11, 1, 7, 30, 0, 0, time.UTC), false},
+}
+
+// createDaypartPlaylist stores a channel in a timezone with a playlist holding one advertisement
+// restricted to a daypart, due since before the case's time
+func createDaypartPlaylist(tb testing.TB, db *gorm.DB, test daypartCase) (models.Playlist, models.Advertisement) {
+	tb.Helper()
+	channel := models.Channel{Name: test.name, Timezone: test.timezone}
+	if err := db.Create(&channel).Error; err != nil {
+		tb.Fatalf("creating channel: %v", err)
+	}
+	playlist := models.Playlist{Title: test.name, ChannelID: channel.ID, IsPublic: true, IsPlayable: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		tb.Fatalf("creating playlist: %v", err)
+	}
+	advertisement := models.Advertisement{
+		PlaylistID:  playlist.ID,
+		Title:       test.name,
+		Duration:    30,
+		IsPublic:    true,
+		ScheduledAt: test.now.Add(-24 * time.Hour),
+		Dayparts:    []models.DaypartRule{test.rule},
+	}
+	if err := db.Create(&advertisement).Error; err != nil {
+		tb.Fatalf("creating advertisement: %v", err)
+	}
+	return playlist, advertisement
+}
+
+func TestGetNextAdvertisementForPlaylistDayparts(t *testing.T) {
+	for _, test := range daypartCases {
+		t.Run(test.name, func(t *testing.T) {
+			db := newTestDB(t)
+			playlist, advertisement := createDaypartPlaylist(t, db, test)
+
+			advertisementModel := models.NewAdvertisementModel(db)
+			advertisementModel.Clock = models.ClockFunc(func() time.Time { return test.now })
+			next, err := advertisementModel.GetNextAdvertisementForPlaylist(playlist.ID)
+			switch {
+			case test.want && err != nil:
+				t.Fatalf("GetNextAdvertisementForPlaylist: %v", err)
+			case test.want && next.ID != advertisement.ID:
+				t.Fatalf("got advertisement %d, want %d", next.ID, advertisement.ID)
+			case !test.want && !errors.Is(err, gorm.ErrRecordNotFound):
+				t.Fatalf("got advertisement %v and error %v, want none", next, err)
+			}
+		})
+	}
+}
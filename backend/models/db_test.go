@@ -0,0 +1,42 @@
+// backend/models/db_test.go
+
+package models_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/shuttlersit/ads-player/backend/database"
+)
+
+// testDatabases numbers the in-memory databases so tests never share one
+var testDatabases int64
+
+// newTestDB opens a migrated in-memory SQLite database that lives as long as the test
+func newTestDB(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(tb.Name())
+	dsn := fmt.Sprintf("file:%s_%d?mode=memory&cache=shared&_foreign_keys=0", name, atomic.AddInt64(&testDatabases, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		tb.Fatalf("opening database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		tb.Fatalf("opening database: %v", err)
+	}
+	// One connection keeps the in-memory database alive and serializes writes
+	sqlDB.SetMaxOpenConns(1)
+	tb.Cleanup(func() { sqlDB.Close() })
+
+	if err := database.Migrate(db); err != nil {
+		tb.Fatalf("migrating database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,297 @@
+// backend/models/engagement.go
+
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Types of content users can comment on, react to, follow or search for
+const (
+	ContentVideo         = "video"
+	ContentPlaylist      = "playlist"
+	ContentAdvertisement = "advertisement"
+	ContentChannel       = "channel"
+)
+
+// Reactions a user can have to content
+const (
+	ReactionNone    = ""
+	ReactionLike    = "like"
+	ReactionDislike = "dislike"
+)
+
+// ErrNotFollowable is returned when following content that has no followers
+var ErrNotFollowable = errors.New("content cannot be followed")
+
+// engagementTables describes where the per-user state and counters of a content type are stored
+type engagementTables struct {
+	Table           string   // Content table
+	Key             string   // Join table column referencing the content
+	Likes           string   // Join table of users who like the content
+	Dislikes        string   // Join table of users who dislike the content
+	Followers       string   // Join table of users who follow the content, empty if it cannot be followed
+	LikeColumns     []string // Counter columns of likes, the first one is read back
+	DislikeColumns  []string // Counter columns of dislikes, the first one is read back
+	FollowerColumns []string // Counter columns of followers, the first one is read back
+}
+
+// engagements maps content types to their tables
+var engagements = map[string]engagementTables{
+	ContentVideo: {
+		Table:          "videos",
+		Key:            "video_id",
+		Likes:          "user_liked_videos",
+		Dislikes:       "user_disliked_videos",
+		LikeColumns:    []string{"likes"},
+		DislikeColumns: []string{"dislikes"},
+	},
+	ContentPlaylist: {
+		Table:           "playlists",
+		Key:             "playlist_id",
+		Likes:           "user_liked_playlists",
+		Dislikes:        "user_disliked_playlists",
+		Followers:       "user_playlist_followers",
+		LikeColumns:     []string{"like_count"},
+		DislikeColumns:  []string{"dislike_count"},
+		FollowerColumns: []string{"follower_count"},
+	},
+	ContentAdvertisement: {
+		Table:           "advertisements",
+		Key:             "advertisement_id",
+		Likes:           "user_liked_advertisements",
+		Dislikes:        "user_disliked_advertisements",
+		Followers:       "user_advertisement_followers",
+		LikeColumns:     []string{"like_count", "likes"},
+		DislikeColumns:  []string{"dislike_count", "dislikes"},
+		FollowerColumns: []string{"followers"},
+	},
+}
+
+// ReactionState is a user's reaction to content along with the content's counters
+type ReactionState struct {
+	Reaction string `json:"reaction"`
+	Likes    uint   `json:"likes"`
+	Dislikes uint   `json:"dislikes"`
+}
+
+// FollowState is whether a user follows content along with its follower count
+type FollowState struct {
+	Following bool `json:"following"`
+	Followers uint `json:"followers"`
+}
+
+// EngagementModel handles likes, dislikes and follows of videos, playlists and advertisements
+type EngagementModel struct {
+	DB *gorm.DB
+}
+
+// NewEngagementModel creates a new instance of EngagementModel
+func NewEngagementModel(db *gorm.DB) *EngagementModel {
+	return &EngagementModel{
+		DB: db,
+	}
+}
+
+// tablesFor returns the engagement tables of a content type
+func tablesFor(contentType string) (engagementTables, error) {
+	tables, ok := engagements[contentType]
+	if !ok {
+		return engagementTables{}, fmt.Errorf("unknown content type %q", contentType)
+	}
+	return tables, nil
+}
+
+// SetReaction sets a user's reaction to content to like, dislike or none. Setting the current reaction
+// again changes nothing, and liking removes a dislike and the other way round. The join tables
+// and counters are updated in one transaction.
+func (em *EngagementModel) SetReaction(contentType string, contentID, userID uint, reaction string) (*ReactionState, error) {
+	if reaction != ReactionNone && reaction != ReactionLike && reaction != ReactionDislike {
+		return nil, fmt.Errorf("unknown reaction %q", reaction)
+	}
+	tables, err := tablesFor(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	state := ReactionState{Reaction: reaction}
+	err = em.DB.Transaction(func(tx *gorm.DB) error {
+		if err := contentExists(tx, tables, contentID); err != nil {
+			return err
+		}
+		if err := setMembership(tx, tables, tables.Likes, tables.LikeColumns, contentID, userID, reaction == ReactionLike); err != nil {
+			return err
+		}
+		if err := setMembership(tx, tables, tables.Dislikes, tables.DislikeColumns, contentID, userID, reaction == ReactionDislike); err != nil {
+			return err
+		}
+		return tx.Table(tables.Table).Where("id = ?", contentID).
+			Select(tables.LikeColumns[0] + " AS likes, " + tables.DislikeColumns[0] + " AS dislikes").
+			Scan(&state).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// RemoveReaction removes a user's like or dislike of content, leaving the other reaction alone.
+// Removing a reaction the user does not have changes nothing.
+func (em *EngagementModel) RemoveReaction(contentType string, contentID, userID uint, reaction string) (*ReactionState, error) {
+	if reaction != ReactionLike && reaction != ReactionDislike {
+		return nil, fmt.Errorf("unknown reaction %q", reaction)
+	}
+	tables, err := tablesFor(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	joinTable, counters := tables.Likes, tables.LikeColumns
+	if reaction == ReactionDislike {
+		joinTable, counters = tables.Dislikes, tables.DislikeColumns
+	}
+	err = em.DB.Transaction(func(tx *gorm.DB) error {
+		if err := contentExists(tx, tables, contentID); err != nil {
+			return err
+		}
+		return setMembership(tx, tables, joinTable, counters, contentID, userID, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return em.GetReaction(contentType, contentID, userID)
+}
+
+// GetReaction fetches a user's reaction to content along with its counters
+func (em *EngagementModel) GetReaction(contentType string, contentID, userID uint) (*ReactionState, error) {
+	tables, err := tablesFor(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if err := contentExists(em.DB, tables, contentID); err != nil {
+		return nil, err
+	}
+
+	var state ReactionState
+	if err := em.DB.Table(tables.Table).Where("id = ?", contentID).
+		Select(tables.LikeColumns[0] + " AS likes, " + tables.DislikeColumns[0] + " AS dislikes").
+		Scan(&state).Error; err != nil {
+		return nil, err
+	}
+	if liked, err := isMember(em.DB, tables, tables.Likes, contentID, userID); err != nil {
+		return nil, err
+	} else if liked {
+		state.Reaction = ReactionLike
+	}
+	if disliked, err := isMember(em.DB, tables, tables.Dislikes, contentID, userID); err != nil {
+		return nil, err
+	} else if disliked {
+		state.Reaction = ReactionDislike
+	}
+	return &state, nil
+}
+
+// SetFollowing makes a user follow or unfollow content. Following twice changes nothing.
+func (em *EngagementModel) SetFollowing(contentType string, contentID, userID uint, following bool) (*FollowState, error) {
+	tables, err := tablesFor(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if tables.Followers == "" {
+		return nil, ErrNotFollowable
+	}
+
+	state := FollowState{Following: following}
+	err = em.DB.Transaction(func(tx *gorm.DB) error {
+		if err := contentExists(tx, tables, contentID); err != nil {
+			return err
+		}
+		if err := setMembership(tx, tables, tables.Followers, tables.FollowerColumns, contentID, userID, following); err != nil {
+			return err
+		}
+		return tx.Table(tables.Table).Where("id = ?", contentID).
+			Select(tables.FollowerColumns[0] + " AS followers").Scan(&state).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// contentExists returns gorm.ErrRecordNotFound unless the content exists
+func contentExists(tx *gorm.DB, tables engagementTables, contentID uint) error {
+	var count int64
+	if err := tx.Table(tables.Table).Where("id = ? AND deleted_at IS NULL", contentID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// isMember reports whether a user is in a join table for the content
+func isMember(tx *gorm.DB, tables engagementTables, joinTable string, contentID, userID uint) (bool, error) {
+	var count int64
+	if err := tx.Table(joinTable).Where("user_id = ? AND "+tables.Key+" = ?", userID, contentID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// setMembership adds or removes a user in a join table and adjusts the counters by the rows actually changed
+func setMembership(tx *gorm.DB, tables engagementTables, joinTable string, counters []string, contentID, userID uint, member bool) error {
+	var result *gorm.DB
+	delta := 1
+	if member {
+		result = tx.Table(joinTable).Clauses(clause.OnConflict{DoNothing: true}).
+			Create(map[string]interface{}{"user_id": userID, tables.Key: contentID})
+	} else {
+		result = tx.Exec("DELETE FROM "+joinTable+" WHERE user_id = ? AND "+tables.Key+" = ?", userID, contentID)
+		delta = -1
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	updates := map[string]interface{}{}
+	for _, column := range counters {
+		updates[column] = gorm.Expr("CASE WHEN "+column+" + ? < 0 THEN 0 ELSE "+column+" + ? END", delta, delta)
+	}
+	return tx.Table(tables.Table).Where("id = ?", contentID).UpdateColumns(updates).Error
+}
+
+// ReconcileEngagementCounters recomputes every like, dislike and follower counter from the join tables,
+// correcting any drift
+func (em *EngagementModel) ReconcileEngagementCounters() error {
+	return em.DB.Transaction(func(tx *gorm.DB) error {
+		for _, tables := range engagements {
+			updates := map[string]interface{}{}
+			count := func(joinTable string) clause.Expr {
+				return gorm.Expr("(SELECT COUNT(*) FROM " + joinTable + " WHERE " + joinTable + "." + tables.Key + " = " + tables.Table + ".id)")
+			}
+			for _, column := range tables.LikeColumns {
+				updates[column] = count(tables.Likes)
+			}
+			for _, column := range tables.DislikeColumns {
+				updates[column] = count(tables.Dislikes)
+			}
+			if tables.Followers != "" {
+				for _, column := range tables.FollowerColumns {
+					updates[column] = count(tables.Followers)
+				}
+			}
+			if err := tx.Table(tables.Table).Where("1 = 1").UpdateColumns(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
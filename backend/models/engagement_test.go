@@ -0,0 +1,198 @@
+// backend/models/engagement_test.go
+
+package models_test
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// engagementContent stores a video, a playlist and an advertisement, returning their IDs by content type
+func engagementContent(tb testing.TB, db *gorm.DB) map[string]uint {
+	tb.Helper()
+	video, playlist, advertisement := commentParents(tb, db)
+	return map[string]uint{
+		models.ContentVideo:         video.ID,
+		models.ContentPlaylist:      playlist.ID,
+		models.ContentAdvertisement: advertisement.ID,
+	}
+}
+
+// counter reads a counter column of a content row
+func counter(tb testing.TB, db *gorm.DB, table, column string, id uint) uint {
+	tb.Helper()
+	var value uint
+	if err := db.Table(table).Where("id = ?", id).Select(column).Scan(&value).Error; err != nil {
+		tb.Fatalf("reading %s.%s: %v", table, column, err)
+	}
+	return value
+}
+
+func TestSetReactionIsIdempotentAndExclusive(t *testing.T) {
+	db := newTestDB(t)
+	content := engagementContent(t, db)
+	engagementModel := models.NewEngagementModel(db)
+
+	steps := []struct {
+		name     string
+		userID   uint
+		reaction string
+		remove   bool // RemoveReaction instead of SetReaction
+		want     models.ReactionState
+	}{
+		{"like", 1, models.ReactionLike, false, models.ReactionState{Reaction: models.ReactionLike, Likes: 1}},
+		{"like again", 1, models.ReactionLike, false, models.ReactionState{Reaction: models.ReactionLike, Likes: 1}},
+		{"another user likes", 2, models.ReactionLike, false, models.ReactionState{Reaction: models.ReactionLike, Likes: 2}},
+		{"switch to dislike", 1, models.ReactionDislike, false, models.ReactionState{Reaction: models.ReactionDislike, Likes: 1, Dislikes: 1}},
+		{"dislike again", 1, models.ReactionDislike, false, models.ReactionState{Reaction: models.ReactionDislike, Likes: 1, Dislikes: 1}},
+		{"remove a like not given", 1, models.ReactionLike, true, models.ReactionState{Reaction: models.ReactionDislike, Likes: 1, Dislikes: 1}},
+		{"switch back to like", 1, models.ReactionLike, false, models.ReactionState{Reaction: models.ReactionLike, Likes: 2}},
+		{"clear", 1, models.ReactionNone, false, models.ReactionState{Likes: 1}},
+		{"clear again", 1, models.ReactionNone, false, models.ReactionState{Likes: 1}},
+		{"remove the other like", 2, models.ReactionLike, true, models.ReactionState{}},
+		{"remove it again", 2, models.ReactionLike, true, models.ReactionState{}},
+	}
+	for contentType, id := range content {
+		for _, step := range steps {
+			var state *models.ReactionState
+			var err error
+			if step.remove {
+				state, err = engagementModel.RemoveReaction(contentType, id, step.userID, step.reaction)
+			} else {
+				state, err = engagementModel.SetReaction(contentType, id, step.userID, step.reaction)
+			}
+			if err != nil {
+				t.Fatalf("%s: %s: %v", contentType, step.name, err)
+			}
+			if *state != step.want {
+				t.Errorf("%s: %s left %+v, want %+v", contentType, step.name, *state, step.want)
+			}
+		}
+	}
+
+	// Advertisements keep their counters in two columns, both updated
+	if _, err := engagementModel.SetReaction(models.ContentAdvertisement, content[models.ContentAdvertisement], 3, models.ReactionDislike); err != nil {
+		t.Fatalf("SetReaction: %v", err)
+	}
+	for _, column := range []string{"dislike_count", "dislikes"} {
+		if got := counter(t, db, "advertisements", column, content[models.ContentAdvertisement]); got != 1 {
+			t.Errorf("advertisements.%s is %d, want 1", column, got)
+		}
+	}
+
+	if _, err := engagementModel.SetReaction(models.ContentVideo, content[models.ContentVideo], 1, "love"); err == nil {
+		t.Error("SetReaction accepted an unknown reaction")
+	}
+	if _, err := engagementModel.SetReaction(models.ContentPlaylist, 999, 1, models.ReactionLike); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("liking a missing playlist got %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestSetFollowingIsIdempotent(t *testing.T) {
+	db := newTestDB(t)
+	content := engagementContent(t, db)
+	engagementModel := models.NewEngagementModel(db)
+
+	steps := []struct {
+		name      string
+		userID    uint
+		following bool
+		want      models.FollowState
+	}{
+		{"follow", 1, true, models.FollowState{Following: true, Followers: 1}},
+		{"follow again", 1, true, models.FollowState{Following: true, Followers: 1}},
+		{"another user follows", 2, true, models.FollowState{Following: true, Followers: 2}},
+		{"unfollow", 1, false, models.FollowState{Followers: 1}},
+		{"unfollow again", 1, false, models.FollowState{Followers: 1}},
+		{"unfollow without following", 3, false, models.FollowState{Followers: 1}},
+	}
+	for _, contentType := range []string{models.ContentPlaylist, models.ContentAdvertisement} {
+		for _, step := range steps {
+			state, err := engagementModel.SetFollowing(contentType, content[contentType], step.userID, step.following)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", contentType, step.name, err)
+			}
+			if *state != step.want {
+				t.Errorf("%s: %s left %+v, want %+v", contentType, step.name, *state, step.want)
+			}
+		}
+	}
+
+	if _, err := engagementModel.SetFollowing(models.ContentVideo, content[models.ContentVideo], 1, true); !errors.Is(err, models.ErrNotFollowable) {
+		t.Errorf("following a video got %v, want ErrNotFollowable", err)
+	}
+	if _, err := engagementModel.SetFollowing(models.ContentAdvertisement, 999, 1, true); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("following a missing advertisement got %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestReconcileEngagementCounters(t *testing.T) {
+	db := newTestDB(t)
+	content := engagementContent(t, db)
+	engagementModel := models.NewEngagementModel(db)
+	for userID := uint(1); userID <= 3; userID++ {
+		for contentType, id := range content {
+			reaction := models.ReactionLike
+			if userID == 3 {
+				reaction = models.ReactionDislike
+			}
+			if _, err := engagementModel.SetReaction(contentType, id, userID, reaction); err != nil {
+				t.Fatalf("SetReaction: %v", err)
+			}
+		}
+		if _, err := engagementModel.SetFollowing(models.ContentPlaylist, content[models.ContentPlaylist], userID, true); err != nil {
+			t.Fatalf("SetFollowing: %v", err)
+		}
+	}
+
+	// Drift every counter, including a follower row written without its counter
+	drift := []struct {
+		table   string
+		columns []string
+	}{
+		{"videos", []string{"likes", "dislikes"}},
+		{"playlists", []string{"like_count", "dislike_count", "follower_count"}},
+		{"advertisements", []string{"like_count", "likes", "dislike_count", "dislikes", "followers"}},
+	}
+	for _, table := range drift {
+		updates := map[string]interface{}{}
+		for _, column := range table.columns {
+			updates[column] = 40
+		}
+		if err := db.Table(table.table).Where("1 = 1").UpdateColumns(updates).Error; err != nil {
+			t.Fatalf("drifting %s: %v", table.table, err)
+		}
+	}
+	if err := db.Exec("INSERT INTO user_advertisement_followers (user_id, advertisement_id) VALUES (?, ?)", 4, content[models.ContentAdvertisement]).Error; err != nil {
+		t.Fatalf("adding follower: %v", err)
+	}
+
+	if err := engagementModel.ReconcileEngagementCounters(); err != nil {
+		t.Fatalf("ReconcileEngagementCounters: %v", err)
+	}
+	want := []struct {
+		table, column string
+		id            uint
+		value         uint
+	}{
+		{"videos", "likes", content[models.ContentVideo], 2},
+		{"videos", "dislikes", content[models.ContentVideo], 1},
+		{"playlists", "like_count", content[models.ContentPlaylist], 2},
+		{"playlists", "dislike_count", content[models.ContentPlaylist], 1},
+		{"playlists", "follower_count", content[models.ContentPlaylist], 3},
+		{"advertisements", "like_count", content[models.ContentAdvertisement], 2},
+		{"advertisements", "likes", content[models.ContentAdvertisement], 2},
+		{"advertisements", "dislike_count", content[models.ContentAdvertisement], 1},
+		{"advertisements", "dislikes", content[models.ContentAdvertisement], 1},
+		{"advertisements", "followers", content[models.ContentAdvertisement], 1},
+	}
+	for _, expected := range want {
+		if got := counter(t, db, expected.table, expected.column, expected.id); got != expected.value {
+			t.Errorf("%s.%s is %d after reconciling, want %d", expected.table, expected.column, got, expected.value)
+		}
+	}
+}
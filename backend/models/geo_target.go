@@ -0,0 +1,172 @@
+// backend/models/geo_target.go
+
+package models
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const earthRadiusKm = 6371.0
+
+// GeoTargetRule limits an advertisement to a place (country, region and/or city) or to a radius around a point,
+// or keeps it out of one when Exclude is set. An advertisement may play wherever any one of its include rules
+// matches (anywhere when it has none) and none of its exclude rules does.
+type GeoTargetRule struct {
+	gorm.Model
+	AdvertisementID uint    `json:"-" gorm:"index"`
+	Country         string  `json:"country,omitempty"`
+	Region          string  `json:"region,omitempty"` // Matched against Location.Region or Location.State
+	City            string  `json:"city,omitempty"`
+	Latitude        float64 `json:"latitude,omitempty"`
+	Longitude       float64 `json:"longitude,omitempty"`
+	RadiusKm        float64 `json:"radiusKm,omitempty"`
+	Exclude         bool    `json:"exclude,omitempty"`
+	// Bounding box around the radius, kept up to date by BeforeSave and used to prefilter in SQL
+	MinLatitude  float64 `json:"-" gorm:"index:idx_geo_target_rules_bbox,priority:1"`
+	MaxLatitude  float64 `json:"-" gorm:"index:idx_geo_target_rules_bbox,priority:2"`
+	MinLongitude float64 `json:"-" gorm:"index:idx_geo_target_rules_bbox,priority:3"`
+	MaxLongitude float64 `json:"-" gorm:"index:idx_geo_target_rules_bbox,priority:4"`
+}
+
+// IsRadius reports whether the rule targets a radius around a point rather than a named place
+func (r GeoTargetRule) IsRadius() bool {
+	return r.RadiusKm > 0
+}
+
+// Validate checks that the rule targets something
+func (r GeoTargetRule) Validate() error {
+	if r.RadiusKm < 0 {
+		return errors.New("geo target radius must not be negative")
+	}
+	if r.IsRadius() {
+		if r.Latitude < -90 || r.Latitude > 90 || r.Longitude < -180 || r.Longitude > 180 {
+			return errors.New("geo target coordinates are out of range")
+		}
+		return nil
+	}
+	if r.Country == "" && r.Region == "" && r.City == "" {
+		return errors.New("geo target needs a country, region, city or radius")
+	}
+	return nil
+}
+
+// BeforeSave computes the bounding box of radius rules
+func (r *GeoTargetRule) BeforeSave(tx *gorm.DB) error {
+	if !r.IsRadius() {
+		r.MinLatitude, r.MaxLatitude, r.MinLongitude, r.MaxLongitude = 0, 0, 0, 0
+		return nil
+	}
+	r.MinLatitude, r.MaxLatitude, r.MinLongitude, r.MaxLongitude = boundingBox(r.Latitude, r.Longitude, r.RadiusKm)
+	return nil
+}
+
+// Matches reports whether the rule covers the given location
+func (r GeoTargetRule) Matches(location Location) bool {
+	if r.IsRadius() {
+		if !location.HasCoordinates() {
+			return false
+		}
+		return HaversineKm(r.Latitude, r.Longitude, location.Latitude, location.Longitude) <= r.RadiusKm
+	}
+	if r.Country != "" && !strings.EqualFold(r.Country, location.Country) {
+		return false
+	}
+	if r.Region != "" && !strings.EqualFold(r.Region, location.Region) && !strings.EqualFold(r.Region, location.State) {
+		return false
+	}
+	if r.City != "" && !strings.EqualFold(r.City, location.City) {
+		return false
+	}
+	return true
+}
+
+// GeoTargetsAllow reports whether an advertisement with the given rules may play at a location.
+// Exclude rules win over include rules; an advertisement without include rules may play anywhere else.
+func GeoTargetsAllow(rules []GeoTargetRule, location Location) bool {
+	included, hasIncludes := false, false
+	for _, rule := range rules {
+		if rule.Exclude {
+			if rule.Matches(location) {
+				return false
+			}
+			continue
+		}
+		hasIncludes = true
+		included = included || rule.Matches(location)
+	}
+	return included || !hasIncludes
+}
+
+// ValidateGeoTargets validates every geo target rule of an advertisement
+func ValidateGeoTargets(rules []GeoTargetRule) error {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// geoTargetScope keeps advertisements without include rules, or with an include rule that could match the location.
+// Radius rules are only prefiltered by bounding box here; the exact distance and exclude rules are checked by GeoTargetsAllow.
+func geoTargetScope(location Location) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		untargeted := "NOT EXISTS (SELECT 1 FROM geo_target_rules g WHERE g.advertisement_id = advertisements.id AND g.deleted_at IS NULL AND NOT g.exclude)"
+		placeMatch := "(g.radius_km = 0 AND (g.country = '' OR LOWER(g.country) = ?) AND (g.region = '' OR LOWER(g.region) IN (?, ?)) AND (g.city = '' OR LOWER(g.city) = ?))"
+		if !location.HasCoordinates() {
+			return db.Where(untargeted+" OR EXISTS (SELECT 1 FROM geo_target_rules g WHERE g.advertisement_id = advertisements.id AND g.deleted_at IS NULL AND NOT g.exclude AND "+placeMatch+")",
+				strings.ToLower(location.Country), strings.ToLower(location.Region), strings.ToLower(location.State), strings.ToLower(location.City))
+		}
+		radiusMatch := "(g.radius_km > 0 AND g.min_latitude <= ? AND g.max_latitude >= ? AND g.min_longitude <= ? AND g.max_longitude >= ?)"
+		return db.Where(untargeted+" OR EXISTS (SELECT 1 FROM geo_target_rules g WHERE g.advertisement_id = advertisements.id AND g.deleted_at IS NULL AND NOT g.exclude AND ("+placeMatch+" OR "+radiusMatch+"))",
+			strings.ToLower(location.Country), strings.ToLower(location.Region), strings.ToLower(location.State), strings.ToLower(location.City),
+			location.Latitude, location.Latitude, location.Longitude, location.Longitude)
+	}
+}
+
+// HasCoordinates reports whether the location has a latitude/longitude set
+func (l Location) HasCoordinates() bool {
+	return l.Latitude != 0 || l.Longitude != 0
+}
+
+// HaversineKm returns the great-circle distance between two points in kilometres
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := degreesToRadians(lat2 - lat1)
+	dLon := degreesToRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(degreesToRadians(lat1))*math.Cos(degreesToRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// boundingBox returns the latitude/longitude box enclosing a radius around a point
+func boundingBox(latitude, longitude, radiusKm float64) (minLat, maxLat, minLon, maxLon float64) {
+	deltaLat := radiansToDegrees(radiusKm / earthRadiusKm)
+	minLat = math.Max(-90, latitude-deltaLat)
+	maxLat = math.Min(90, latitude+deltaLat)
+
+	// Longitudes spread furthest at the edge nearest a pole; near the poles or across
+	// the antimeridian the box covers every longitude
+	cosLat := math.Cos(degreesToRadians(math.Max(math.Abs(minLat), math.Abs(maxLat))))
+	if minLat <= -90 || maxLat >= 90 || cosLat <= 0 {
+		return minLat, maxLat, -180, 180
+	}
+	deltaLon := radiansToDegrees(radiusKm / (earthRadiusKm * cosLat))
+	minLon = longitude - deltaLon
+	maxLon = longitude + deltaLon
+	if minLon < -180 || maxLon > 180 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, minLon, maxLon
+}
+
+func degreesToRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func radiansToDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
@@ -0,0 +1,199 @@
+// backend/models/geo_target_test.go
+
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	paris      = Location{Latitude: 48.8566, Longitude: 2.3522, City: "Paris", Region: "Île-de-France", Country: "FR"}
+	lyon       = Location{Latitude: 45.7640, Longitude: 4.8357, City: "Lyon", Region: "Auvergne-Rhône-Alpes", Country: "FR"}
+	london     = Location{Latitude: 51.5074, Longitude: -0.1278, City: "London", Country: "GB"}
+	newYork    = Location{Latitude: 40.7128, Longitude: -74.0060, City: "New York", State: "NY", Country: "US"}
+	losAngeles = Location{Latitude: 34.0522, Longitude: -118.2437, City: "Los Angeles", State: "CA", Country: "US"}
+)
+
+func TestHaversineKm(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to Location
+		want     float64
+	}{
+		{"same point", paris, paris, 0},
+		{"Paris to London", paris, london, 343.5},
+		{"Paris to Lyon", paris, lyon, 391.5},
+		{"New York to Los Angeles", newYork, losAngeles, 3935.7},
+		{"across the antimeridian", Location{Longitude: 179.5}, Location{Longitude: -179.5}, 111.2},
+		{"antipodes", Location{Longitude: 0}, Location{Longitude: 180}, math.Pi * earthRadiusKm},
+		{"pole to pole", Location{Latitude: 90}, Location{Latitude: -90}, math.Pi * earthRadiusKm},
+	}
+	for _, test := range tests {
+		got := HaversineKm(test.from.Latitude, test.from.Longitude, test.to.Latitude, test.to.Longitude)
+		if math.Abs(got-test.want) > 0.5 {
+			t.Errorf("%s: got %.1fkm, want %.1fkm", test.name, got, test.want)
+		}
+		if back := HaversineKm(test.to.Latitude, test.to.Longitude, test.from.Latitude, test.from.Longitude); math.Abs(back-got) > 1e-9 {
+			t.Errorf("%s: distance is not symmetric, %.3fkm back", test.name, back)
+		}
+	}
+}
+
+// destination returns the point radiusKm away from a point along a bearing in degrees
+func destination(latitude, longitude, radiusKm, bearing float64) (float64, float64) {
+	lat, lon := degreesToRadians(latitude), degreesToRadians(longitude)
+	angle, theta := radiusKm/earthRadiusKm, degreesToRadians(bearing)
+	lat2 := math.Asin(math.Sin(lat)*math.Cos(angle) + math.Cos(lat)*math.Sin(angle)*math.Cos(theta))
+	lon2 := lon + math.Atan2(math.Sin(theta)*math.Sin(angle)*math.Cos(lat), math.Cos(angle)-math.Sin(lat)*math.Sin(lat2))
+	// Normalize to [-180, 180)
+	return radiansToDegrees(lat2), math.Mod(radiansToDegrees(lon2)+540, 360) - 180
+}
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name                string
+		latitude, longitude float64
+		radiusKm            float64
+		allLongitudes       bool // Whether the box must span every longitude
+	}{
+		{"mid latitude", paris.Latitude, paris.Longitude, 100, false},
+		{"equator", 0, 30, 500, false},
+		{"southern hemisphere", -33.87, 151.21, 250, false},
+		{"east of the antimeridian", 0, 179.9, 50, true},
+		{"west of the antimeridian", -17.7, -179.8, 100, true},
+		{"near the north pole", 89.9, 0, 50, true},
+		{"near the south pole", -89.5, 120, 100, true},
+		{"at the north pole", 90, 0, 10, true},
+	}
+	for _, test := range tests {
+		minLat, maxLat, minLon, maxLon := boundingBox(test.latitude, test.longitude, test.radiusKm)
+		if minLat < -90 || maxLat > 90 || minLon < -180 || maxLon > 180 {
+			t.Errorf("%s: box %g..%g, %g..%g leaves the globe", test.name, minLat, maxLat, minLon, maxLon)
+		}
+		if spans := minLon == -180 && maxLon == 180; spans != test.allLongitudes {
+			t.Errorf("%s: box spans every longitude = %v, want %v", test.name, spans, test.allLongitudes)
+		}
+		if !test.allLongitudes && maxLon-minLon > 4*radiansToDegrees(test.radiusKm/earthRadiusKm)/math.Cos(degreesToRadians(test.latitude)) {
+			t.Errorf("%s: box %g..%g is far wider than the radius", test.name, minLon, maxLon)
+		}
+		// Every point on the circle, and the centre, lies in the box
+		for bearing := 0.0; bearing < 360; bearing += 5 {
+			lat, lon := destination(test.latitude, test.longitude, test.radiusKm*0.999, bearing)
+			if lat < minLat || lat > maxLat || lon < minLon || lon > maxLon {
+				t.Errorf("%s: point %g,%g at bearing %g is outside the box %g..%g, %g..%g",
+					test.name, lat, lon, bearing, minLat, maxLat, minLon, maxLon)
+				break
+			}
+		}
+	}
+}
+
+func TestGeoTargetsAllow(t *testing.T) {
+	includeFrance := GeoTargetRule{Country: "fr"}
+	includeLondon := GeoTargetRule{Country: "GB", City: "london"}
+	includeNearParis := GeoTargetRule{Latitude: paris.Latitude, Longitude: paris.Longitude, RadiusKm: 50}
+	excludeParis := GeoTargetRule{City: "Paris", Exclude: true}
+	excludeNearParis := GeoTargetRule{Latitude: paris.Latitude, Longitude: paris.Longitude, RadiusKm: 50, Exclude: true}
+	excludeFrance := GeoTargetRule{Country: "FR", Exclude: true}
+
+	tests := []struct {
+		name     string
+		rules    []GeoTargetRule
+		location Location
+		want     bool
+	}{
+		{"no rules", nil, paris, true},
+		{"included country", []GeoTargetRule{includeFrance}, lyon, true},
+		{"other country", []GeoTargetRule{includeFrance}, london, false},
+		{"any include rule", []GeoTargetRule{includeFrance, includeLondon}, london, true},
+		{"city needs its country", []GeoTargetRule{includeLondon}, Location{City: "London", Country: "CA"}, false},
+		{"region matches the state", []GeoTargetRule{{Country: "US", Region: "ny"}}, newYork, true},
+		{"inside the radius", []GeoTargetRule{includeNearParis}, paris, true},
+		{"outside the radius", []GeoTargetRule{includeNearParis}, lyon, false},
+		{"radius without coordinates", []GeoTargetRule{includeNearParis}, Location{Country: "FR"}, false},
+		{"exclusion wins over inclusion", []GeoTargetRule{includeFrance, excludeParis}, paris, false},
+		{"exclusion elsewhere", []GeoTargetRule{includeFrance, excludeParis}, lyon, true},
+		{"excluded radius", []GeoTargetRule{includeFrance, excludeNearParis}, paris, false},
+		{"excluded country over included radius", []GeoTargetRule{includeNearParis, excludeFrance}, paris, false},
+		{"exclusion before inclusion", []GeoTargetRule{excludeParis, includeFrance}, paris, false},
+		{"only exclusions outside", []GeoTargetRule{excludeFrance}, london, true},
+		{"only exclusions inside", []GeoTargetRule{excludeFrance}, lyon, false},
+	}
+	for _, test := range tests {
+		if got := GeoTargetsAllow(test.rules, test.location); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGeoTargetScope(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&Advertisement{}, &GeoTargetRule{}); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+
+	advertisements := map[string][]GeoTargetRule{
+		"untargeted":      nil,
+		"france":          {{Country: "FR"}},
+		"paris":           {{Country: "FR", City: "Paris"}},
+		"near paris":      {{Latitude: paris.Latitude, Longitude: paris.Longitude, RadiusKm: 50}},
+		"near tokyo":      {{Latitude: 35.68, Longitude: 139.69, RadiusKm: 50}},
+		"not france":      {{Country: "FR", Exclude: true}},
+		"us or near lyon": {{Country: "US"}, {Latitude: lyon.Latitude, Longitude: lyon.Longitude, RadiusKm: 30}},
+		"deleted rule":    {{Country: "GB"}},
+	}
+	for title, rules := range advertisements {
+		advertisement := Advertisement{Title: title, GeoTargets: rules}
+		if err := db.Create(&advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+		if title == "deleted rule" {
+			if err := db.Delete(&advertisement.GeoTargets[0]).Error; err != nil {
+				t.Fatalf("deleting rule: %v", err)
+			}
+		}
+	}
+
+	// The scope is a prefilter: bounding boxes may keep a radius a little too wide, never too narrow
+	tests := []struct {
+		name     string
+		location Location
+		want     []string
+	}{
+		{"Paris", paris, []string{"deleted rule", "france", "near paris", "not france", "paris", "untargeted"}},
+		{"Paris by name", Location{City: "paris", Country: "fr"}, []string{"deleted rule", "france", "not france", "paris", "untargeted"}},
+		{"Lyon", lyon, []string{"deleted rule", "france", "not france", "untargeted", "us or near lyon"}},
+		{"New York", newYork, []string{"deleted rule", "not france", "untargeted", "us or near lyon"}},
+		{"nowhere", Location{}, []string{"deleted rule", "not france", "untargeted"}},
+	}
+	for _, test := range tests {
+		var found []Advertisement
+		if err := db.Scopes(geoTargetScope(test.location)).Find(&found).Error; err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		var titles []string
+		for _, advertisement := range found {
+			titles = append(titles, advertisement.Title)
+		}
+		sort.Strings(titles)
+		if fmt.Sprint(titles) != fmt.Sprint(test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, titles, test.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// backend/models/media_probe.go
+
+package models
+
+import "time"
+
+// DurationTolerance is how many seconds a declared duration may differ from the probed one before it is flagged
+const DurationTolerance = 1
+
+// MediaProbe struct for media details read from the file itself rather than entered by hand
+type MediaProbe struct {
+	Duration         int        `json:"duration"` // Seconds, rounded
+	Width            int        `json:"width"`
+	Height           int        `json:"height"`
+	VideoCodec       string     `json:"videoCodec"`
+	AudioCodec       string     `json:"audioCodec"`
+	Bitrate          int64      `json:"bitrate"`        // Bits per second
+	Size             int64      `json:"size"`           // Bytes
+	FragmentOffset   int64      `json:"fragmentOffset"` // Offset of the first movie fragment, 0 unless the file is fragmented MP4
+	ProbedAt         *time.Time `json:"probedAt"`
+	Error            string     `json:"error,omitempty"` // Why the last probe failed
+	DurationMismatch bool       `json:"durationMismatch" gorm:"default:false"`
+}
+
+// Fragmented reports whether the probed file is a fragmented MP4, made of an initialization section
+// followed by movie fragments
+func (mp MediaProbe) Fragmented() bool {
+	return mp.FragmentOffset > 0 && mp.Size > mp.FragmentOffset
+}
+
+// probeColumns returns the column updates that store a probe result. A failed probe only records
+// when and why it failed, keeping the values of the last successful one.
+func probeColumns(probe MediaProbe) map[string]interface{} {
+	if probe.Error != "" {
+		return map[string]interface{}{
+			"probe_probed_at": probe.ProbedAt,
+			"probe_error":     probe.Error,
+		}
+	}
+	return map[string]interface{}{
+		"probe_duration":          probe.Duration,
+		"probe_width":             probe.Width,
+		"probe_height":            probe.Height,
+		"probe_video_codec":       probe.VideoCodec,
+		"probe_audio_codec":       probe.AudioCodec,
+		"probe_bitrate":           probe.Bitrate,
+		"probe_size":              probe.Size,
+		"probe_fragment_offset":   probe.FragmentOffset,
+		"probe_probed_at":         probe.ProbedAt,
+		"probe_error":             probe.Error,
+		"probe_duration_mismatch": probe.DurationMismatch,
+	}
+}
+
+// durationMismatch reports whether a declared duration disagrees with a probed one
+func durationMismatch(declared, probed int) bool {
+	if declared <= 0 || probed <= 0 {
+		return false
+	}
+	difference := declared - probed
+	if difference < 0 {
+		difference = -difference
+	}
+	return difference > DurationTolerance
+}
+
+// RecordProbe stores a probe result for an advertisement. Duration and the quality fields are
+// filled in when they were left empty; a declared duration that disagrees with the file is flagged.
+// A failed probe leaves the previous results in place.
+func (am *AdvertisementModel) RecordProbe(advertisementID uint, probe MediaProbe, videoQuality, audioQuality string) error {
+	var advertisement Advertisement
+	if err := am.DB.Select("id", "duration", "video_quality", "audio_quality").First(&advertisement, advertisementID).Error; err != nil {
+		return err
+	}
+
+	updates := probeColumns(probe)
+	if probe.Error == "" {
+		if advertisement.Duration == 0 && probe.Duration > 0 {
+			updates["duration"] = probe.Duration
+		}
+		if advertisement.VideoQuality == "" && videoQuality != "" {
+			updates["video_quality"] = videoQuality
+		}
+		if advertisement.AudioQuality == "" && audioQuality != "" {
+			updates["audio_quality"] = audioQuality
+		}
+		updates["probe_duration_mismatch"] = durationMismatch(advertisement.Duration, probe.Duration)
+	}
+
+	if err := am.DB.Model(&Advertisement{}).Where("id = ?", advertisementID).Updates(updates).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetAdvertisementsToProbe fetches advertisements with content that have not been probed since the given time
+func (am *AdvertisementModel) GetAdvertisementsToProbe(probedBefore time.Time) ([]Advertisement, error) {
+	var advertisements []Advertisement
+	if err := am.DB.Where("content_url <> '' AND (probe_probed_at IS NULL OR probe_probed_at < ?)", probedBefore).Find(&advertisements).Error; err != nil {
+		return nil, err
+	}
+	return advertisements, nil
+}
+
+// RecordProbe stores a probe result for a video, filling in Duration when it was left empty
+func (vm *VideoModel) RecordProbe(videoID uint, probe MediaProbe) error {
+	var video Video
+	if err := vm.DB.Select("id", "duration").First(&video, videoID).Error; err != nil {
+		return err
+	}
+
+	updates := probeColumns(probe)
+	if probe.Error == "" {
+		if video.Duration == 0 && probe.Duration > 0 {
+			updates["duration"] = probe.Duration
+		}
+		updates["probe_duration_mismatch"] = durationMismatch(video.Duration, probe.Duration)
+	}
+
+	if err := vm.DB.Model(&Video{}).Where("id = ?", videoID).Updates(updates).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetVideosToProbe fetches videos that have not been probed since the given time
+func (vm *VideoModel) GetVideosToProbe(probedBefore time.Time) ([]Video, error) {
+	var videos []Video
+	if err := vm.DB.Where("url <> '' AND (probe_probed_at IS NULL OR probe_probed_at < ?)", probedBefore).Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
@@ -0,0 +1,67 @@
+// backend/models/media_probe_test.go
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestFailedProbeKeepsStoredResults(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "playlist", IsPublic: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	advertisement := models.Advertisement{Title: "advertisement", PlaylistID: playlist.ID, ContentURL: "https://cdn.example.com/ad.mp4", Duration: 20}
+	if err := db.Create(&advertisement).Error; err != nil {
+		t.Fatalf("creating advertisement: %v", err)
+	}
+	video := models.Video{Title: "video", PlaylistID: playlist.ID, URL: "https://cdn.example.com/video.mp4"}
+	if err := db.Create(&video).Error; err != nil {
+		t.Fatalf("creating video: %v", err)
+	}
+
+	probedAt := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	good := models.MediaProbe{Duration: 30, Width: 1920, Height: 1080, VideoCodec: "avc1", AudioCodec: "mp4a",
+		Bitrate: 4000000, Size: 15000000, FragmentOffset: 1200, ProbedAt: &probedAt}
+	failedAt := probedAt.Add(24 * time.Hour)
+	failed := models.MediaProbe{ProbedAt: &failedAt, Error: "media: HEAD returned 503 Service Unavailable"}
+
+	advertisementModel := models.NewAdvertisementModel(db)
+	videoModel := models.NewVideoModel(db)
+	for _, probe := range []models.MediaProbe{good, failed} {
+		if err := advertisementModel.RecordProbe(advertisement.ID, probe, "1080p", "mp4a 48kHz stereo"); err != nil {
+			t.Fatalf("recording advertisement probe: %v", err)
+		}
+		if err := videoModel.RecordProbe(video.ID, probe); err != nil {
+			t.Fatalf("recording video probe: %v", err)
+		}
+	}
+
+	var storedAdvertisement models.Advertisement
+	if err := db.First(&storedAdvertisement, advertisement.ID).Error; err != nil {
+		t.Fatalf("reading advertisement: %v", err)
+	}
+	var storedVideo models.Video
+	if err := db.First(&storedVideo, video.ID).Error; err != nil {
+		t.Fatalf("reading video: %v", err)
+	}
+	for name, probe := range map[string]models.MediaProbe{"advertisement": storedAdvertisement.Probe, "video": storedVideo.Probe} {
+		if probe.ProbedAt == nil || !probe.ProbedAt.Equal(failedAt) || probe.Error != failed.Error {
+			t.Errorf("%s probe failure recorded at %v as %q, want %v and %q", name, probe.ProbedAt, probe.Error, failedAt, failed.Error)
+		}
+		probe.ProbedAt, probe.Error, probe.DurationMismatch = good.ProbedAt, "", false
+		if probe != good {
+			t.Errorf("%s probe results are %+v after a failure, want %+v", name, probe, good)
+		}
+	}
+	if !storedAdvertisement.Probe.DurationMismatch {
+		t.Error("advertisement lost its duration mismatch flag after a failure")
+	}
+	if storedVideo.Duration != 30 {
+		t.Errorf("video duration is %d, want 30 from the successful probe", storedVideo.Duration)
+	}
+}
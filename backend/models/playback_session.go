@@ -0,0 +1,187 @@
+// backend/models/playback_session.go
+
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tracking event names recorded in AdvertisementTrackingEvent
+const (
+	TrackingEventImpression = "impression"
+)
+
+// ErrAdvertisementGone is returned for an inserted advertisement that was deleted or lost its content since the session began
+var ErrAdvertisementGone = errors.New("advertisement is no longer available")
+
+// PlaybackSession is one viewer's server-side stitched stream of a playlist.
+// The advertisements chosen for the session are fixed when it is created.
+type PlaybackSession struct {
+	ID         string               `json:"id" gorm:"primaryKey;size:32"`
+	PlaylistID uint                 `json:"playlistID" gorm:"index"`
+	ViewerID   string               `json:"viewerID"`
+	Location   Location             `json:"location" gorm:"embedded"`
+	Insertions []SessionAdInsertion `json:"insertions" gorm:"foreignKey:SessionID"`
+	CreatedAt  time.Time            `json:"createdAt"`
+}
+
+// SessionAdInsertion is an advertisement spliced into a session's stream at an ad break
+type SessionAdInsertion struct {
+	ID              uint          `json:"id" gorm:"primaryKey"`
+	SessionID       string        `json:"-" gorm:"index;size:32"`
+	AdvertisementID uint          `json:"advertisementID"`
+	Advertisement   Advertisement `json:"advertisement"`
+	BreakPosition   int           `json:"breakPosition"` // Index of the video the break plays before
+	Sequence        int           `json:"sequence"`      // Position within the break, starting at 1
+	ImpressionAt    *time.Time    `json:"impressionAt"`  // When the ad segment was first requested
+}
+
+// Playable reports whether the inserted advertisement still exists and has content to play
+func (i SessionAdInsertion) Playable() bool {
+	return i.Advertisement.ID != 0 && i.Advertisement.ContentURL != ""
+}
+
+// AdvertisementTrackingEvent records a tracking beacon fired for an advertisement
+type AdvertisementTrackingEvent struct {
+	ID              uint      `gorm:"primaryKey"`
+	AdvertisementID uint      `gorm:"index"`
+	PlaylistID      uint      `gorm:"index"`
+	SessionID       string    `gorm:"index;size:32"`
+	Event           string    `gorm:"size:32"`
+	OccurredAt      time.Time `gorm:"index"`
+}
+
+// SessionModel handles database operations for PlaybackSession
+type SessionModel struct {
+	DB    *gorm.DB
+	Clock Clock
+}
+
+// NewSessionModel creates a new instance of SessionModel
+func NewSessionModel(db *gorm.DB) *SessionModel {
+	return &SessionModel{
+		DB:    db,
+		Clock: SystemClock{},
+	}
+}
+
+// CreateSession stores a new session with the advertisements planned for its breaks
+func (sm *SessionModel) CreateSession(playlistID uint, viewerID string, location Location, breaks []AdBreak) (*PlaybackSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	session := PlaybackSession{
+		ID:         id,
+		PlaylistID: playlistID,
+		ViewerID:   viewerID,
+		Location:   location,
+		CreatedAt:  sm.Clock.Now(),
+	}
+	for _, adBreak := range breaks {
+		for i, advertisement := range adBreak.Pod.Advertisements {
+			session.Insertions = append(session.Insertions, SessionAdInsertion{
+				AdvertisementID: advertisement.ID,
+				BreakPosition:   adBreak.Position,
+				Sequence:        i + 1,
+			})
+		}
+	}
+
+	if err := sm.DB.Omit("Insertions.Advertisement").Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetSession fetches a session with its insertions and their advertisements
+func (sm *SessionModel) GetSession(sessionID string) (*PlaybackSession, error) {
+	var session PlaybackSession
+	if err := sm.DB.Preload("Insertions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("break_position, sequence")
+	}).Preload("Insertions.Advertisement").First(&session, "id = ?", sessionID).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Breaks rebuilds the session's ad breaks from its playable insertions, dropping advertisements deleted
+// or emptied since the session began and the breaks left without any
+func (s PlaybackSession) Breaks() []AdBreak {
+	breaks := []AdBreak{}
+	for _, insertion := range s.Insertions {
+		if !insertion.Playable() {
+			continue
+		}
+		if len(breaks) == 0 || breaks[len(breaks)-1].Position != insertion.BreakPosition {
+			breaks = append(breaks, AdBreak{Position: insertion.BreakPosition, Pod: AdPod{Advertisements: []Advertisement{}}})
+		}
+		pod := &breaks[len(breaks)-1].Pod
+		pod.Advertisements = append(pod.Advertisements, insertion.Advertisement)
+		pod.Duration += insertion.Advertisement.Duration
+	}
+	return breaks
+}
+
+// RecordImpression fires the server-side beacon for an inserted advertisement. Only the first request
+// of a segment counts, so players retrying or seeking back do not inflate impressions. Advertisements
+// that can no longer be played return ErrAdvertisementGone and record nothing.
+func (sm *SessionModel) RecordImpression(session *PlaybackSession, insertionID uint) (*SessionAdInsertion, error) {
+	var insertion SessionAdInsertion
+	err := sm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Advertisement").Where("session_id = ?", session.ID).First(&insertion, insertionID).Error; err != nil {
+			return err
+		}
+		if !insertion.Playable() {
+			return ErrAdvertisementGone
+		}
+		if insertion.ImpressionAt != nil {
+			return nil
+		}
+
+		now := sm.Clock.Now()
+		result := tx.Model(&SessionAdInsertion{}).Where("id = ? AND impression_at IS NULL", insertion.ID).Update("impression_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// A concurrent request recorded it first
+			return nil
+		}
+		insertion.ImpressionAt = &now
+
+		if err := tx.Create(&AdvertisementTrackingEvent{
+			AdvertisementID: insertion.AdvertisementID,
+			PlaylistID:      session.PlaylistID,
+			SessionID:       session.ID,
+			Event:           TrackingEventImpression,
+			OccurredAt:      now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&AdvertisementPlayEvent{
+			AdvertisementID: insertion.AdvertisementID,
+			PlaylistID:      session.PlaylistID,
+			PlayTime:        now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &insertion, nil
+}
+
+// newSessionID returns a random, unguessable session identifier
+func newSessionID() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
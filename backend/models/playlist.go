@@ -18,15 +18,19 @@ type Playlist struct {
 	Description                  string            `json:"description"`
 	Videos                       []Video           `gorm:"foreignKey:PlaylistID"`
 	ChannelID                    uint              `json:"-"`
+	OwnerID                      uint              `json:"ownerId" gorm:"index"` // User who created the playlist
 	Channel                      Channel           `json:"channel"`
 	IsFeatured                   bool              `json:"isFeatured" gorm:"default:false"`
 	IsPublic                     bool              `json:"isPublic" gorm:"default:true"`
 	FeaturedArtwork              string            `json:"featuredArtwork"`
-	Tags                         []string          `json:"tags" gorm:"type:varchar(255)[]"`
+	Tags                         []string          `json:"tags" gorm:"serializer:json"`
+	Language                     string            `json:"language"`
 	IsPlayable                   bool              `json:"isPlayable" gorm:"default:true"`
 	PlayCount                    uint              `json:"playCount" gorm:"default:0"`
+	LegacyPlayCount              uint              `json:"-" gorm:"default:0"` // Plays counted before the watch history, kept by RollUpWatchHistory
 	LikeCount                    uint              `json:"likeCount" gorm:"default:0"`
 	DislikeCount                 uint              `json:"dislikeCount" gorm:"default:0"`
+	FollowerCount                uint              `json:"followerCount" gorm:"default:0"`
 	Comments                     []Comment         `gorm:"foreignKey:PlaylistID"`
 	ShareCount                   uint              `json:"shareCount" gorm:"default:0"`
 	Advertisements               []Advertisement   `json:"advertisements" gorm:"foreignKey:PlaylistID"`
@@ -34,9 +38,13 @@ type Playlist struct {
 	Contributors                 []User            `gorm:"many2many:user_playlist_contributors;"`
 	RelatedPlaylists             []RelatedPlaylist `json:"relatedPlaylists" gorm:"foreignKey:PlaylistID"`
 	TotalDuration                int               `json:"totalDuration" gorm:"default:0"`
+	AdBreakInterval              int               `json:"adBreakInterval" gorm:"default:0"` // Seconds of content between breaks, 0 uses DefaultAdBreakInterval
+	AdBreakDuration              int               `json:"adBreakDuration" gorm:"default:0"` // Seconds, 0 uses DefaultAdBreakDuration
+	MaxAdsPerBreak               int               `json:"maxAdsPerBreak" gorm:"default:0"`  // 0 uses DefaultMaxAdsPerBreak
 	LastModified                 int               `json:"lastModified" gorm:"autoUpdateTime"`
 	LastAdvertisementScheduledAt time.Time         `json:"lastAdvertisementScheduledAt" gorm:"default:null"`
 	PrivacySetting               PrivacySetting    `json:"privacySetting" gorm:"embedded"`
+	Access                       AccessSetting     `json:"access" gorm:"embedded"`
 	Location                     Location          `json:"location" gorm:"embedded"`
 }
 
@@ -47,18 +55,22 @@ type UserPlaylistFollowers struct {
 	PlaylistID uint
 }
 
-// UserPlaylistContributors model for many-to-many relationship between users and playlists
+// UserPlaylistContributors is the join table of Playlist.Contributors: users who accepted an invitation to a playlist
 type UserPlaylistContributors struct {
-	gorm.Model
-	UserID     uint
-	PlaylistID uint
+	PlaylistID  uint      `json:"playlistId" gorm:"primaryKey"`
+	UserID      uint      `json:"userId" gorm:"primaryKey"`
+	InvitedByID uint      `json:"invitedById"`
+	CreatedAt   time.Time `json:"joinedAt"`
 }
 
-// RelatedPlaylist model for representing related playlists
+// RelatedPlaylist model for representing related playlists, computed by RefreshRelatedContent or pinned by hand
 type RelatedPlaylist struct {
 	gorm.Model
-	PlaylistID        uint
-	RelatedPlaylistID uint
+	PlaylistID        uint    `json:"-" gorm:"index"`
+	RelatedPlaylistID uint    `json:"relatedPlaylistId"`
+	Score             float64 `json:"score"`
+	Pinned            bool    `json:"pinned" gorm:"default:false"`
+	Order             int     `json:"order" gorm:"default:0"` // Position among the pinned entries
 }
 
 // PrivacySetting model for defining playlist privacy settings
@@ -80,6 +92,23 @@ func NewPlaylistModel(db *gorm.DB) *PlaylistModel {
 	}
 }
 
+// GetAllPlaylists fetches all playlists
+func (pm *PlaylistModel) GetAllPlaylists() ([]Playlist, error) {
+	var playlists []Playlist
+	if err := pm.DB.Find(&playlists).Error; err != nil {
+		return nil, err
+	}
+	return playlists, nil
+}
+
+// CreatePlaylist creates a new playlist along with its videos
+func (pm *PlaylistModel) CreatePlaylist(playlist *Playlist) error {
+	if err := pm.DB.Create(playlist).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
 // UpdateLastScheduledTime updates the last scheduled time for a playlist
 func (pm *PlaylistModel) UpdateLastScheduledTime(playlistID uint, lastScheduledTime time.Time) error {
 	var playlist Playlist
@@ -95,156 +124,95 @@ func (pm *PlaylistModel) UpdateLastScheduledTime(playlistID uint, lastScheduledT
 	return nil
 }
 
-// GetPlaylistsForAdvertisements fetches playlists that have associated advertisements
-func (pm *PlaylistModel) GetPlaylistsForAdvertisements() ([]Playlist, error) {
-	var playlists []Playlist
-	if err := pm.DB.Preload("Advertisements").Find(&playlists).Error; err != nil {
-		log.Printf("Error fetching playlists with advertisements: %v", err)
-		return nil, errors.New("failed to fetch playlists")
-	}
-
-	if len(playlists) == 0 {
-		return nil, errors.New("no playlists found with advertisements")
-	}
-
-	// Perform additional processing or filtering if needed
-	// For example, you can filter out playlists without active advertisements
-	// You can also sort playlists based on criteria such as popularity or freshness
-
-	filteredPlaylists := make([]Playlist, 0)
-	for _, p := range playlists {
-		if hasActiveAdvertisements(&p) && isFreshPlaylist(&p) && hasHighPopularity(&p) {
-			filteredPlaylists = append(filteredPlaylists, p)
+// GetPlaylistForSelection fetches a playlist with the channel details advertisement selection needs
+func (pm *PlaylistModel) GetPlaylistForSelection(playlistID uint) (*Playlist, error) {
+	var playlist Playlist
+	if err := pm.DB.Preload("Channel.Categories").First(&playlist, playlistID).Error; err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// GetFeedLastModified returns when the content of a playlist's feed last changed: the playlist itself,
+// or any of its videos and advertisements being updated or deleted
+func (pm *PlaylistModel) GetFeedLastModified(playlist Playlist) (time.Time, error) {
+	lastModified := time.Unix(int64(playlist.LastModified), 0).UTC()
+	for _, model := range []interface{}{&Video{}, &Advertisement{}} {
+		for _, column := range []string{"updated_at", "deleted_at"} {
+			var latest []time.Time
+			if err := pm.DB.Unscoped().Model(model).Where("playlist_id = ? AND "+column+" IS NOT NULL", playlist.ID).
+				Order(column+" DESC").Limit(1).Pluck(column, &latest).Error; err != nil {
+				return time.Time{}, err
+			}
+			if len(latest) > 0 && latest[0].After(lastModified) {
+				lastModified = latest[0].UTC()
+			}
 		}
 	}
-
-	if len(filteredPlaylists) == 0 {
-		return nil, errors.New("no playlists found with active advertisements, are fresh, and have high popularity")
-	}
-
-	// Sort playlists based on a custom ranking algorithm or popularity criteria
-	sort.Slice(filteredPlaylists, func(i, j int) bool {
-		return calculateTotalViews(&filteredPlaylists[i]) > calculateTotalViews(&filteredPlaylists[j])
-	})
-
-	return filteredPlaylists, nil
+	return lastModified, nil
 }
 
-// GetPlaylistsForAdvertisements fetches playlists that have associated advertisements
-func (pm *PlaylistModel) GetPlaylistsForAdvertisementsFreshness() ([]Playlist, error) {
-	var playlists []Playlist
-	if err := pm.DB.Preload("Advertisements").Find(&playlists).Error; err != nil {
-		log.Printf("Error fetching playlists with advertisements: %v", err)
+// GetPlaylistWithComments fetches a playlist along with its visible top-level comments
+func (pm *PlaylistModel) GetPlaylistWithComments(playlistID uint) (*Playlist, error) {
+	var playlist Playlist
+	if err := pm.DB.Scopes(WithComments).First(&playlist, playlistID).Error; err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// GetPlaylistsForAdvertisements fetches the playlists eligible for advertisements at the given time,
+// using each channel's playlist selector. Filtering and ranking run in SQL, with one query per distinct
+// channel configuration. Playlists are grouped by channel ID and ranked within their channel.
+// It returns an empty slice, not an error, when no playlist is eligible.
+func (pm *PlaylistModel) GetPlaylistsForAdvertisements(now time.Time) ([]Playlist, error) {
+	var configurations []Channel
+	if err := pm.DB.Table("channels").
+		Select("DISTINCT COALESCE(playlist_filters, '') AS playlist_filters, COALESCE(playlist_ranking, '') AS playlist_ranking").
+		Where("deleted_at IS NULL").Scan(&configurations).Error; err != nil {
+		log.Printf("Error fetching channel playlist selectors: %v", err)
 		return nil, errors.New("failed to fetch playlists")
 	}
 
-	if len(playlists) == 0 {
-		return nil, errors.New("no playlists found with advertisements")
+	// Playlists without a channel use the default configuration, so always query it
+	hasDefault := false
+	for _, configuration := range configurations {
+		hasDefault = hasDefault || (configuration.PlaylistFilters == "" && configuration.PlaylistRanking == "")
 	}
-
-	// Perform additional processing or filtering if needed
-	// For example, you can filter out playlists without active advertisements
-	// You can also sort playlists based on criteria such as popularity or freshness
-
-	filteredPlaylists := make([]Playlist, 0)
-	for _, p := range playlists {
-		if hasActiveAdvertisements(&p) && isFreshPlaylist(&p) {
-			filteredPlaylists = append(filteredPlaylists, p)
-		}
+	if !hasDefault {
+		configurations = append(configurations, Channel{})
 	}
 
-	if len(filteredPlaylists) == 0 {
-		return nil, errors.New("no playlists found with active advertisements and are fresh")
-	}
-
-	// Sort playlists based on freshness or popularity
-	sortPlaylistsByFreshness(filteredPlaylists)
-
-	return filteredPlaylists, nil
-}
-
-/* ][][][][][][][][][][][][][][][][][][][][][][][][][][[][][]][][][][][][][][][][][*/
-func (pm *PlaylistModel) GetPlaylistsForAdvertisementsByPopularity() ([]Playlist, error) {
-	var playlists []Playlist
-	if err := pm.DB.Preload("Advertisements").Find(&playlists).Error; err != nil {
-		log.Printf("Error fetching playlists with advertisements: %v", err)
-		return nil, errors.New("failed to fetch playlists")
-	}
-
-	if len(playlists) == 0 {
-		return nil, errors.New("no playlists found with advertisements")
-	}
-
-	// Perform additional processing or filtering if needed
-	// For example, you can filter out playlists without active advertisements
-	// You can also sort playlists based on criteria such as popularity or freshness
-
-	filteredPlaylists := make([]Playlist, 0)
-	for _, p := range playlists {
-		if hasActiveAdvertisements(&p) && isFreshPlaylist(&p) && hasHighPopularity(&p) {
-			filteredPlaylists = append(filteredPlaylists, p)
+	eligible := make([]Playlist, 0)
+	for _, configuration := range configurations {
+		selector, err := configuration.PlaylistSelector()
+		if err != nil {
+			log.Printf("Using the default playlist selector for channels configured with %q and %q: %v",
+				configuration.PlaylistFilters, configuration.PlaylistRanking, err)
+			selector = DefaultPlaylistSelector()
 		}
-	}
-
-	if len(filteredPlaylists) == 0 {
-		return nil, errors.New("no playlists found with active advertisements, are fresh, and have high popularity")
-	}
 
-	// Sort playlists based on a custom ranking algorithm or popularity criteria
-	sortPlaylistsByPopularity(filteredPlaylists)
-
-	return filteredPlaylists, nil
-}
-
-// hasActiveAdvertisements checks if a playlist has active advertisements
-func hasActiveAdvertisements(playlist *Playlist) bool {
-	for _, ad := range playlist.Advertisements {
-		// You can customize the criteria for active advertisements based on your requirements
-		if !ad.Played {
-			return true
+		channels := "SELECT id FROM channels WHERE COALESCE(playlist_filters, '') = ? AND COALESCE(playlist_ranking, '') = ? AND deleted_at IS NULL"
+		query := pm.DB.Model(&Playlist{})
+		if configuration.PlaylistFilters == "" && configuration.PlaylistRanking == "" {
+			query = query.Where("playlists.channel_id IN ("+channels+") OR playlists.channel_id NOT IN (SELECT id FROM channels WHERE deleted_at IS NULL)",
+				configuration.PlaylistFilters, configuration.PlaylistRanking)
+		} else {
+			query = query.Where("playlists.channel_id IN ("+channels+")", configuration.PlaylistFilters, configuration.PlaylistRanking)
 		}
-	}
-	return false
-}
-
-// isFreshPlaylist checks if a playlist is considered fresh (e.g., created within the last 7 days)
-func isFreshPlaylist(playlist *Playlist) bool {
-	const freshnessThreshold = 7 // Number of days considered as fresh
-
-	return time.Since(playlist.CreatedAt).Hours()/24 <= freshnessThreshold
-}
-
-// hasHighPopularity checks if a playlist has high popularity based on custom criteria
-func hasHighPopularity(playlist *Playlist) bool {
-	// You can implement custom criteria to determine playlist popularity
-	// For simplicity, this example considers a playlist with more than 100 views as popular
-	return calculateTotalViews(playlist) > 100
-}
 
-// calculateTotalViews calculates the total number of views for all advertisements in a playlist
-func calculateTotalViews(playlist *Playlist) int {
-	totalViews := 0
-	for _, ad := range playlist.Advertisements {
-		totalViews += int(ad.Analytics.Views)
+		var playlists []Playlist
+		if err := selector.Query(query.Order("playlists.channel_id"), now).Find(&playlists).Error; err != nil {
+			log.Printf("Error fetching playlists with advertisements: %v", err)
+			return nil, errors.New("failed to fetch playlists")
+		}
+		eligible = append(eligible, playlists...)
 	}
-	return totalViews
-}
 
-// sortPlaylistsByFreshness sorts playlists based on freshness or popularity
-func sortPlaylistsByFreshness(playlists []Playlist) {
-	// You can implement custom sorting logic here
-	// For example, sorting by the number of active advertisements or other criteria
-	// For simplicity, this example sorts by the playlist's creation time (freshness)
-	sort.Slice(playlists, func(i, j int) bool {
-		return playlists[i].CreatedAt.After(playlists[j].CreatedAt)
+	// Each configuration's playlists are already grouped by channel, so merging keeps the channel rankings
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return eligible[i].ChannelID < eligible[j].ChannelID
 	})
-}
 
-// sortPlaylistsByPopularity sorts playlists based on a custom ranking algorithm or popularity criteria
-func sortPlaylistsByPopularity(playlists []Playlist) {
-	// You can implement a custom sorting algorithm based on popularity or other criteria
-	// For simplicity, this example sorts playlists based on the total number of views
-	sort.Slice(playlists, func(i, j int) bool {
-		return calculateTotalViews(&playlists[i]) > calculateTotalViews(&playlists[j])
-	})
+	return eligible, nil
 }
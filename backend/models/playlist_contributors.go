@@ -0,0 +1,151 @@
+// backend/models/playlist_contributors.go
+
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrAlreadyContributor is returned when inviting a user who already contributes to the playlist
+	ErrAlreadyContributor = errors.New("user already contributes to the playlist")
+	// ErrInvalidContributor is returned when inviting a user who does not exist or owns the playlist
+	ErrInvalidContributor = errors.New("user cannot be invited to the playlist")
+)
+
+// PlaylistInvitation is a pending invitation for a user to contribute to a playlist
+type PlaylistInvitation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PlaylistID  uint      `json:"playlistId" gorm:"uniqueIndex:idx_playlist_invitations_invitee,priority:1"`
+	Playlist    *Playlist `json:"playlist,omitempty"`
+	UserID      uint      `json:"userId" gorm:"uniqueIndex:idx_playlist_invitations_invitee,priority:2;index"`
+	User        *User     `json:"user,omitempty"`
+	InvitedByID uint      `json:"invitedById"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PlaylistContributors lists the contributors of a playlist and the users invited to join them
+type PlaylistContributors struct {
+	Contributors []User               `json:"contributors"`
+	Invitations  []PlaylistInvitation `json:"invitations,omitempty"`
+}
+
+// ContributorModel handles playlist contributors and their invitations
+type ContributorModel struct {
+	DB          *gorm.DB
+	AccessModel *AccessModel
+}
+
+// NewContributorModel creates a new instance of ContributorModel
+func NewContributorModel(db *gorm.DB) *ContributorModel {
+	return &ContributorModel{
+		DB:          db,
+		AccessModel: NewAccessModel(db),
+	}
+}
+
+// InviteContributor invites a user to contribute to a playlist. Inviting a user again returns
+// the pending invitation.
+func (cm *ContributorModel) InviteContributor(playlistID, userID, invitedByID uint) (*PlaylistInvitation, error) {
+	if err := cm.DB.Select("id").First(&User{}, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidContributor
+		}
+		return nil, err
+	}
+	if err := cm.AccessModel.CanManage(ContentPlaylist, playlistID, userID); err == nil {
+		return nil, ErrInvalidContributor
+	} else if !errors.Is(err, ErrNotEditor) {
+		return nil, err
+	}
+
+	invitation := PlaylistInvitation{PlaylistID: playlistID, UserID: userID, InvitedByID: invitedByID}
+	err := cm.DB.Transaction(func(tx *gorm.DB) error {
+		var contributors int64
+		if err := tx.Model(&UserPlaylistContributors{}).Where("playlist_id = ? AND user_id = ?", playlistID, userID).
+			Count(&contributors).Error; err != nil {
+			return err
+		}
+		if contributors > 0 {
+			return ErrAlreadyContributor
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&invitation).Error; err != nil {
+			return err
+		}
+		return tx.Where("playlist_id = ? AND user_id = ?", playlistID, userID).First(&invitation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// AcceptInvitation makes an invited user a contributor of the playlist
+func (cm *ContributorModel) AcceptInvitation(playlistID, userID uint) (*UserPlaylistContributors, error) {
+	var contributor UserPlaylistContributors
+	err := cm.DB.Transaction(func(tx *gorm.DB) error {
+		var invitation PlaylistInvitation
+		if err := tx.Where("playlist_id = ? AND user_id = ?", playlistID, userID).First(&invitation).Error; err != nil {
+			return err
+		}
+		contributor = UserPlaylistContributors{PlaylistID: playlistID, UserID: userID, InvitedByID: invitation.InvitedByID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&contributor).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&invitation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &contributor, nil
+}
+
+// RemoveContributor removes a contributor from a playlist, or withdraws their pending invitation
+func (cm *ContributorModel) RemoveContributor(playlistID, userID uint) error {
+	return cm.DB.Transaction(func(tx *gorm.DB) error {
+		contributors := tx.Where("playlist_id = ? AND user_id = ?", playlistID, userID).Delete(&UserPlaylistContributors{})
+		if contributors.Error != nil {
+			return contributors.Error
+		}
+		invitations := tx.Where("playlist_id = ? AND user_id = ?", playlistID, userID).Delete(&PlaylistInvitation{})
+		if invitations.Error != nil {
+			return invitations.Error
+		}
+		if contributors.RowsAffected == 0 && invitations.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetContributors fetches the contributors of a playlist, along with the pending invitations when requested
+func (cm *ContributorModel) GetContributors(playlistID uint, withInvitations bool) (*PlaylistContributors, error) {
+	result := PlaylistContributors{Contributors: []User{}}
+	if err := cm.DB.Joins("JOIN user_playlist_contributors ON user_playlist_contributors.user_id = users.id").
+		Where("user_playlist_contributors.playlist_id = ?", playlistID).
+		Order("user_playlist_contributors.created_at, users.id").Find(&result.Contributors).Error; err != nil {
+		return nil, err
+	}
+	if withInvitations {
+		result.Invitations = []PlaylistInvitation{}
+		if err := cm.DB.Preload("User").Where("playlist_id = ?", playlistID).Order("created_at, id").
+			Find(&result.Invitations).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &result, nil
+}
+
+// GetInvitations fetches the pending invitations of a user, newest first
+func (cm *ContributorModel) GetInvitations(userID uint) ([]PlaylistInvitation, error) {
+	invitations := []PlaylistInvitation{}
+	if err := cm.DB.Preload("Playlist").Where("user_id = ?", userID).Order("created_at DESC, id DESC").
+		Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
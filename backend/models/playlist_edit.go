@@ -0,0 +1,298 @@
+// backend/models/playlist_edit.go
+
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Actions recorded in the edit log of a playlist
+const (
+	PlaylistEditAdd     = "add"
+	PlaylistEditRemove  = "remove"
+	PlaylistEditReorder = "reorder"
+)
+
+var (
+	// ErrInvalidOrder is returned when reordering with a list that is not exactly the videos of the playlist
+	ErrInvalidOrder = errors.New("order must list every video of the playlist once")
+	// ErrAlreadyRolledBack is returned when rolling back an edit twice
+	ErrAlreadyRolledBack = errors.New("edit was already rolled back")
+	// ErrEditNotRevertible is returned when later changes keep an edit from being rolled back
+	ErrEditNotRevertible = errors.New("edit can no longer be rolled back")
+)
+
+// PlaylistEdit is one change to the videos of a playlist, kept so owners can review and roll back changes
+type PlaylistEdit struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	PlaylistID   uint       `json:"playlistId" gorm:"index"`
+	UserID       uint       `json:"userId"`
+	Action       string     `json:"action" gorm:"size:16"`
+	VideoID      uint       `json:"videoId,omitempty"`                       // Video added or removed
+	Before       []uint     `json:"before,omitempty" gorm:"serializer:json"` // Video IDs in playback order before a reorder
+	After        []uint     `json:"after,omitempty" gorm:"serializer:json"`  // Video IDs in playback order after a reorder
+	RollbackOfID uint       `json:"rollbackOfId,omitempty"`                  // Edit this one rolled back, 0 otherwise
+	RolledBackAt *time.Time `json:"rolledBackAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// PlaylistEditModel changes the videos of playlists and keeps their edit log
+type PlaylistEditModel struct {
+	DB    *gorm.DB
+	Clock Clock
+}
+
+// NewPlaylistEditModel creates a new instance of PlaylistEditModel
+func NewPlaylistEditModel(db *gorm.DB) *PlaylistEditModel {
+	return &PlaylistEditModel{
+		DB:    db,
+		Clock: SystemClock{},
+	}
+}
+
+// playlistVideoIDs returns the IDs of the videos of a playlist in playback order
+func playlistVideoIDs(tx *gorm.DB, playlistID uint) ([]uint, error) {
+	var ids []uint
+	if err := tx.Model(&Video{}).Where("playlist_id = ?", playlistID).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "order"}}).Order("id").
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// setVideoOrder numbers the videos of a playlist in the given order
+func setVideoOrder(tx *gorm.DB, playlistID uint, ids []uint) error {
+	for i, id := range ids {
+		if err := tx.Model(&Video{}).Where("id = ? AND playlist_id = ?", id, playlistID).
+			UpdateColumn("order", i).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addDuration adjusts the total duration of a playlist when a video joins or leaves it
+func addDuration(tx *gorm.DB, playlistID uint, seconds int) error {
+	if seconds == 0 {
+		return nil
+	}
+	return tx.Model(&Playlist{}).Where("id = ?", playlistID).
+		UpdateColumn("total_duration", gorm.Expr("total_duration + ?", seconds)).Error
+}
+
+// AddVideo adds a new video at the end of a playlist
+func (pm *PlaylistEditModel) AddVideo(playlistID, userID uint, video *Video) error {
+	return pm.DB.Transaction(func(tx *gorm.DB) error {
+		var playlist Playlist
+		if err := tx.Select("id", "channel_id").First(&playlist, playlistID).Error; err != nil {
+			return err
+		}
+		ids, err := playlistVideoIDs(tx, playlistID)
+		if err != nil {
+			return err
+		}
+
+		video.ID = 0
+		video.PlaylistID = playlistID
+		video.ChannelID = playlist.ChannelID
+		video.UploaderID = userID
+		video.Order = len(ids)
+		if err := tx.Omit(clause.Associations).Create(video).Error; err != nil {
+			return err
+		}
+		if err := addDuration(tx, playlistID, video.Duration); err != nil {
+			return err
+		}
+		return tx.Create(&PlaylistEdit{PlaylistID: playlistID, UserID: userID, Action: PlaylistEditAdd, VideoID: video.ID}).Error
+	})
+}
+
+// RemoveVideo removes a video from a playlist. The video is soft deleted so the removal can be rolled back.
+func (pm *PlaylistEditModel) RemoveVideo(playlistID, videoID, userID uint) error {
+	return pm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := removeVideo(tx, playlistID, videoID); err != nil {
+			return err
+		}
+		return tx.Create(&PlaylistEdit{PlaylistID: playlistID, UserID: userID, Action: PlaylistEditRemove, VideoID: videoID}).Error
+	})
+}
+
+// removeVideo soft deletes a video of a playlist
+func removeVideo(tx *gorm.DB, playlistID, videoID uint) error {
+	var video Video
+	if err := tx.Where("playlist_id = ?", playlistID).First(&video, videoID).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&video).Error; err != nil {
+		return err
+	}
+	return addDuration(tx, playlistID, -video.Duration)
+}
+
+// restoreVideo brings back a soft deleted video of a playlist at its end, as its old position may be taken
+func restoreVideo(tx *gorm.DB, playlistID, videoID uint) error {
+	var video Video
+	if err := tx.Unscoped().Where("playlist_id = ? AND deleted_at IS NOT NULL", playlistID).First(&video, videoID).Error; err != nil {
+		return err
+	}
+	ids, err := playlistVideoIDs(tx, playlistID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&video).UpdateColumns(map[string]interface{}{"deleted_at": nil, "order": len(ids)}).Error; err != nil {
+		return err
+	}
+	if err := indexSearch(tx, ContentVideo, video.ID); err != nil {
+		return err
+	}
+	return addDuration(tx, playlistID, video.Duration)
+}
+
+// ReorderVideos sets the playback order of the videos of a playlist, given every video ID in the new order
+func (pm *PlaylistEditModel) ReorderVideos(playlistID, userID uint, videoIDs []uint) error {
+	return pm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&Playlist{}, playlistID).Error; err != nil {
+			return err
+		}
+		before, err := playlistVideoIDs(tx, playlistID)
+		if err != nil {
+			return err
+		}
+		if !samePlaylistVideos(before, videoIDs) {
+			return ErrInvalidOrder
+		}
+		if err := setVideoOrder(tx, playlistID, videoIDs); err != nil {
+			return err
+		}
+		return tx.Create(&PlaylistEdit{
+			PlaylistID: playlistID,
+			UserID:     userID,
+			Action:     PlaylistEditReorder,
+			Before:     before,
+			After:      videoIDs,
+		}).Error
+	})
+}
+
+// samePlaylistVideos reports whether an order lists exactly the videos of a playlist, each once
+func samePlaylistVideos(current, order []uint) bool {
+	if len(current) != len(order) {
+		return false
+	}
+	remaining := make(map[uint]bool, len(current))
+	for _, id := range current {
+		remaining[id] = true
+	}
+	for _, id := range order {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
+
+// restoredOrder returns the current videos in a previous order. Videos missing from the previous order
+// keep their current relative order after the others.
+func restoredOrder(current, previous []uint) []uint {
+	present := make(map[uint]bool, len(current))
+	for _, id := range current {
+		present[id] = true
+	}
+	order := make([]uint, 0, len(current))
+	placed := make(map[uint]bool, len(current))
+	for _, id := range previous {
+		if present[id] && !placed[id] {
+			order = append(order, id)
+			placed[id] = true
+		}
+	}
+	for _, id := range current {
+		if !placed[id] {
+			order = append(order, id)
+		}
+	}
+	return order
+}
+
+// GetEdits fetches a page of the edit log of a playlist, newest first
+func (pm *PlaylistEditModel) GetEdits(playlistID uint, offset, limit int) ([]PlaylistEdit, int64, error) {
+	query := pm.DB.Model(&PlaylistEdit{}).Where("playlist_id = ?", playlistID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	edits := []PlaylistEdit{}
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&edits).Error; err != nil {
+		return nil, 0, err
+	}
+	return edits, total, nil
+}
+
+// RollBackEdit undoes an edit of a playlist and records the undoing as a new edit: an added video is removed,
+// a removed video comes back at the end and a reorder restores the previous order of the videos still in the playlist
+func (pm *PlaylistEditModel) RollBackEdit(playlistID, editID, userID uint) (*PlaylistEdit, error) {
+	var rollback PlaylistEdit
+	err := pm.DB.Transaction(func(tx *gorm.DB) error {
+		var edit PlaylistEdit
+		if err := tx.Where("playlist_id = ?", playlistID).First(&edit, editID).Error; err != nil {
+			return err
+		}
+		if edit.RolledBackAt != nil {
+			return ErrAlreadyRolledBack
+		}
+
+		rollback = PlaylistEdit{PlaylistID: playlistID, UserID: userID, VideoID: edit.VideoID, RollbackOfID: edit.ID}
+		switch edit.Action {
+		case PlaylistEditAdd:
+			rollback.Action = PlaylistEditRemove
+			if err := removeVideo(tx, playlistID, edit.VideoID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrEditNotRevertible
+				}
+				return err
+			}
+		case PlaylistEditRemove:
+			rollback.Action = PlaylistEditAdd
+			if err := restoreVideo(tx, playlistID, edit.VideoID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrEditNotRevertible
+				}
+				return err
+			}
+		case PlaylistEditReorder:
+			rollback.Action = PlaylistEditReorder
+			current, err := playlistVideoIDs(tx, playlistID)
+			if err != nil {
+				return err
+			}
+			rollback.Before = current
+			rollback.After = restoredOrder(current, edit.Before)
+			if err := setVideoOrder(tx, playlistID, rollback.After); err != nil {
+				return err
+			}
+		default:
+			return ErrEditNotRevertible
+		}
+
+		// Only one of concurrent rollbacks of the edit goes through
+		result := tx.Model(&edit).Where("rolled_back_at IS NULL").UpdateColumn("rolled_back_at", pm.Clock.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyRolledBack
+		}
+		return tx.Create(&rollback).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rollback, nil
+}
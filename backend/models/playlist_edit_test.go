@@ -0,0 +1,140 @@
+// backend/models/playlist_edit_test.go
+
+package models_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// playlistState returns the videos of a playlist in playback order and its total duration
+func playlistState(tb testing.TB, db *gorm.DB, playlistID uint) (string, int) {
+	tb.Helper()
+	var ids []uint
+	if err := db.Model(&models.Video{}).Where("playlist_id = ?", playlistID).Order(`"order", id`).Pluck("id", &ids).Error; err != nil {
+		tb.Fatalf("reading videos: %v", err)
+	}
+	var playlist models.Playlist
+	if err := db.First(&playlist, playlistID).Error; err != nil {
+		tb.Fatalf("reading playlist: %v", err)
+	}
+	return fmt.Sprint(ids), playlist.TotalDuration
+}
+
+func TestPlaylistEditLogAndRollback(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "playlist", OwnerID: 7, IsPublic: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	editModel := models.NewPlaylistEditModel(db)
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	editModel.Clock = models.ClockFunc(func() time.Time { return now })
+
+	videos := make([]models.Video, 3)
+	for i := range videos {
+		videos[i] = models.Video{Title: fmt.Sprintf("video %d", i+1), URL: "https://cdn.example.com/video.mp4", Duration: 10 * (i + 1)}
+		if err := editModel.AddVideo(playlist.ID, 8, &videos[i]); err != nil {
+			t.Fatalf("AddVideo: %v", err)
+		}
+	}
+	first, second, third := videos[0].ID, videos[1].ID, videos[2].ID
+	if order, duration := playlistState(t, db, playlist.ID); order != fmt.Sprint([]uint{first, second, third}) || duration != 60 {
+		t.Errorf("after adding, videos are %s lasting %ds, want %d %d %d lasting 60s", order, duration, first, second, third)
+	}
+	if videos[1].UploaderID != 8 || videos[1].Order != 1 {
+		t.Errorf("added video uploaded by %d at %d, want by 8 at 1", videos[1].UploaderID, videos[1].Order)
+	}
+
+	if err := editModel.ReorderVideos(playlist.ID, 8, []uint{third, first}); !errors.Is(err, models.ErrInvalidOrder) {
+		t.Errorf("reordering without every video got %v, want ErrInvalidOrder", err)
+	}
+	if err := editModel.ReorderVideos(playlist.ID, 8, []uint{third, first, first}); !errors.Is(err, models.ErrInvalidOrder) {
+		t.Errorf("reordering with a repeated video got %v, want ErrInvalidOrder", err)
+	}
+	if err := editModel.ReorderVideos(playlist.ID, 8, []uint{third, first, second}); err != nil {
+		t.Fatalf("ReorderVideos: %v", err)
+	}
+	if err := editModel.RemoveVideo(playlist.ID, first, 9); err != nil {
+		t.Fatalf("RemoveVideo: %v", err)
+	}
+	if order, duration := playlistState(t, db, playlist.ID); order != fmt.Sprint([]uint{third, second}) || duration != 50 {
+		t.Errorf("after reordering and removing, videos are %s lasting %ds, want %d %d lasting 50s", order, duration, third, second)
+	}
+
+	edits, total, err := editModel.GetEdits(playlist.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("GetEdits: %v", err)
+	}
+	if total != 5 || len(edits) != 5 {
+		t.Fatalf("got %d of %d edits, want 5", len(edits), total)
+	}
+	removal, reorder, added := edits[0], edits[1], edits[2]
+	if removal.Action != models.PlaylistEditRemove || removal.VideoID != first || removal.UserID != 9 {
+		t.Errorf("newest edit is %+v, want user 9 removing video %d", removal, first)
+	}
+	if reorder.Action != models.PlaylistEditReorder || fmt.Sprint(reorder.Before) != fmt.Sprint([]uint{first, second, third}) ||
+		fmt.Sprint(reorder.After) != fmt.Sprint([]uint{third, first, second}) {
+		t.Errorf("reorder edit is %+v, want the order before and after", reorder)
+	}
+	if added.Action != models.PlaylistEditAdd || added.VideoID != third {
+		t.Errorf("third newest edit is %+v, want adding video %d", added, third)
+	}
+
+	// Rolling back the reorder restores the previous order of the videos still in the playlist
+	rollback, err := editModel.RollBackEdit(playlist.ID, reorder.ID, 7)
+	if err != nil {
+		t.Fatalf("RollBackEdit of the reorder: %v", err)
+	}
+	if rollback.RollbackOfID != reorder.ID || rollback.Action != models.PlaylistEditReorder || rollback.UserID != 7 {
+		t.Errorf("rollback recorded as %+v, want a reorder by 7 rolling back %d", rollback, reorder.ID)
+	}
+	if order, _ := playlistState(t, db, playlist.ID); order != fmt.Sprint([]uint{second, third}) {
+		t.Errorf("after rolling back the reorder, videos are %s, want %d %d", order, second, third)
+	}
+	if _, err := editModel.RollBackEdit(playlist.ID, reorder.ID, 7); !errors.Is(err, models.ErrAlreadyRolledBack) {
+		t.Errorf("rolling back the reorder twice got %v, want ErrAlreadyRolledBack", err)
+	}
+
+	// Rolling back the removal brings the video back, after the others
+	if _, err := editModel.RollBackEdit(playlist.ID, removal.ID, 7); err != nil {
+		t.Fatalf("RollBackEdit of the removal: %v", err)
+	}
+	if order, duration := playlistState(t, db, playlist.ID); order != fmt.Sprint([]uint{second, third, first}) || duration != 60 {
+		t.Errorf("after restoring, videos are %s lasting %ds, want %d %d %d lasting 60s", order, duration, second, third, first)
+	}
+
+	// Rolling back an addition removes the video, unless it was already removed
+	if _, err := editModel.RollBackEdit(playlist.ID, added.ID, 7); err != nil {
+		t.Fatalf("RollBackEdit of the addition: %v", err)
+	}
+	if order, duration := playlistState(t, db, playlist.ID); order != fmt.Sprint([]uint{second, first}) || duration != 30 {
+		t.Errorf("after rolling back the addition, videos are %s lasting %ds, want %d %d lasting 30s", order, duration, second, first)
+	}
+	if err := editModel.RemoveVideo(playlist.ID, second, 7); err != nil {
+		t.Fatalf("RemoveVideo: %v", err)
+	}
+	if _, err := editModel.RollBackEdit(playlist.ID, edits[3].ID, 7); !errors.Is(err, models.ErrEditNotRevertible) {
+		t.Errorf("rolling back the addition of a removed video got %v, want ErrEditNotRevertible", err)
+	}
+	if _, err := editModel.RollBackEdit(playlist.ID+1, edits[4].ID, 7); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("rolling back an edit of another playlist got %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	var rolledBack models.PlaylistEdit
+	if err := db.First(&rolledBack, added.ID).Error; err != nil {
+		t.Fatalf("reading edit: %v", err)
+	}
+	if rolledBack.RolledBackAt == nil || !rolledBack.RolledBackAt.Equal(now) {
+		t.Errorf("rolled back edit has rolledBackAt %v, want %v", rolledBack.RolledBackAt, now)
+	}
+	if _, total, _ := editModel.GetEdits(playlist.ID, 0, 1); total != 9 {
+		t.Errorf("edit log has %d edits, want 9 with the rollbacks", total)
+	}
+}
@@ -0,0 +1,191 @@
+// backend/models/playlist_selector.go
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// FreshPlaylistAge is how long after creation a playlist counts as fresh
+	FreshPlaylistAge = 7 * 24 * time.Hour
+	// RoundRobinPeriod is how long each playlist stays first in round-robin ranking, one scheduler pass
+	RoundRobinPeriod = 5 * time.Minute
+
+	// DefaultPlaylistFilters are the filters used by channels that do not configure their own
+	DefaultPlaylistFilters = "active,fresh,popular"
+	// DefaultPlaylistRanking is the ranking used by channels that do not configure their own
+	DefaultPlaylistRanking = "popularity"
+)
+
+// PlaylistFilter restricts a playlist query to the playlists eligible for advertisements
+type PlaylistFilter interface {
+	Filter(query *gorm.DB, now time.Time) *gorm.DB
+}
+
+// PlaylistFilterFunc adapts a function to a PlaylistFilter
+type PlaylistFilterFunc func(query *gorm.DB, now time.Time) *gorm.DB
+
+// Filter calls f
+func (f PlaylistFilterFunc) Filter(query *gorm.DB, now time.Time) *gorm.DB {
+	return f(query, now)
+}
+
+// PlaylistRanker adds an ordering to a playlist query. Rankers are applied in priority order,
+// so each one only breaks the ties of those before it.
+type PlaylistRanker interface {
+	Rank(query *gorm.DB, now time.Time) *gorm.DB
+}
+
+// PlaylistRankerFunc adapts a function to a PlaylistRanker
+type PlaylistRankerFunc func(query *gorm.DB, now time.Time) *gorm.DB
+
+// Rank calls f
+func (f PlaylistRankerFunc) Rank(query *gorm.DB, now time.Time) *gorm.DB {
+	return f(query, now)
+}
+
+// playlistFilters are the filters channels can configure by name
+var playlistFilters = map[string]PlaylistFilter{
+	"active":   PlaylistFilterFunc(hasActiveAdvertisements),
+	"fresh":    PlaylistFilterFunc(isFreshPlaylist),
+	"popular":  PlaylistFilterFunc(hasHighPopularity),
+	"playable": PlaylistFilterFunc(isPlayablePlaylist),
+}
+
+// playlistRankers are the rankers channels can configure by name
+var playlistRankers = map[string]PlaylistRanker{
+	"freshness":                PlaylistRankerFunc(sortPlaylistsByFreshness),
+	"popularity":               PlaylistRankerFunc(sortPlaylistsByPopularity),
+	"round-robin":              PlaylistRankerFunc(rotatePlaylists),
+	"least-recently-scheduled": PlaylistRankerFunc(sortPlaylistsByLastScheduled),
+}
+
+// PlaylistSelector picks and orders the playlists that advertisements are scheduled for
+type PlaylistSelector struct {
+	Filters []PlaylistFilter // A playlist must pass every filter
+	Rankers []PlaylistRanker // The first ranker decides the order, later ones break its ties
+}
+
+// DefaultPlaylistSelector returns the selector used by channels without their own configuration
+func DefaultPlaylistSelector() PlaylistSelector {
+	selector, _ := ParsePlaylistSelector(DefaultPlaylistFilters, DefaultPlaylistRanking)
+	return selector
+}
+
+// ParsePlaylistSelector builds a selector from comma-separated filter and ranker names,
+// e.g. "active,fresh" and "least-recently-scheduled,popularity"
+func ParsePlaylistSelector(filters, ranking string) (PlaylistSelector, error) {
+	var selector PlaylistSelector
+	for _, name := range splitNames(filters) {
+		filter, ok := playlistFilters[name]
+		if !ok {
+			return PlaylistSelector{}, fmt.Errorf("unknown playlist filter %q", name)
+		}
+		selector.Filters = append(selector.Filters, filter)
+	}
+	for _, name := range splitNames(ranking) {
+		ranker, ok := playlistRankers[name]
+		if !ok {
+			return PlaylistSelector{}, fmt.Errorf("unknown playlist ranking %q", name)
+		}
+		selector.Rankers = append(selector.Rankers, ranker)
+	}
+	return selector, nil
+}
+
+// splitNames splits a comma-separated list of names, ignoring blanks and case
+func splitNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Query applies the selector's filters and rankers to a playlist query
+func (ps PlaylistSelector) Query(query *gorm.DB, now time.Time) *gorm.DB {
+	for _, filter := range ps.Filters {
+		query = filter.Filter(query, now)
+	}
+	for _, ranker := range ps.Rankers {
+		query = ranker.Rank(query, now)
+	}
+	return query
+}
+
+// PlaylistSelector returns the channel's configured playlist selector, or the default one if it has none
+func (c Channel) PlaylistSelector() (PlaylistSelector, error) {
+	filters, ranking := c.PlaylistFilters, c.PlaylistRanking
+	if filters == "" {
+		filters = DefaultPlaylistFilters
+	}
+	if ranking == "" {
+		ranking = DefaultPlaylistRanking
+	}
+	return ParsePlaylistSelector(filters, ranking)
+}
+
+// playlistScoreSQL is the materialized popularity score of the playlist in the outer query, 0 without stats
+const playlistScoreSQL = "COALESCE((SELECT playlist_stats.score FROM playlist_stats WHERE playlist_stats.playlist_id = playlists.id), 0)"
+
+// hasActiveAdvertisements keeps playlists with an unplayed, unpaused advertisement that is due.
+// The subquery is served by the advertisements eligibility index.
+func hasActiveAdvertisements(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("EXISTS (SELECT 1 FROM advertisements WHERE advertisements.playlist_id = playlists.id "+
+		"AND advertisements.played = ? AND advertisements.scheduled_at <= ? "+
+		"AND advertisements.is_paused = ? AND advertisements.deleted_at IS NULL)", false, now, false)
+}
+
+// isFreshPlaylist keeps playlists created within FreshPlaylistAge
+func isFreshPlaylist(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("playlists.created_at >= ?", now.Add(-FreshPlaylistAge))
+}
+
+// hasHighPopularity keeps playlists with a popularity score above PopularPlaylistScore. Fresh playlists nobody
+// has viewed yet are kept too, since they could never be scheduled, and so viewed, otherwise.
+func hasHighPopularity(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("("+playlistScoreSQL+" > ? OR (playlists.created_at >= ? AND "+playlistScoreSQL+" = 0))",
+		PopularPlaylistScore, now.Add(-FreshPlaylistAge))
+}
+
+// isPlayablePlaylist keeps playlists marked as playable
+func isPlayablePlaylist(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("playlists.is_playable = ?", true)
+}
+
+// sortPlaylistsByFreshness sorts the newest playlists first
+func sortPlaylistsByFreshness(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Order("playlists.created_at DESC")
+}
+
+// sortPlaylistsByPopularity sorts the playlists with the highest popularity score first
+func sortPlaylistsByPopularity(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Order(playlistScoreSQL + " DESC")
+}
+
+// sortPlaylistsByLastScheduled sorts the playlists that had advertisements scheduled longest ago first.
+// Never scheduled playlists have a NULL time, which sorts first in ascending order.
+func sortPlaylistsByLastScheduled(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Order("playlists.last_advertisement_scheduled_at")
+}
+
+// rotatePlaylists orders each channel's playlists by ID, rotated by one every RoundRobinPeriod
+// so each playlist takes its turn first. The rotation is computed over the filtered rows with window functions.
+func rotatePlaylists(query *gorm.DB, now time.Time) *gorm.DB {
+	const position = "(ROW_NUMBER() OVER (PARTITION BY playlists.channel_id ORDER BY playlists.id) - 1)"
+	const count = "COUNT(*) OVER (PARTITION BY playlists.channel_id)"
+
+	slot := now.UnixNano() / int64(RoundRobinPeriod)
+	if slot < 0 {
+		slot = -slot
+	}
+	// The slot is formatted into the clause since gorm drops other orderings when given an order expression
+	return query.Order(fmt.Sprintf("(%s + %s - %d %% %s) %% %s", position, count, slot, count, count))
+}
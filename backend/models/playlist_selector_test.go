@@ -0,0 +1,209 @@
+// backend/models/playlist_selector_test.go
+
+package models_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// seedPlaylists inserts playlists spread over ten channels, each with one advertisement. Half the playlists
+// are fresh, half of the advertisements are still to play and a third of the playlists are popular.
+func seedPlaylists(tb testing.TB, db *gorm.DB, count int, now time.Time) {
+	tb.Helper()
+	statements := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{`WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < 10)
+			INSERT INTO channels (id, name, created_at, updated_at) SELECT n, 'channel ' || n, ?, ? FROM seq`,
+			[]interface{}{now, now}},
+		{`WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < ?)
+			INSERT INTO playlists (id, title, channel_id, is_public, is_playable, created_at, updated_at)
+			SELECT n, 'playlist ' || n, n % 10 + 1, 1, 1, CASE WHEN n % 2 = 0 THEN ? ELSE ? END, ? FROM seq`,
+			[]interface{}{count, now.Add(-time.Hour), now.Add(-30 * 24 * time.Hour), now}},
+		{`INSERT INTO advertisements (playlist_id, title, played, is_paused, is_public, scheduled_at, created_at, updated_at)
+			SELECT id, 'ad ' || id, CASE WHEN id % 4 < 2 THEN 0 ELSE 1 END, 0, 1, ?, ?, ? FROM playlists`,
+			[]interface{}{now.Add(-time.Minute), now, now}},
+		{`INSERT INTO playlist_stats (playlist_id, score, updated_at)
+			SELECT id, CASE WHEN id % 3 = 0 THEN 50 + id % 7 ELSE 1 END, ? FROM playlists`,
+			[]interface{}{now}},
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement.sql, statement.args...).Error; err != nil {
+			tb.Fatalf("seeding playlists: %v", err)
+		}
+	}
+}
+
+// preloadPlaylistsForAdvertisements is the eligibility check GetPlaylistsForAdvertisements replaced: it loads
+// every playlist with its channel and advertisements and applies the default selector in Go. The playlists
+// are loaded in batches, since preloading 100k of them at once exceeds the SQLite variable limit.
+func preloadPlaylistsForAdvertisements(db *gorm.DB, now time.Time) ([]models.Playlist, error) {
+	var playlists []models.Playlist
+	var batch []models.Playlist
+	if err := db.Preload("Channel").Preload("Advertisements").Order("channel_id, id").
+		FindInBatches(&batch, 10000, func(tx *gorm.DB, _ int) error {
+			playlists = append(playlists, batch...)
+			return nil
+		}).Error; err != nil {
+		return nil, err
+	}
+	var stats []models.PlaylistStats
+	if err := db.Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	scores := make(map[uint]float64, len(stats))
+	for _, stat := range stats {
+		scores[stat.PlaylistID] = stat.Score
+	}
+
+	eligible := make([]models.Playlist, 0)
+	for _, playlist := range playlists {
+		active := false
+		for _, ad := range playlist.Advertisements {
+			active = active || (!ad.Played && !ad.IsPaused && !ad.ScheduledAt.After(now))
+		}
+		fresh := !playlist.CreatedAt.Before(now.Add(-models.FreshPlaylistAge))
+		popular := scores[playlist.ID] > models.PopularPlaylistScore || (fresh && scores[playlist.ID] == 0)
+		if active && fresh && popular {
+			eligible = append(eligible, playlist)
+		}
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		if eligible[i].ChannelID != eligible[j].ChannelID {
+			return eligible[i].ChannelID < eligible[j].ChannelID
+		}
+		return scores[eligible[i].ID] > scores[eligible[j].ID]
+	})
+	return eligible, nil
+}
+
+// playlistIDs returns the IDs of playlists, sorted
+func playlistIDs(playlists []models.Playlist) []uint {
+	ids := make([]uint, len(playlists))
+	for i, playlist := range playlists {
+		ids[i] = playlist.ID
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestGetPlaylistsForAdvertisementsMatchesPreload(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	seedPlaylists(t, db, 1200, now)
+
+	got, err := models.NewPlaylistModel(db).GetPlaylistsForAdvertisements(now)
+	if err != nil {
+		t.Fatalf("GetPlaylistsForAdvertisements: %v", err)
+	}
+	want, err := preloadPlaylistsForAdvertisements(db, now)
+	if err != nil {
+		t.Fatalf("preloading playlists: %v", err)
+	}
+	if len(want) == 0 {
+		t.Fatal("seed has no eligible playlist")
+	}
+
+	gotIDs, wantIDs := playlistIDs(got), playlistIDs(want)
+	if len(gotIDs) != len(wantIDs) {
+		t.Fatalf("got %d eligible playlists, want %d", len(gotIDs), len(wantIDs))
+	}
+	for i := range gotIDs {
+		if gotIDs[i] != wantIDs[i] {
+			t.Fatalf("eligible playlist %d is %d, want %d", i, gotIDs[i], wantIDs[i])
+		}
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].ChannelID < got[i-1].ChannelID {
+			t.Fatalf("playlist %d of channel %d comes after channel %d", got[i].ID, got[i].ChannelID, got[i-1].ChannelID)
+		}
+	}
+}
+
+// BenchmarkGetPlaylistsForAdvertisements compares the SQL eligibility query with the preload path it
+// replaced, on 100k playlists: go test -run '^$' -bench GetPlaylistsForAdvertisements ./models
+func BenchmarkGetPlaylistsForAdvertisements(b *testing.B) {
+	db := newTestDB(b)
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	seedPlaylists(b, db, 100000, now)
+
+	b.Run("sql", func(b *testing.B) {
+		playlistModel := models.NewPlaylistModel(db)
+		for i := 0; i < b.N; i++ {
+			if _, err := playlistModel.GetPlaylistsForAdvertisements(now); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("preload", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := preloadPlaylistsForAdvertisements(db, now); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDefaultSelectorSchedulesNewPlaylists(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		title    string
+		age      time.Duration
+		stats    bool // Whether the playlist has materialized stats
+		score    float64
+		eligible bool
+	}{
+		{"new", time.Hour, false, 0, true},
+		{"new and only scheduled", time.Hour, true, 0, true},
+		{"new and barely watched", time.Hour, true, 2, false},
+		{"new and popular", time.Hour, true, 20, true},
+		{"old", 30 * 24 * time.Hour, false, 0, false},
+	}
+	want := map[uint]string{}
+	for _, test := range tests {
+		playlist := models.Playlist{Title: test.title, IsPublic: true, IsPlayable: true}
+		playlist.CreatedAt = now.Add(-test.age)
+		if err := db.Create(&playlist).Error; err != nil {
+			t.Fatalf("creating playlist: %v", err)
+		}
+		advertisement := models.Advertisement{PlaylistID: playlist.ID, Title: "advertisement", Duration: 30, IsPublic: true, ScheduledAt: now.Add(-time.Minute)}
+		if err := db.Create(&advertisement).Error; err != nil {
+			t.Fatalf("creating advertisement: %v", err)
+		}
+		if test.stats {
+			if err := db.Create(&models.PlaylistStats{PlaylistID: playlist.ID, Score: test.score, UpdatedAt: now}).Error; err != nil {
+				t.Fatalf("creating stats: %v", err)
+			}
+		}
+		if test.eligible {
+			want[playlist.ID] = test.title
+		}
+	}
+
+	eligible, err := models.NewPlaylistModel(db).GetPlaylistsForAdvertisements(now)
+	if err != nil {
+		t.Fatalf("GetPlaylistsForAdvertisements: %v", err)
+	}
+	got := map[uint]string{}
+	for _, playlist := range eligible {
+		got[playlist.ID] = playlist.Title
+	}
+	for id, title := range want {
+		if _, ok := got[id]; !ok {
+			t.Errorf("playlist %q is not eligible", title)
+		}
+	}
+	for id, title := range got {
+		if _, ok := want[id]; !ok {
+			t.Errorf("playlist %q is eligible", title)
+		}
+	}
+}
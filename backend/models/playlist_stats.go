@@ -0,0 +1,151 @@
+// backend/models/playlist_stats.go
+
+package models
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// ShortPopularityHalfLife is the half-life of the short-term decayed counts
+	ShortPopularityHalfLife = 24 * time.Hour
+	// LongPopularityHalfLife is the half-life of the long-term decayed counts
+	LongPopularityHalfLife = 7 * 24 * time.Hour
+	// PopularityWindow is how far back events are read, four long half-lives
+	PopularityWindow = 4 * LongPopularityHalfLife
+	// PopularPlaylistScore is the score above which a playlist counts as popular,
+	// roughly three and a half impressions or watches a day at a steady rate
+	PopularPlaylistScore = 10
+)
+
+// popularityEvent is a kind of event counted in the playlist stats
+type popularityEvent int
+
+const (
+	impressionEvent popularityEvent = iota // A viewer's player reported an advertisement impression
+	watchEvent                             // A viewer watched a video of the playlist long enough to count as a view
+	playEvent                              // The scheduler played an advertisement
+)
+
+// PlaylistStats holds the time-decayed popularity of a playlist, materialized by RefreshPlaylistStats.
+// Each event counts 1 when it happens and half as much after every half-life. The score only weighs
+// real viewing: the scheduler's own plays are kept apart, so its choices do not make themselves popular.
+type PlaylistStats struct {
+	PlaylistID uint      `json:"playlistId" gorm:"primaryKey;autoIncrement:false"`
+	Views24h   float64   `json:"views24h"`   // Impressions decayed with ShortPopularityHalfLife
+	Watches24h float64   `json:"watches24h"` // Watched videos decayed with ShortPopularityHalfLife
+	Plays24h   float64   `json:"plays24h"`   // Scheduled advertisement plays decayed with ShortPopularityHalfLife, not scored
+	Views7d    float64   `json:"views7d"`    // Impressions decayed with LongPopularityHalfLife
+	Watches7d  float64   `json:"watches7d"`  // Watched videos decayed with LongPopularityHalfLife
+	Plays7d    float64   `json:"plays7d"`    // Scheduled advertisement plays decayed with LongPopularityHalfLife, not scored
+	Score      float64   `json:"score" gorm:"index"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// TableName keeps the table name singular, as the struct is a set of statistics per playlist
+func (PlaylistStats) TableName() string {
+	return "playlist_stats"
+}
+
+// add counts one event that happened age ago
+func (ps *PlaylistStats) add(age time.Duration, event popularityEvent) {
+	short := decay(age, ShortPopularityHalfLife)
+	long := decay(age, LongPopularityHalfLife)
+	switch event {
+	case impressionEvent:
+		ps.Views24h += short
+		ps.Views7d += long
+	case watchEvent:
+		ps.Watches24h += short
+		ps.Watches7d += long
+	default:
+		ps.Plays24h += short
+		ps.Plays7d += long
+	}
+}
+
+// score combines the decayed counts of impressions and watches. At a steady rate the short and long
+// terms weigh the same, since the long counts are divided by the ratio of the half-lives.
+func (ps *PlaylistStats) score() float64 {
+	ratio := float64(LongPopularityHalfLife) / float64(ShortPopularityHalfLife)
+	return ps.Views24h + ps.Watches24h + (ps.Views7d+ps.Watches7d)/ratio
+}
+
+// decay returns the weight of an event that happened age ago
+func decay(age, halfLife time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// countEvents reads the playlist ID and time of every row of a query and counts them as events
+func countEvents(query *gorm.DB, event popularityEvent, count func(uint, time.Time, popularityEvent)) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var playlistID uint
+		var at time.Time
+		if err := rows.Scan(&playlistID, &at); err != nil {
+			return err
+		}
+		count(playlistID, at, event)
+	}
+	return rows.Err()
+}
+
+// RefreshPlaylistStats recomputes the stats of every playlist from the impressions, watch history and
+// scheduled plays of the last PopularityWindow and replaces the playlist_stats table with them.
+// A watch history entry counts once, when it was last updated, if its current watch counted as a view.
+func (pm *PlaylistModel) RefreshPlaylistStats(now time.Time) error {
+	since := now.Add(-PopularityWindow)
+	stats := map[uint]*PlaylistStats{}
+	count := func(playlistID uint, at time.Time, event popularityEvent) {
+		entry, ok := stats[playlistID]
+		if !ok {
+			entry = &PlaylistStats{PlaylistID: playlistID, UpdatedAt: now}
+			stats[playlistID] = entry
+		}
+		entry.add(now.Sub(at), event)
+	}
+
+	impressions := pm.DB.Model(&AdvertisementTrackingEvent{}).Select("playlist_id, occurred_at").
+		Where("event = ? AND occurred_at >= ? AND occurred_at <= ? AND playlist_id <> 0", TrackingEventImpression, since, now)
+	if err := countEvents(impressions, impressionEvent, count); err != nil {
+		return err
+	}
+	watches := pm.DB.Model(&WatchHistoryEntry{}).Select("videos.playlist_id, watch_history.updated_at").
+		Joins("JOIN videos ON videos.id = watch_history.video_id AND videos.deleted_at IS NULL").
+		Where("watch_history.view_counted = ? AND watch_history.updated_at >= ? AND watch_history.updated_at <= ? AND videos.playlist_id <> 0",
+			true, since, now)
+	if err := countEvents(watches, watchEvent, count); err != nil {
+		return err
+	}
+	plays := pm.DB.Model(&AdvertisementPlayEvent{}).Select("playlist_id, play_time").
+		Where("play_time >= ? AND play_time <= ? AND playlist_id <> 0", since, now)
+	if err := countEvents(plays, playEvent, count); err != nil {
+		return err
+	}
+
+	rows := make([]PlaylistStats, 0, len(stats))
+	for _, entry := range stats {
+		entry.Score = entry.score()
+		rows = append(rows, *entry)
+	}
+
+	return pm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&PlaylistStats{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
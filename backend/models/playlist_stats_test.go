@@ -0,0 +1,77 @@
+// backend/models/playlist_stats_test.go
+
+package models_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestRefreshPlaylistStatsScoresRealViewing(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	// The scheduler played ads on the first playlist, viewers saw ads and watched videos on the second
+	var scheduled, watched models.Playlist
+	for _, playlist := range []*models.Playlist{&scheduled, &watched} {
+		playlist.Title, playlist.IsPublic = "playlist", true
+		if err := db.Create(playlist).Error; err != nil {
+			t.Fatalf("creating playlist: %v", err)
+		}
+	}
+	for i := 0; i < 50; i++ {
+		if err := db.Create(&models.AdvertisementPlayEvent{PlaylistID: scheduled.ID, AdvertisementID: 1, PlayTime: now}).Error; err != nil {
+			t.Fatalf("creating play event: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := db.Create(&models.AdvertisementTrackingEvent{PlaylistID: watched.ID, AdvertisementID: 2,
+			Event: models.TrackingEventImpression, OccurredAt: now}).Error; err != nil {
+			t.Fatalf("creating tracking event: %v", err)
+		}
+	}
+	video := models.Video{Title: "video", PlaylistID: watched.ID}
+	if err := db.Create(&video).Error; err != nil {
+		t.Fatalf("creating video: %v", err)
+	}
+	// Two viewers watched the video, a third only started it
+	for userID, counted := range []bool{true, true, false} {
+		entry := models.WatchHistoryEntry{UserID: uint(userID + 1), VideoID: video.ID, ViewCounted: counted, UpdatedAt: now.Add(-models.ShortPopularityHalfLife)}
+		if err := db.Create(&entry).Error; err != nil {
+			t.Fatalf("creating watch history: %v", err)
+		}
+	}
+
+	if err := models.NewPlaylistModel(db).RefreshPlaylistStats(now); err != nil {
+		t.Fatalf("RefreshPlaylistStats: %v", err)
+	}
+	var stats []models.PlaylistStats
+	if err := db.Order("playlist_id").Find(&stats).Error; err != nil {
+		t.Fatalf("reading stats: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("got stats for %d playlists, want 2", len(stats))
+	}
+
+	ratio := float64(models.LongPopularityHalfLife) / float64(models.ShortPopularityHalfLife)
+	longWatch := math.Pow(0.5, 1/ratio)
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"scheduled plays", stats[0].Plays24h, 50},
+		{"scheduled score", stats[0].Score, 0},
+		{"impressions", stats[1].Views24h, 3},
+		{"watches a half-life ago", stats[1].Watches24h, 1},
+		{"long term watches", stats[1].Watches7d, 2 * longWatch},
+		{"viewing score", stats[1].Score, 3 + 1 + (3+2*longWatch)/ratio},
+	}
+	for _, test := range tests {
+		if math.Abs(test.got-test.want) > 1e-9 {
+			t.Errorf("%s is %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
@@ -0,0 +1,503 @@
+// backend/models/related.go
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxRelatedItems is the number of computed related entries kept per video, playlist or advertisement
+	MaxRelatedItems = 20
+	// RelatedWindow is how far back watch history and play events count as co-viewing
+	RelatedWindow = 90 * 24 * time.Hour
+	// CoViewingWeight is the weight of shared viewers, or shared playlists for advertisements
+	CoViewingWeight = 0.6
+	// TagWeight is the weight of shared tags
+	TagWeight = 0.25
+	// CategoryWeight is the weight of shared categories
+	CategoryWeight = 0.15
+	// MaxSignalFanout skips viewers, tags and categories shared by more items than this. They say little
+	// about relatedness and would make the computation quadratic.
+	MaxSignalFanout = 1000
+)
+
+// ErrInvalidRelated is returned when pinning an item as related to itself
+var ErrInvalidRelated = errors.New("content cannot be related to itself")
+
+// relatedTables describes where the related entries of a content type are stored
+type relatedTables struct {
+	Table      string // Related entries table
+	Key        string // Column referencing the content
+	RelatedKey string // Column referencing the related content
+}
+
+// relatedContent maps content types to their related entries tables
+var relatedContent = map[string]relatedTables{
+	ContentVideo:         {Table: "related_videos", Key: "video_id", RelatedKey: "related_video_id"},
+	ContentPlaylist:      {Table: "related_playlists", Key: "playlist_id", RelatedKey: "related_playlist_id"},
+	ContentAdvertisement: {Table: "related_ads", Key: "advertisement_id", RelatedKey: "related_advertisement_id"},
+}
+
+// relatedCandidate holds the signals the relatedness of one item is computed from
+type relatedCandidate struct {
+	Audience   map[string]bool // Users who watched it, or playlists an advertisement was played in
+	Tags       map[string]bool
+	Categories map[string]bool
+}
+
+// relatedSignal is one component of the relatedness score
+type relatedSignal struct {
+	Weight     float64
+	Values     func(*relatedCandidate) map[string]bool
+	Similarity func(shared, a, b int) float64
+}
+
+// relatedSignals are the components of the relatedness score. Co-viewing uses cosine similarity
+// so that popular items are not related to everything, tags and categories use Jaccard similarity.
+var relatedSignals = []relatedSignal{
+	{Weight: CoViewingWeight, Values: func(c *relatedCandidate) map[string]bool { return c.Audience }, Similarity: cosineSimilarity},
+	{Weight: TagWeight, Values: func(c *relatedCandidate) map[string]bool { return c.Tags }, Similarity: jaccardSimilarity},
+	{Weight: CategoryWeight, Values: func(c *relatedCandidate) map[string]bool { return c.Categories }, Similarity: jaccardSimilarity},
+}
+
+// scoredRelated is a related item and how related it is
+type scoredRelated struct {
+	ID    uint
+	Score float64
+}
+
+// RelatedModel computes and reads related videos, playlists and advertisements
+type RelatedModel struct {
+	DB    *gorm.DB
+	Clock Clock
+}
+
+// NewRelatedModel creates a new instance of RelatedModel
+func NewRelatedModel(db *gorm.DB) *RelatedModel {
+	return &RelatedModel{
+		DB:    db,
+		Clock: SystemClock{},
+	}
+}
+
+// relatedTablesFor returns the related entries tables of a content type
+func relatedTablesFor(contentType string) (relatedTables, error) {
+	tables, ok := relatedContent[contentType]
+	if !ok {
+		return relatedTables{}, fmt.Errorf("unknown content type %q", contentType)
+	}
+	return tables, nil
+}
+
+// RefreshRelatedContent recomputes the related videos, playlists and advertisements from co-viewing
+// and tag and category overlap, replacing every computed entry. Pinned entries are kept.
+func (rm *RelatedModel) RefreshRelatedContent() error {
+	now := rm.Clock.Now()
+	since := now.Add(-RelatedWindow)
+
+	videos, err := rm.videoCandidates(since)
+	if err != nil {
+		return err
+	}
+	playlistCategories, err := rm.playlistCategories()
+	if err != nil {
+		return err
+	}
+	playlists, err := rm.playlistCandidates(since, playlistCategories)
+	if err != nil {
+		return err
+	}
+	advertisements, err := rm.advertisementCandidates(since, playlistCategories)
+	if err != nil {
+		return err
+	}
+
+	return rm.DB.Transaction(func(tx *gorm.DB) error {
+		for contentType, candidates := range map[string]map[uint]*relatedCandidate{
+			ContentVideo:         videos,
+			ContentPlaylist:      playlists,
+			ContentAdvertisement: advertisements,
+		} {
+			if err := saveRelated(tx, relatedContent[contentType], scoreRelated(candidates), now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// videoCandidates loads the tags, category and recent viewers of every video
+func (rm *RelatedModel) videoCandidates(since time.Time) (map[uint]*relatedCandidate, error) {
+	var videos []Video
+	if err := rm.DB.Select("id", "tags", "category_id").Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	candidates := map[uint]*relatedCandidate{}
+	for _, video := range videos {
+		candidate := newRelatedCandidate(video.Tags)
+		if video.CategoryID != 0 {
+			candidate.Categories[fmt.Sprint(video.CategoryID)] = true
+		}
+		candidates[video.ID] = candidate
+	}
+
+	err := scanPairs(rm.DB.Model(&WatchHistoryEntry{}).Select("video_id, user_id").Where("updated_at >= ?", since), func(videoID, userID uint) {
+		if candidate, ok := candidates[videoID]; ok {
+			candidate.Audience["u"+fmt.Sprint(userID)] = true
+		}
+	})
+	return candidates, err
+}
+
+// playlistCategories loads the categories of the videos of every playlist
+func (rm *RelatedModel) playlistCategories() (map[uint][]uint, error) {
+	categories := map[uint][]uint{}
+	err := scanPairs(rm.DB.Model(&Video{}).Distinct("playlist_id", "category_id").Where("playlist_id <> 0 AND category_id <> 0"), func(playlistID, categoryID uint) {
+		categories[playlistID] = append(categories[playlistID], categoryID)
+	})
+	return categories, err
+}
+
+// playlistCandidates loads the tags, video categories and recent audience of every playlist.
+// The audience is the users who watched its videos and the advertisements played in it.
+func (rm *RelatedModel) playlistCandidates(since time.Time, categories map[uint][]uint) (map[uint]*relatedCandidate, error) {
+	var playlists []Playlist
+	if err := rm.DB.Select("id", "tags").Find(&playlists).Error; err != nil {
+		return nil, err
+	}
+	candidates := map[uint]*relatedCandidate{}
+	for _, playlist := range playlists {
+		candidate := newRelatedCandidate(playlist.Tags)
+		for _, categoryID := range categories[playlist.ID] {
+			candidate.Categories[fmt.Sprint(categoryID)] = true
+		}
+		candidates[playlist.ID] = candidate
+	}
+
+	viewers := rm.DB.Model(&WatchHistoryEntry{}).Distinct("videos.playlist_id", "watch_history.user_id").
+		Joins("JOIN videos ON videos.id = watch_history.video_id AND videos.deleted_at IS NULL").
+		Where("watch_history.updated_at >= ?", since)
+	if err := scanPairs(viewers, func(playlistID, userID uint) {
+		if candidate, ok := candidates[playlistID]; ok {
+			candidate.Audience["u"+fmt.Sprint(userID)] = true
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	plays := rm.DB.Model(&AdvertisementPlayEvent{}).Distinct("playlist_id", "advertisement_id").Where("play_time >= ?", since)
+	err := scanPairs(plays, func(playlistID, advertisementID uint) {
+		if candidate, ok := candidates[playlistID]; ok {
+			candidate.Audience["a"+fmt.Sprint(advertisementID)] = true
+		}
+	})
+	return candidates, err
+}
+
+// advertisementCandidates loads the tags, playlist video categories and recent playlists of every advertisement
+func (rm *RelatedModel) advertisementCandidates(since time.Time, categories map[uint][]uint) (map[uint]*relatedCandidate, error) {
+	var advertisements []Advertisement
+	if err := rm.DB.Select("id", "tags", "playlist_id").Find(&advertisements).Error; err != nil {
+		return nil, err
+	}
+	candidates := map[uint]*relatedCandidate{}
+	for _, advertisement := range advertisements {
+		candidate := newRelatedCandidate(advertisement.Tags)
+		for _, categoryID := range categories[advertisement.PlaylistID] {
+			candidate.Categories[fmt.Sprint(categoryID)] = true
+		}
+		candidates[advertisement.ID] = candidate
+	}
+
+	plays := rm.DB.Model(&AdvertisementPlayEvent{}).Distinct("advertisement_id", "playlist_id").Where("play_time >= ?", since)
+	err := scanPairs(plays, func(advertisementID, playlistID uint) {
+		if candidate, ok := candidates[advertisementID]; ok {
+			candidate.Audience["p"+fmt.Sprint(playlistID)] = true
+		}
+	})
+	return candidates, err
+}
+
+// newRelatedCandidate creates a candidate with the given tags, compared case-insensitively
+func newRelatedCandidate(tags []string) *relatedCandidate {
+	candidate := &relatedCandidate{Audience: map[string]bool{}, Tags: map[string]bool{}, Categories: map[string]bool{}}
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			candidate.Tags[tag] = true
+		}
+	}
+	return candidate
+}
+
+// scanPairs runs a query selecting two ID columns and calls fn with each row
+func scanPairs(query *gorm.DB, fn func(first, second uint)) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var first, second uint
+		if err := rows.Scan(&first, &second); err != nil {
+			return err
+		}
+		fn(first, second)
+	}
+	return rows.Err()
+}
+
+// scoreRelated returns the MaxRelatedItems most related items of every item, best first
+func scoreRelated(candidates map[uint]*relatedCandidate) map[uint][]scoredRelated {
+	indexes := make([]map[string][]uint, len(relatedSignals))
+	for i, signal := range relatedSignals {
+		index := map[string][]uint{}
+		for id, candidate := range candidates {
+			for value := range signal.Values(candidate) {
+				index[value] = append(index[value], id)
+			}
+		}
+		indexes[i] = index
+	}
+
+	related := map[uint][]scoredRelated{}
+	for id, candidate := range candidates {
+		scores := map[uint]float64{}
+		for i, signal := range relatedSignals {
+			values := signal.Values(candidate)
+			shared := map[uint]int{}
+			for value := range values {
+				ids := indexes[i][value]
+				if len(ids) > MaxSignalFanout {
+					continue
+				}
+				for _, other := range ids {
+					if other != id {
+						shared[other]++
+					}
+				}
+			}
+			for other, count := range shared {
+				scores[other] += signal.Weight * signal.Similarity(count, len(values), len(signal.Values(candidates[other])))
+			}
+		}
+
+		entries := make([]scoredRelated, 0, len(scores))
+		for other, score := range scores {
+			entries = append(entries, scoredRelated{ID: other, Score: score})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Score != entries[j].Score {
+				return entries[i].Score > entries[j].Score
+			}
+			return entries[i].ID < entries[j].ID
+		})
+		if len(entries) > MaxRelatedItems {
+			entries = entries[:MaxRelatedItems]
+		}
+		if len(entries) > 0 {
+			related[id] = entries
+		}
+	}
+	return related
+}
+
+// cosineSimilarity is the cosine similarity of two sets with the given sizes and shared count
+func cosineSimilarity(shared, a, b int) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return float64(shared) / math.Sqrt(float64(a)*float64(b))
+}
+
+// jaccardSimilarity is the Jaccard similarity of two sets with the given sizes and shared count
+func jaccardSimilarity(shared, a, b int) float64 {
+	if a+b-shared == 0 {
+		return 0
+	}
+	return float64(shared) / float64(a+b-shared)
+}
+
+// saveRelated replaces the computed entries of a related entries table, skipping pairs that are pinned
+func saveRelated(tx *gorm.DB, tables relatedTables, related map[uint][]scoredRelated, now time.Time) error {
+	pinned := map[[2]uint]bool{}
+	if err := scanPairs(tx.Table(tables.Table).Select(tables.Key, tables.RelatedKey).Where("pinned = ? AND deleted_at IS NULL", true), func(id, relatedID uint) {
+		pinned[[2]uint{id, relatedID}] = true
+	}); err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM "+tables.Table+" WHERE pinned = ?", false).Error; err != nil {
+		return err
+	}
+
+	var rows []map[string]interface{}
+	for id, entries := range related {
+		for _, entry := range entries {
+			if pinned[[2]uint{id, entry.ID}] {
+				continue
+			}
+			rows = append(rows, map[string]interface{}{
+				tables.Key:        id,
+				tables.RelatedKey: entry.ID,
+				"score":           entry.Score,
+				"pinned":          false,
+				"order":           0,
+				"created_at":      now,
+				"updated_at":      now,
+			})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Table(tables.Table).CreateInBatches(rows, 500).Error
+}
+
+// relatedIDs fetches the IDs of the items related to one item, pinned entries first in their order,
+// then computed entries by score
+func (rm *RelatedModel) relatedIDs(contentType string, id uint, limit int) ([]uint, error) {
+	tables, err := relatedTablesFor(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if err := contentExists(rm.DB, engagements[contentType], id); err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	if err := rm.DB.Table(tables.Table).Where(tables.Key+" = ? AND deleted_at IS NULL", id).
+		Order("pinned DESC").
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "order"}}).
+		Order("score DESC").
+		Order(tables.RelatedKey).
+		Limit(limit).Pluck(tables.RelatedKey, &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// positions maps IDs to their position in a list, to restore the order of a query by ID
+func positions(ids []uint) map[uint]int {
+	position := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		position[id] = i
+	}
+	return position
+}
+
+// GetRelatedVideos fetches up to limit videos a viewer can read related to a video
+func (rm *RelatedModel) GetRelatedVideos(videoID uint, viewer Viewer, limit int) ([]Video, error) {
+	ids, err := rm.relatedIDs(ContentVideo, videoID, limit)
+	if err != nil {
+		return nil, err
+	}
+	videos := []Video{}
+	if len(ids) == 0 {
+		return videos, nil
+	}
+	if err := rm.DB.Where("videos.id IN ?", ids).Scopes(VisibleVideos(viewer)).Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	position := positions(ids)
+	sort.Slice(videos, func(i, j int) bool { return position[videos[i].ID] < position[videos[j].ID] })
+	return videos, nil
+}
+
+// GetRelatedPlaylists fetches up to limit playlists a viewer can read related to a playlist
+func (rm *RelatedModel) GetRelatedPlaylists(playlistID uint, viewer Viewer, limit int) ([]Playlist, error) {
+	ids, err := rm.relatedIDs(ContentPlaylist, playlistID, limit)
+	if err != nil {
+		return nil, err
+	}
+	playlists := []Playlist{}
+	if len(ids) == 0 {
+		return playlists, nil
+	}
+	if err := rm.DB.Where("playlists.id IN ?", ids).Scopes(VisiblePlaylists(viewer)).Find(&playlists).Error; err != nil {
+		return nil, err
+	}
+	position := positions(ids)
+	sort.Slice(playlists, func(i, j int) bool { return position[playlists[i].ID] < position[playlists[j].ID] })
+	return playlists, nil
+}
+
+// GetRelatedAdvertisements fetches up to limit advertisements a viewer can read related to an advertisement
+func (rm *RelatedModel) GetRelatedAdvertisements(advertisementID uint, viewer Viewer, limit int) ([]Advertisement, error) {
+	ids, err := rm.relatedIDs(ContentAdvertisement, advertisementID, limit)
+	if err != nil {
+		return nil, err
+	}
+	advertisements := []Advertisement{}
+	if len(ids) == 0 {
+		return advertisements, nil
+	}
+	if err := rm.DB.Where("advertisements.id IN ?", ids).Scopes(VisibleAdvertisements(viewer)).Find(&advertisements).Error; err != nil {
+		return nil, err
+	}
+	position := positions(ids)
+	sort.Slice(advertisements, func(i, j int) bool {
+		return position[advertisements[i].ID] < position[advertisements[j].ID]
+	})
+	return advertisements, nil
+}
+
+// PinRelated pins an item as related to another at the given position among the pinned entries.
+// Pinned entries come before computed ones and survive RefreshRelatedContent.
+func (rm *RelatedModel) PinRelated(contentType string, id, relatedID uint, order int) error {
+	tables, err := relatedTablesFor(contentType)
+	if err != nil {
+		return err
+	}
+	if id == relatedID {
+		return ErrInvalidRelated
+	}
+
+	return rm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := contentExists(tx, engagements[contentType], id); err != nil {
+			return err
+		}
+		if err := contentExists(tx, engagements[contentType], relatedID); err != nil {
+			return err
+		}
+
+		now := rm.Clock.Now()
+		result := tx.Table(tables.Table).Where(tables.Key+" = ? AND "+tables.RelatedKey+" = ?", id, relatedID).
+			Updates(map[string]interface{}{"pinned": true, "order": order, "updated_at": now})
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+		return tx.Table(tables.Table).Create(map[string]interface{}{
+			tables.Key:        id,
+			tables.RelatedKey: relatedID,
+			"score":           0,
+			"pinned":          true,
+			"order":           order,
+			"created_at":      now,
+			"updated_at":      now,
+		}).Error
+	})
+}
+
+// UnpinRelated removes a pinned related entry. The next refresh may compute the pair again.
+func (rm *RelatedModel) UnpinRelated(contentType string, id, relatedID uint) error {
+	tables, err := relatedTablesFor(contentType)
+	if err != nil {
+		return err
+	}
+	result := rm.DB.Exec("DELETE FROM "+tables.Table+" WHERE "+tables.Key+" = ? AND "+tables.RelatedKey+" = ? AND pinned = ?", id, relatedID, true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
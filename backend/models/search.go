@@ -0,0 +1,84 @@
+// backend/models/search.go
+
+package models
+
+import (
+	"github.com/shuttlersit/ads-player/backend/search"
+	"gorm.io/gorm"
+)
+
+// SearchEngine is kept in sync with videos, playlists, channels and advertisements by their hooks.
+// Nothing is indexed while it is nil.
+var SearchEngine search.Engine
+
+// SearchSources are the searchable content types. Private and password protected playlists and advertisements,
+// and the videos of those playlists, are not found.
+var SearchSources = []search.Source{
+	{Type: ContentVideo, Table: "videos", Title: "title", Description: "description",
+		Visible: "videos.playlist_id = 0 OR videos.playlist_id IN (SELECT id FROM playlists WHERE deleted_at IS NULL AND " + publicPlaylists + ")"},
+	{Type: ContentPlaylist, Table: "playlists", Title: "title", Description: "description", Visible: publicPlaylists},
+	{Type: ContentChannel, Table: "channels", Title: "name", Description: "description"},
+	{Type: ContentAdvertisement, Table: "advertisements", Title: "title", Description: "description",
+		Visible: "advertisements.is_public = true AND COALESCE(advertisements.password_hash, '') = ''"},
+}
+
+// publicPlaylists is the condition of the playlists anyone can find
+const publicPlaylists = "playlists.is_public = true AND COALESCE(playlists.password_hash, '') = ''"
+
+// indexSearch updates the search document of an item after it is saved. The item is read back from
+// the database, as partial updates only carry the changed fields.
+func indexSearch(tx *gorm.DB, docType string, id uint) error {
+	if SearchEngine == nil || id == 0 {
+		return nil
+	}
+	return SearchEngine.Index(tx.Session(&gorm.Session{NewDB: true}), docType, id)
+}
+
+// removeSearch drops the search document of an item after it is deleted. Deletes by condition do not
+// carry the ID, those documents are skipped by searches and dropped on the next reindex.
+func removeSearch(tx *gorm.DB, docType string, id uint) error {
+	if SearchEngine == nil || id == 0 {
+		return nil
+	}
+	return SearchEngine.Remove(tx.Session(&gorm.Session{NewDB: true}), docType, id)
+}
+
+// AfterSave indexes the video for search
+func (v *Video) AfterSave(tx *gorm.DB) error {
+	return indexSearch(tx, ContentVideo, v.ID)
+}
+
+// AfterDelete removes the video from search
+func (v *Video) AfterDelete(tx *gorm.DB) error {
+	return removeSearch(tx, ContentVideo, v.ID)
+}
+
+// AfterSave indexes the playlist for search
+func (p *Playlist) AfterSave(tx *gorm.DB) error {
+	return indexSearch(tx, ContentPlaylist, p.ID)
+}
+
+// AfterDelete removes the playlist from search
+func (p *Playlist) AfterDelete(tx *gorm.DB) error {
+	return removeSearch(tx, ContentPlaylist, p.ID)
+}
+
+// AfterSave indexes the channel for search
+func (c *Channel) AfterSave(tx *gorm.DB) error {
+	return indexSearch(tx, ContentChannel, c.ID)
+}
+
+// AfterDelete removes the channel from search
+func (c *Channel) AfterDelete(tx *gorm.DB) error {
+	return removeSearch(tx, ContentChannel, c.ID)
+}
+
+// AfterSave indexes the advertisement for search
+func (a *Advertisement) AfterSave(tx *gorm.DB) error {
+	return indexSearch(tx, ContentAdvertisement, a.ID)
+}
+
+// AfterDelete removes the advertisement from search
+func (a *Advertisement) AfterDelete(tx *gorm.DB) error {
+	return removeSearch(tx, ContentAdvertisement, a.ID)
+}
@@ -9,16 +9,20 @@ import (
 // User model
 type User struct {
 	gorm.Model
-	Username       string     `json:"username"`
-	Email          string     `json:"email" gorm:"unique"`
-	Password       string     `json:"-"`
-	ProfilePicture string     `json:"profilePicture"`
-	FirstName      string     `json:"firstName"`
-	LastName       string     `json:"lastName"`
-	Bio            string     `json:"bio"`
-	Subscriptions  []Channel  `gorm:"many2many:user_subscriptions;"`
-	Playlists      []Playlist `json:"playlists"`
-	WatchHistory   []Video    `json:"watchHistory" gorm:"many2many:user_watch_history;"`
-	LikedVideos    []Video    `json:"likedVideos" gorm:"many2many:user_liked_videos;"`
-	DislikedVideos []Video    `json:"dislikedVideos" gorm:"many2many:user_disliked_videos;"`
+	Username               string              `json:"username"`
+	Email                  string              `json:"email" gorm:"unique"`
+	Password               string              `json:"-"`
+	ProfilePicture         string              `json:"profilePicture"`
+	FirstName              string              `json:"firstName"`
+	LastName               string              `json:"lastName"`
+	Bio                    string              `json:"bio"`
+	Subscriptions          []Channel           `gorm:"many2many:user_subscriptions;"`
+	Playlists              []Playlist          `json:"playlists" gorm:"foreignKey:OwnerID"`
+	WatchHistory           []WatchHistoryEntry `json:"watchHistory" gorm:"foreignKey:UserID"`
+	LikedVideos            []Video             `json:"likedVideos" gorm:"many2many:user_liked_videos;"`
+	DislikedVideos         []Video             `json:"dislikedVideos" gorm:"many2many:user_disliked_videos;"`
+	LikedPlaylists         []Playlist          `json:"likedPlaylists" gorm:"many2many:user_liked_playlists;"`
+	DislikedPlaylists      []Playlist          `json:"dislikedPlaylists" gorm:"many2many:user_disliked_playlists;"`
+	LikedAdvertisements    []Advertisement     `json:"likedAdvertisements" gorm:"many2many:user_liked_advertisements;"`
+	DislikedAdvertisements []Advertisement     `json:"dislikedAdvertisements" gorm:"many2many:user_disliked_advertisements;"`
 }
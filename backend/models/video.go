@@ -12,6 +12,7 @@ type Video struct {
 	URL             string         `json:"url"`
 	ThumbnailURL    string         `json:"thumbnailUrl"`
 	Views           uint           `json:"views" gorm:"default:0"`
+	LegacyViews     uint           `json:"-" gorm:"default:0"` // Views counted before the watch history, kept by RollUpWatchHistory
 	Likes           uint           `json:"likes" gorm:"default:0"`
 	Dislikes        uint           `json:"dislikes" gorm:"default:0"`
 	Comments        []Comment      `gorm:"foreignKey:VideoID"`
@@ -19,20 +20,49 @@ type Video struct {
 	Playlist        Playlist       `json:"playlist"`
 	IsAdvertisement bool           `json:"isAdvertisement" gorm:"default:false"`
 	Duration        int            `json:"duration"` // Duration in seconds
+	Probe           MediaProbe     `json:"probe" gorm:"embedded;embeddedPrefix:probe_"`
 	Order           int            `json:"order" gorm:"default:0"`
-	Tags            []string       `json:"tags" gorm:"type:varchar(255)[]"`
+	Tags            []string       `json:"tags" gorm:"serializer:json"`
+	Language        string         `json:"language"`
 	UploadDate      int            `json:"uploadDate" gorm:"autoCreateTime"`
+	UploaderID      uint           `json:"uploaderId"`
 	Uploader        User           `json:"uploader"`
-	Category        Category       `json:"category"`
+	ChannelID       uint           `json:"channelId" gorm:"index"`
+	CategoryID      uint           `json:"categoryId" gorm:"index"` // 0 when uncategorized
+	Category        *Category      `json:"category,omitempty"`
 	PrivacySetting  PrivacySetting `json:"privacySetting" gorm:"embedded"`
 	CommentsEnabled bool           `json:"commentsEnabled" gorm:"default:true"`
 	RelatedVideos   []RelatedVideo `json:"relatedVideos" gorm:"foreignKey:VideoID"`
 	// Add more video-related fields as needed
 }
 
-// RelatedVideo model for representing related videos
+// RelatedVideo model for representing related videos, computed by RefreshRelatedContent or pinned by hand
 type RelatedVideo struct {
 	gorm.Model
-	VideoID        uint
-	RelatedVideoID uint
+	VideoID        uint    `json:"-" gorm:"index"`
+	RelatedVideoID uint    `json:"relatedVideoId"`
+	Score          float64 `json:"score"`
+	Pinned         bool    `json:"pinned" gorm:"default:false"`
+	Order          int     `json:"order" gorm:"default:0"` // Position among the pinned entries
+}
+
+// VideoModel handles database operations for Video
+type VideoModel struct {
+	DB *gorm.DB
+}
+
+// NewVideoModel creates a new instance of VideoModel
+func NewVideoModel(db *gorm.DB) *VideoModel {
+	return &VideoModel{
+		DB: db,
+	}
+}
+
+// GetVideoWithComments fetches a video along with its visible top-level comments
+func (vm *VideoModel) GetVideoWithComments(videoID uint) (*Video, error) {
+	var video Video
+	if err := vm.DB.Scopes(WithComments).First(&video, videoID).Error; err != nil {
+		return nil, err
+	}
+	return &video, nil
 }
@@ -0,0 +1,210 @@
+// backend/models/watch_history.go
+
+package models
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ViewThresholdSeconds is how long a user has to watch a video, in one watch, for it to count as a view.
+	// Shorter videos count once CompletionRatio of them was watched.
+	ViewThresholdSeconds = 30
+	// CompletionRatio is the fraction of a video's duration after which a watch counts as completed
+	CompletionRatio = 0.95
+	// ViewCooldown is how long after a counted view the same user's next watch of the video can count again
+	ViewCooldown = 30 * time.Minute
+)
+
+const (
+	// videoHistoryViewsSQL is the number of views of the video in the outer query recorded in the watch history
+	videoHistoryViewsSQL = "(SELECT COALESCE(SUM(watch_history.views), 0) FROM watch_history WHERE watch_history.video_id = videos.id)"
+	// playlistHistoryViewsSQL is the number of views of the videos of the playlist in the outer query recorded in the watch history
+	playlistHistoryViewsSQL = "(SELECT COALESCE(SUM(watch_history.views), 0) FROM watch_history " +
+		"JOIN videos ON videos.id = watch_history.video_id WHERE videos.playlist_id = playlists.id)"
+)
+
+// WatchHistoryEntry is a user's progress through a video. There is one entry per user and video,
+// restarted when the user watches a completed video again.
+type WatchHistoryEntry struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"userId" gorm:"uniqueIndex:idx_watch_history_user_video,priority:1"`
+	VideoID        uint       `json:"videoId" gorm:"uniqueIndex:idx_watch_history_user_video,priority:2"`
+	Video          Video      `json:"video"`
+	Position       int        `json:"position"`       // Resume position in seconds
+	WatchedSeconds int        `json:"watchedSeconds"` // Seconds watched over every watch
+	Completed      bool       `json:"completed"`
+	Views          uint       `json:"views"` // Watches of the video counted in Video.Views
+	CurrentSeconds int        `json:"-"`     // Seconds watched in the current watch
+	ViewCounted    bool       `json:"-"`     // Whether the current watch has been counted as a view
+	ViewCountedAt  *time.Time `json:"-"`     // When a watch was last counted as a view
+	ReportedAt     *time.Time `json:"-"`     // When the player last reported progress
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt" gorm:"index"`
+}
+
+// TableName keeps the table name singular, as the history is one log of viewing
+func (WatchHistoryEntry) TableName() string {
+	return "watch_history"
+}
+
+// WatchProgress is a progress report from a player
+type WatchProgress struct {
+	Position  int  `json:"position"`  // Current position in seconds
+	Watched   int  `json:"watched"`   // Seconds watched since the previous report
+	Completed bool `json:"completed"` // Set when the player reached the end
+}
+
+// WatchHistoryModel handles database operations for watch history
+type WatchHistoryModel struct {
+	DB    *gorm.DB
+	Clock Clock
+}
+
+// NewWatchHistoryModel creates a new instance of WatchHistoryModel
+func NewWatchHistoryModel(db *gorm.DB) *WatchHistoryModel {
+	return &WatchHistoryModel{
+		DB:    db,
+		Clock: SystemClock{},
+	}
+}
+
+// ReportProgress records a user's progress through a video. Players report when playback starts and
+// then regularly; a report never credits more watched seconds than elapsed since the previous one.
+// The first time a watch reaches the video's view threshold it counts as a view of the video and a play
+// of its playlist, at most once per ViewCooldown. Going back to the start of a completed video begins a new watch.
+func (wm *WatchHistoryModel) ReportProgress(userID, videoID uint, progress WatchProgress) (*WatchHistoryEntry, error) {
+	var entry WatchHistoryEntry
+	err := wm.DB.Transaction(func(tx *gorm.DB) error {
+		var video Video
+		if err := tx.First(&video, videoID).Error; err != nil {
+			return err
+		}
+
+		// Concurrent first reports would both insert, so the entry is created if missing, then read locked
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}, {Name: "video_id"}}, DoNothing: true}).
+			Create(&WatchHistoryEntry{UserID: userID, VideoID: videoID}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND video_id = ?", userID, videoID).First(&entry).Error; err != nil {
+			return err
+		}
+
+		now := wm.Clock.Now()
+		position := clampSeconds(progress.Position, video.Duration)
+		elapsed := 0
+		if entry.ReportedAt != nil {
+			elapsed = int(now.Sub(*entry.ReportedAt) / time.Second)
+		}
+		watched := clampSeconds(progress.Watched, video.Duration)
+		if watched > elapsed {
+			watched = elapsed
+		}
+		entry.ReportedAt = &now
+		if entry.Completed && position < entry.Position {
+			entry.Completed = false
+			entry.CurrentSeconds = 0
+			entry.ViewCounted = false
+		}
+		entry.Video = video
+		entry.Position = position
+		entry.WatchedSeconds += watched
+		entry.CurrentSeconds += watched
+		if progress.Completed || (video.Duration > 0 && float64(position) >= float64(video.Duration)*CompletionRatio) {
+			entry.Completed = true
+		}
+
+		countView := !entry.ViewCounted && entry.CurrentSeconds >= viewThreshold(video.Duration) &&
+			(entry.ViewCountedAt == nil || now.Sub(*entry.ViewCountedAt) >= ViewCooldown)
+		if countView {
+			entry.ViewCounted = true
+			entry.ViewCountedAt = &now
+			entry.Views++
+			entry.Video.Views++
+		}
+		if err := tx.Omit("Video").Save(&entry).Error; err != nil {
+			return err
+		}
+		if !countView {
+			return nil
+		}
+
+		if err := tx.Model(&Video{}).Where("id = ?", videoID).
+			UpdateColumn("views", gorm.Expr("views + 1")).Error; err != nil {
+			return err
+		}
+		if video.PlaylistID == 0 {
+			return nil
+		}
+		return tx.Model(&Playlist{}).Where("id = ?", video.PlaylistID).
+			UpdateColumn("play_count", gorm.Expr("play_count + 1")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// viewThreshold returns how many seconds of a video of the given duration must be watched for a view
+func viewThreshold(duration int) int {
+	if duration > 0 && float64(duration)*CompletionRatio < ViewThresholdSeconds {
+		return int(math.Ceil(float64(duration) * CompletionRatio))
+	}
+	return ViewThresholdSeconds
+}
+
+// clampSeconds keeps a number of seconds between zero and the duration, when the duration is known
+func clampSeconds(seconds, duration int) int {
+	if seconds < 0 {
+		return 0
+	}
+	if duration > 0 && seconds > duration {
+		return duration
+	}
+	return seconds
+}
+
+// GetContinueWatching fetches a page of the videos a user started but did not finish, most recent first
+func (wm *WatchHistoryModel) GetContinueWatching(userID uint, offset, limit int) ([]WatchHistoryEntry, int64, error) {
+	return wm.findEntries(wm.DB.Where("watch_history.completed = ? AND watch_history.position > 0", false), userID, offset, limit)
+}
+
+// GetWatchHistory fetches a page of a user's watch history, most recent first
+func (wm *WatchHistoryModel) GetWatchHistory(userID uint, offset, limit int) ([]WatchHistoryEntry, int64, error) {
+	return wm.findEntries(wm.DB, userID, offset, limit)
+}
+
+// findEntries fetches a page of a user's watch history entries for videos that still exist
+func (wm *WatchHistoryModel) findEntries(query *gorm.DB, userID uint, offset, limit int) ([]WatchHistoryEntry, int64, error) {
+	query = query.Model(&WatchHistoryEntry{}).
+		Joins("JOIN videos ON videos.id = watch_history.video_id AND videos.deleted_at IS NULL").
+		Where("watch_history.user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := []WatchHistoryEntry{}
+	if err := query.Preload("Video").Order("watch_history.updated_at DESC, watch_history.id DESC").
+		Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// RollUpWatchHistory recomputes the views of every video and the play count of every playlist
+// from the watch history, correcting any drift. Counts from before the watch history are kept.
+func (wm *WatchHistoryModel) RollUpWatchHistory() error {
+	return wm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Video{}).Where("1 = 1").UpdateColumn("views", gorm.Expr("legacy_views + "+videoHistoryViewsSQL)).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Playlist{}).Where("1 = 1").UpdateColumn("play_count", gorm.Expr("legacy_play_count + "+playlistHistoryViewsSQL)).Error
+	})
+}
@@ -0,0 +1,173 @@
+// backend/models/watch_history_test.go
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+func TestRollUpWatchHistoryKeepsLegacyCounts(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "playlist", IsPublic: true, PlayCount: 100, LegacyPlayCount: 100}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	// The first video has 60 views from before the watch history, the second 40 and no history
+	watched := models.Video{Title: "watched", PlaylistID: playlist.ID, Duration: 120, Views: 60, LegacyViews: 60}
+	unwatched := models.Video{Title: "unwatched", PlaylistID: playlist.ID, Duration: 120, Views: 40, LegacyViews: 40}
+	for _, video := range []*models.Video{&watched, &unwatched} {
+		if err := db.Create(video).Error; err != nil {
+			t.Fatalf("creating video: %v", err)
+		}
+	}
+
+	watchHistoryModel := models.NewWatchHistoryModel(db)
+	now := time.Date(2026, 3, 6, 20, 0, 0, 0, time.UTC)
+	watchHistoryModel.Clock = models.ClockFunc(func() time.Time { return now })
+	for userID := uint(1); userID <= 2; userID++ {
+		if _, err := watchHistoryModel.ReportProgress(userID, watched.ID, models.WatchProgress{}); err != nil {
+			t.Fatalf("ReportProgress: %v", err)
+		}
+	}
+	now = now.Add(45 * time.Second)
+	for userID := uint(1); userID <= 2; userID++ {
+		if _, err := watchHistoryModel.ReportProgress(userID, watched.ID, models.WatchProgress{Position: 45, Watched: 45}); err != nil {
+			t.Fatalf("ReportProgress: %v", err)
+		}
+	}
+	// Drift in both directions
+	if err := db.Model(&watched).UpdateColumn("views", 3).Error; err != nil {
+		t.Fatalf("updating views: %v", err)
+	}
+	if err := db.Model(&playlist).UpdateColumn("play_count", 500).Error; err != nil {
+		t.Fatalf("updating play count: %v", err)
+	}
+
+	if err := watchHistoryModel.RollUpWatchHistory(); err != nil {
+		t.Fatalf("RollUpWatchHistory: %v", err)
+	}
+	for _, want := range []struct {
+		video models.Video
+		views uint
+	}{{watched, 62}, {unwatched, 40}} {
+		var video models.Video
+		if err := db.First(&video, want.video.ID).Error; err != nil {
+			t.Fatalf("reading video: %v", err)
+		}
+		if video.Views != want.views {
+			t.Errorf("video %q has %d views, want %d", video.Title, video.Views, want.views)
+		}
+	}
+	var rolledUp models.Playlist
+	if err := db.First(&rolledUp, playlist.ID).Error; err != nil {
+		t.Fatalf("reading playlist: %v", err)
+	}
+	if rolledUp.PlayCount != 102 {
+		t.Errorf("playlist play count is %d, want 102", rolledUp.PlayCount)
+	}
+}
+
+func TestReportProgressAddsToExistingEntry(t *testing.T) {
+	db := newTestDB(t)
+	video := models.Video{Title: "video", Duration: 120}
+	if err := db.Create(&video).Error; err != nil {
+		t.Fatalf("creating video: %v", err)
+	}
+
+	watchHistoryModel := models.NewWatchHistoryModel(db)
+	now := time.Date(2026, 3, 6, 20, 0, 0, 0, time.UTC)
+	watchHistoryModel.Clock = models.ClockFunc(func() time.Time { return now })
+	var entry *models.WatchHistoryEntry
+	for _, position := range []int{0, 20, 40} {
+		now = now.Add(20 * time.Second)
+		var err error
+		if entry, err = watchHistoryModel.ReportProgress(1, video.ID, models.WatchProgress{Position: position, Watched: position - entryPosition(entry)}); err != nil {
+			t.Fatalf("ReportProgress: %v", err)
+		}
+	}
+	var entries int64
+	if err := db.Model(&models.WatchHistoryEntry{}).Count(&entries).Error; err != nil {
+		t.Fatalf("counting entries: %v", err)
+	}
+	if entries != 1 || entry.Position != 40 || entry.WatchedSeconds != 40 || entry.Views != 1 {
+		t.Errorf("got %d entries, the last at %ds with %ds watched and %d views, want 1 at 40s with 40s and 1 view",
+			entries, entry.Position, entry.WatchedSeconds, entry.Views)
+	}
+}
+
+// entryPosition returns the position of a watch history entry, 0 before the first report
+func entryPosition(entry *models.WatchHistoryEntry) int {
+	if entry == nil {
+		return 0
+	}
+	return entry.Position
+}
+
+func TestReportProgressRestartCycle(t *testing.T) {
+	db := newTestDB(t)
+	playlist := models.Playlist{Title: "playlist", IsPublic: true}
+	if err := db.Create(&playlist).Error; err != nil {
+		t.Fatalf("creating playlist: %v", err)
+	}
+	video := models.Video{Title: "video", PlaylistID: playlist.ID, Duration: 120}
+	if err := db.Create(&video).Error; err != nil {
+		t.Fatalf("creating video: %v", err)
+	}
+
+	watchHistoryModel := models.NewWatchHistoryModel(db)
+	now := time.Date(2026, 3, 6, 20, 0, 0, 0, time.UTC)
+	watchHistoryModel.Clock = models.ClockFunc(func() time.Time { return now })
+	report := func(progress models.WatchProgress) *models.WatchHistoryEntry {
+		t.Helper()
+		entry, err := watchHistoryModel.ReportProgress(1, video.ID, progress)
+		if err != nil {
+			t.Fatalf("ReportProgress: %v", err)
+		}
+		return entry
+	}
+
+	// A client alternating completion and restarts a second apart, claiming whole watches, never watches 30s in a row
+	for i := 0; i < 20; i++ {
+		report(models.WatchProgress{Position: 120, Watched: 120, Completed: true})
+		now = now.Add(time.Second)
+		report(models.WatchProgress{Position: 0})
+		now = now.Add(time.Second)
+	}
+	if entry := report(models.WatchProgress{Position: 0}); entry.Views != 0 || entry.WatchedSeconds > 40 {
+		t.Errorf("cycling 20 times in 40s counted %d views and %ds watched, want none and at most 40s", entry.Views, entry.WatchedSeconds)
+	}
+
+	steps := []struct {
+		name     string
+		wait     time.Duration
+		progress models.WatchProgress
+		views    uint
+	}{
+		{"watching 40s", 40 * time.Second, models.WatchProgress{Position: 40, Watched: 40}, 1},
+		{"finishing", 80 * time.Second, models.WatchProgress{Position: 120, Watched: 80, Completed: true}, 1},
+		{"restarting", time.Second, models.WatchProgress{Position: 0}, 1},
+		{"rewatching within the cooldown", 40 * time.Second, models.WatchProgress{Position: 40, Watched: 40}, 1},
+		{"watching on after the cooldown", models.ViewCooldown, models.WatchProgress{Position: 80, Watched: 40}, 2},
+	}
+	for _, step := range steps {
+		now = now.Add(step.wait)
+		if entry := report(step.progress); entry.Views != step.views {
+			t.Errorf("%s left %d views, want %d", step.name, entry.Views, step.views)
+		}
+	}
+
+	var stored models.Video
+	if err := db.First(&stored, video.ID).Error; err != nil {
+		t.Fatalf("loading video: %v", err)
+	}
+	var storedPlaylist models.Playlist
+	if err := db.First(&storedPlaylist, playlist.ID).Error; err != nil {
+		t.Fatalf("loading playlist: %v", err)
+	}
+	if stored.Views != 2 || storedPlaylist.PlayCount != 2 {
+		t.Errorf("video has %d views and playlist %d plays, want 2 and 2", stored.Views, storedPlaylist.PlayCount)
+	}
+}
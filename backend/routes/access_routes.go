@@ -0,0 +1,25 @@
+// backend/routes/access_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// RegisterAccessRoutes registers routes for password protecting playlists and advertisements and unlocking them
+func RegisterAccessRoutes(r *gin.Engine, db *gorm.DB) {
+	accessController := controllers.NewAccessController(db)
+
+	for path, contentType := range map[string]string{
+		"/playlists":      models.ContentPlaylist,
+		"/advertisements": models.ContentAdvertisement,
+	} {
+		group := r.Group(path)
+		group.POST("/:id/unlock", accessController.Unlock(contentType))
+		group.PUT("/:id/password", accessController.SetPassword(contentType))
+	}
+}
@@ -0,0 +1,28 @@
+// backend/routes/advertisement_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+	"github.com/shuttlersit/ads-player/backend/storage"
+)
+
+// RegisterAdvertisementRoutes registers routes related to advertisements
+func RegisterAdvertisementRoutes(r *gin.Engine, db *gorm.DB, store storage.Storage, probeService *controllers.MediaProbeService) {
+	creativeController := controllers.NewCreativeController(models.NewAdvertisementModel(db), store, probeService)
+
+	advertisements := r.Group("/advertisements")
+	{
+		advertisements.POST("/:id/creative", creativeController.UploadCreative)
+		advertisements.POST("/:id/thumbnail", creativeController.UploadThumbnail)
+	}
+
+	// Serve locally stored creatives
+	if local, ok := store.(*storage.LocalStorage); ok {
+		r.Static(local.BaseURL, local.Root)
+	}
+}
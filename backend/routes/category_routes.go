@@ -0,0 +1,30 @@
+// backend/routes/category_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+)
+
+// RegisterCategoryRoutes registers routes for the category taxonomy and categorizing channels and advertisements
+func RegisterCategoryRoutes(r *gin.Engine, db *gorm.DB) {
+	categoryController := controllers.NewCategoryController(db)
+
+	categories := r.Group("/categories")
+	{
+		categories.GET("", categoryController.GetCategories)
+		categories.GET("/:id", categoryController.GetCategory)
+		categories.POST("", categoryController.CreateCategory)
+		categories.PUT("/:id", categoryController.UpdateCategory)
+		categories.DELETE("/:id", categoryController.DeleteCategory)
+		categories.GET("/:id/videos", categoryController.GetCategoryVideos)
+		categories.GET("/:id/playlists", categoryController.GetCategoryPlaylists)
+		categories.GET("/:id/advertisements", categoryController.GetCategoryAdvertisements)
+	}
+
+	r.PUT("/channels/:id/categories", categoryController.SetChannelCategories)
+	r.PUT("/advertisements/:id/categories", categoryController.SetAdvertisementCategories)
+}
@@ -0,0 +1,30 @@
+// backend/routes/collaboration_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+)
+
+// RegisterCollaborationRoutes registers routes for playlist contributors, their edits and the edit log
+func RegisterCollaborationRoutes(r *gin.Engine, db *gorm.DB) {
+	collaborationController := controllers.NewCollaborationController(db)
+
+	playlists := r.Group("/playlists")
+	{
+		playlists.GET("/:id/contributors", collaborationController.GetContributors)
+		playlists.POST("/:id/contributors", collaborationController.InviteContributor)
+		playlists.POST("/:id/contributors/accept", collaborationController.AcceptInvitation)
+		playlists.DELETE("/:id/contributors/:userId", collaborationController.RemoveContributor)
+		playlists.POST("/:id/videos", collaborationController.AddVideo)
+		playlists.DELETE("/:id/videos/:videoId", collaborationController.RemoveVideo)
+		playlists.PUT("/:id/videos/order", collaborationController.ReorderVideos)
+		playlists.GET("/:id/edits", collaborationController.GetEdits)
+		playlists.POST("/:id/edits/:editId/rollback", collaborationController.RollBackEdit)
+	}
+
+	r.GET("/me/invitations", collaborationController.GetInvitations)
+}
@@ -0,0 +1,45 @@
+// backend/routes/comment_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// RegisterCommentRoutes registers routes for comments on videos, playlists and advertisements, and their hashtags
+func RegisterCommentRoutes(r *gin.Engine, db *gorm.DB) {
+	commentController := controllers.NewCommentController(db)
+
+	r.GET("/videos/:id/comments", commentController.GetComments(models.CommentableVideo))
+	r.POST("/videos/:id/comments", commentController.CreateComment(models.CommentableVideo))
+	r.GET("/playlists/:id/comments", commentController.GetComments(models.CommentablePlaylist))
+	r.POST("/playlists/:id/comments", commentController.CreateComment(models.CommentablePlaylist))
+	r.GET("/advertisements/:id/comments", commentController.GetComments(models.CommentableAdvertisement))
+	r.POST("/advertisements/:id/comments", commentController.CreateComment(models.CommentableAdvertisement))
+
+	comments := r.Group("/comments")
+	{
+		comments.GET("/:id/replies", commentController.GetReplies)
+		comments.PUT("/:id", commentController.UpdateComment)
+		comments.DELETE("/:id", commentController.DeleteComment)
+		comments.POST("/:id/report", commentController.ReportComment)
+		comments.DELETE("/:id/report", commentController.UnreportComment)
+	}
+
+	hashtags := r.Group("/hashtags")
+	{
+		hashtags.GET("/trending", commentController.GetTrendingHashtags)
+		hashtags.GET("/:name/comments", commentController.GetHashtagComments)
+	}
+
+	moderation := r.Group("/moderation/comments")
+	{
+		moderation.GET("", commentController.GetModerationQueue)
+		moderation.POST("/:id/approve", commentController.ApproveComment)
+		moderation.POST("/:id/remove", commentController.RemoveComment)
+	}
+}
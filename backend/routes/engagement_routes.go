@@ -0,0 +1,37 @@
+// backend/routes/engagement_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// RegisterEngagementRoutes registers routes for liking, disliking and following videos, playlists and advertisements
+func RegisterEngagementRoutes(r *gin.Engine, db *gorm.DB) {
+	engagementController := controllers.NewEngagementController(db)
+
+	for _, content := range []struct {
+		path       string
+		kind       string
+		followable bool
+	}{
+		{"/videos", models.ContentVideo, false},
+		{"/playlists", models.ContentPlaylist, true},
+		{"/advertisements", models.ContentAdvertisement, true},
+	} {
+		group := r.Group(content.path)
+		group.GET("/:id/reaction", engagementController.GetReaction(content.kind))
+		group.PUT("/:id/like", engagementController.SetReaction(content.kind, models.ReactionLike))
+		group.DELETE("/:id/like", engagementController.RemoveReaction(content.kind, models.ReactionLike))
+		group.PUT("/:id/dislike", engagementController.SetReaction(content.kind, models.ReactionDislike))
+		group.DELETE("/:id/dislike", engagementController.RemoveReaction(content.kind, models.ReactionDislike))
+		if content.followable {
+			group.PUT("/:id/follow", engagementController.SetFollowing(content.kind, true))
+			group.DELETE("/:id/follow", engagementController.SetFollowing(content.kind, false))
+		}
+	}
+}
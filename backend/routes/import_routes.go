@@ -0,0 +1,17 @@
+// backend/routes/import_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+)
+
+// RegisterImportRoutes registers routes for bulk imports
+func RegisterImportRoutes(r *gin.Engine, db *gorm.DB) {
+	importController := controllers.NewImportController(db)
+
+	r.POST("/import", importController.Import)
+}
@@ -14,6 +14,10 @@ import (
 // RegisterPlaylistRoutes registers routes related to playlists
 func RegisterPlaylistRoutes(r *gin.Engine, db *gorm.DB) {
 	playlistController := controllers.NewPlaylistController(db)
+	vastController := controllers.NewVASTController(db)
+	streamController := controllers.NewStreamController(db)
+	sessionController := controllers.NewSessionController(db)
+	feedController := controllers.NewFeedController(db)
 
 	playlists := r.Group("/playlists")
 	{
@@ -22,5 +26,17 @@ func RegisterPlaylistRoutes(r *gin.Engine, db *gorm.DB) {
 		playlists.POST("", playlistController.CreatePlaylist)
 		playlists.PUT("/:id", playlistController.UpdatePlaylist)
 		playlists.DELETE("/:id", playlistController.DeletePlaylist)
+		playlists.GET("/:id/vast", vastController.GetPlaylistVAST)
+		playlists.GET("/:id/ads/:adId/impression", vastController.TrackImpression)
+		playlists.GET("/:id/stream.m3u8", streamController.GetPlaylistStream)
+		playlists.POST("/:id/sessions", sessionController.CreateSession)
+		playlists.GET("/:id/feed.rss", feedController.GetPlaylistRSS)
+		playlists.GET("/:id/feed.json", feedController.GetPlaylistJSON)
+	}
+
+	sessions := r.Group("/sessions")
+	{
+		sessions.GET("/:id/stream.m3u8", sessionController.GetSessionStream)
+		sessions.GET("/:id/ads/:insertionId", sessionController.GetSessionAdSegment)
 	}
 }
@@ -0,0 +1,27 @@
+// backend/routes/related_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// RegisterRelatedRoutes registers routes for related videos, playlists and advertisements and their pins
+func RegisterRelatedRoutes(r *gin.Engine, db *gorm.DB) {
+	relatedController := controllers.NewRelatedController(db)
+
+	for path, contentType := range map[string]string{
+		"/videos":         models.ContentVideo,
+		"/playlists":      models.ContentPlaylist,
+		"/advertisements": models.ContentAdvertisement,
+	} {
+		group := r.Group(path)
+		group.GET("/:id/related", relatedController.GetRelated(contentType))
+		group.PUT("/:id/related/:relatedId", relatedController.PinRelated(contentType))
+		group.DELETE("/:id/related/:relatedId", relatedController.UnpinRelated(contentType))
+	}
+}
@@ -0,0 +1,16 @@
+// backend/routes/search_routes.go
+
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+	"github.com/shuttlersit/ads-player/backend/search"
+)
+
+// RegisterSearchRoutes registers the search route
+func RegisterSearchRoutes(r *gin.Engine, engine search.Engine) {
+	searchController := controllers.NewSearchController(engine)
+
+	r.GET("/search", searchController.Search)
+}
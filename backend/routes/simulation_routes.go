@@ -0,0 +1,17 @@
+// backend/routes/simulation_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+)
+
+// RegisterSimulationRoutes registers routes for scheduler simulations
+func RegisterSimulationRoutes(r *gin.Engine, db *gorm.DB) {
+	simulationController := controllers.NewSimulationController(db)
+
+	r.POST("/simulate", simulationController.PostSimulation)
+}
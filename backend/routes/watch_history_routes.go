@@ -0,0 +1,23 @@
+// backend/routes/watch_history_routes.go
+
+package routes
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/ads-player/backend/controllers"
+)
+
+// RegisterWatchHistoryRoutes registers routes for reporting playback progress and reading the watch history
+func RegisterWatchHistoryRoutes(r *gin.Engine, db *gorm.DB) {
+	watchHistoryController := controllers.NewWatchHistoryController(db)
+
+	r.PUT("/videos/:id/progress", watchHistoryController.ReportProgress)
+
+	me := r.Group("/me")
+	{
+		me.GET("/history", watchHistoryController.GetWatchHistory)
+		me.GET("/continue-watching", watchHistoryController.GetContinueWatching)
+	}
+}
@@ -0,0 +1,135 @@
+// backend/search/fts5.go
+
+package search
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrFTS5Unavailable is returned when SQLite was built without FTS5. The mattn/go-sqlite3 driver
+// includes it when built with the sqlite_fts5 tag.
+var ErrFTS5Unavailable = errors.New("search: SQLite FTS5 is not available")
+
+// fts5Table is the virtual table holding the documents of every source
+const fts5Table = "search_index"
+
+// FTS5Engine searches an SQLite FTS5 index, ranked by BM25 with titles weighing more than descriptions
+type FTS5Engine struct {
+	DB      *gorm.DB
+	Sources []Source
+}
+
+// NewFTS5Engine creates the index table if needed and fills it when it is new
+func NewFTS5Engine(db *gorm.DB, sources []Source) (*FTS5Engine, error) {
+	engine := &FTS5Engine{DB: db, Sources: sources}
+	exists := db.Migrator().HasTable(fts5Table)
+	err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS " + fts5Table +
+		" USING fts5(type UNINDEXED, id UNINDEXED, title, description, tokenize = 'unicode61 remove_diacritics 2')").Error
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			return nil, ErrFTS5Unavailable
+		}
+		return nil, err
+	}
+	if !exists {
+		if err := engine.Reindex(); err != nil {
+			return nil, err
+		}
+	}
+	return engine, nil
+}
+
+// source returns the source of a type
+func (e *FTS5Engine) source(docType string) (Source, error) {
+	sources, err := sourcesFor(e.Sources, docType)
+	if err != nil || docType == "" {
+		return Source{}, ErrUnknownType
+	}
+	return sources[0], nil
+}
+
+// Index implements Engine. Rows that are soft deleted are dropped from the index.
+func (e *FTS5Engine) Index(tx *gorm.DB, docType string, id uint) error {
+	source, err := e.source(docType)
+	if err != nil {
+		return err
+	}
+	if err := e.Remove(tx, docType, id); err != nil {
+		return err
+	}
+	return tx.Exec("INSERT INTO "+fts5Table+" (type, id, title, description) "+
+		"SELECT ?, id, COALESCE("+source.Title+", ''), COALESCE("+source.Description+", '') FROM "+source.Table+
+		" WHERE id = ? AND deleted_at IS NULL", docType, id).Error
+}
+
+// Remove implements Engine
+func (e *FTS5Engine) Remove(tx *gorm.DB, docType string, id uint) error {
+	return tx.Exec("DELETE FROM "+fts5Table+" WHERE type = ? AND id = ?", docType, id).Error
+}
+
+// Reindex implements Engine. Sources whose table does not exist yet are skipped.
+func (e *FTS5Engine) Reindex() error {
+	return e.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM " + fts5Table).Error; err != nil {
+			return err
+		}
+		for _, source := range e.Sources {
+			if !tx.Migrator().HasTable(source.Table) {
+				continue
+			}
+			if err := tx.Exec("INSERT INTO "+fts5Table+" (type, id, title, description) "+
+				"SELECT ?, id, COALESCE("+source.Title+", ''), COALESCE("+source.Description+", '') FROM "+source.Table+
+				" WHERE deleted_at IS NULL", source.Type).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Search implements Engine. Every query word has to match, the last one as a prefix so results
+// show up while typing. Documents of deleted or hidden rows are skipped.
+func (e *FTS5Engine) Search(query Query) ([]Result, int64, error) {
+	sources, err := sourcesFor(e.Sources, query.Type)
+	if err != nil {
+		return nil, 0, err
+	}
+	words := terms(query.Text)
+	if len(words) == 0 {
+		return []Result{}, 0, nil
+	}
+	phrases := make([]string, len(words))
+	for i, word := range words {
+		phrases[i] = `"` + word + `"`
+	}
+	phrases[len(phrases)-1] += "*"
+
+	conditions := make([]string, len(sources))
+	args := []interface{}{strings.Join(phrases, " ")}
+	for i, source := range sources {
+		conditions[i] = "(type = ? AND id IN (SELECT id FROM " + source.Table + " WHERE " + visibleRows(source) + "))"
+		args = append(args, source.Type)
+	}
+	where := " FROM " + fts5Table + " WHERE " + fts5Table + " MATCH ? AND (" + strings.Join(conditions, " OR ") + ")"
+
+	var total int64
+	if err := e.DB.Raw("SELECT COUNT(*)"+where, args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	results := []Result{}
+	selectArgs := append([]interface{}{matchStart, matchEnd, snippetWords}, args...)
+	selectArgs = append(selectArgs, query.Limit, query.Offset)
+	if err := e.DB.Raw("SELECT type, id, title, snippet("+fts5Table+", -1, ?, ?, '…', ?) AS snippet, "+
+		"-bm25("+fts5Table+", 0, 0, 10.0, 1.0) AS score"+where+
+		" ORDER BY score DESC, type, id LIMIT ? OFFSET ?", selectArgs...).Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+	for i := range results {
+		results[i].Snippet = markup(results[i].Snippet)
+	}
+	return results, total, nil
+}
@@ -0,0 +1,181 @@
+// backend/search/like.go
+
+package search
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// likeEscape is the LIKE escape character. A backslash is not portable, MySQL and SQLite
+// disagree on escaping it in string literals.
+const likeEscape = "!"
+
+// LikeEngine searches the content tables with LIKE queries, for databases without a full-text index.
+// It needs no index, so Index, Remove and Reindex do nothing.
+type LikeEngine struct {
+	DB      *gorm.DB
+	Sources []Source
+}
+
+// NewLikeEngine creates a LikeEngine
+func NewLikeEngine(db *gorm.DB, sources []Source) *LikeEngine {
+	return &LikeEngine{DB: db, Sources: sources}
+}
+
+// Index implements Engine
+func (e *LikeEngine) Index(tx *gorm.DB, docType string, id uint) error {
+	return nil
+}
+
+// Remove implements Engine
+func (e *LikeEngine) Remove(tx *gorm.DB, docType string, id uint) error {
+	return nil
+}
+
+// Reindex implements Engine
+func (e *LikeEngine) Reindex() error {
+	return nil
+}
+
+// Search implements Engine. Every query word has to appear in the title or description.
+// Results are ranked by the number of words found, those in the title counting double.
+func (e *LikeEngine) Search(query Query) ([]Result, int64, error) {
+	sources, err := sourcesFor(e.Sources, query.Type)
+	if err != nil {
+		return nil, 0, err
+	}
+	words := terms(query.Text)
+	if len(words) == 0 {
+		return []Result{}, 0, nil
+	}
+
+	selects := make([]string, len(sources))
+	var args []interface{}
+	for i, source := range sources {
+		title := "LOWER(COALESCE(" + source.Table + "." + source.Title + ", ''))"
+		description := "LOWER(COALESCE(" + source.Table + "." + source.Description + ", ''))"
+		like := " LIKE ? ESCAPE '" + likeEscape + "'"
+
+		scores := make([]string, len(words))
+		matches := make([]string, len(words))
+		var scoreArgs, matchArgs []interface{}
+		for j, word := range words {
+			pattern := "%" + escapeLike(word) + "%"
+			scores[j] = "(CASE WHEN " + title + like + " THEN 2 ELSE 0 END + CASE WHEN " + description + like + " THEN 1 ELSE 0 END)"
+			matches[j] = "(" + title + like + " OR " + description + like + ")"
+			scoreArgs = append(scoreArgs, pattern, pattern)
+			matchArgs = append(matchArgs, pattern, pattern)
+		}
+
+		selects[i] = "SELECT ? AS type, " + source.Table + ".id AS id, " + source.Table + "." + source.Title + " AS title, " +
+			source.Table + "." + source.Description + " AS description, " + strings.Join(scores, " + ") + " AS score " +
+			"FROM " + source.Table + " WHERE " + visibleRows(source) + " AND " + strings.Join(matches, " AND ")
+		args = append(args, source.Type)
+		args = append(args, scoreArgs...)
+		args = append(args, matchArgs...)
+	}
+	union := "(" + strings.Join(selects, " UNION ALL ") + ") AS results"
+
+	var total int64
+	if err := e.DB.Raw("SELECT COUNT(*) FROM "+union, args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		Result
+		Description string
+	}
+	if err := e.DB.Raw("SELECT * FROM "+union+" ORDER BY score DESC, type, id LIMIT ? OFFSET ?",
+		append(args, query.Limit, query.Offset)...).Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]Result, len(rows))
+	for i, row := range rows {
+		results[i] = row.Result
+		text := row.Description
+		if !containsAny(strings.ToLower(text), words) {
+			text = row.Title
+		}
+		results[i].Snippet = markup(snippet(text, words))
+	}
+	return results, total, nil
+}
+
+// escapeLike escapes the LIKE wildcards in a word
+func escapeLike(word string) string {
+	return strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_").Replace(word)
+}
+
+// containsAny reports whether text contains any of the words
+func containsAny(text string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet returns up to snippetWords words of text around the first match, with the matches between
+// matchStart and matchEnd
+func snippet(text string, words []string) string {
+	fields := strings.Fields(strings.NewReplacer(matchStart, "", matchEnd, "").Replace(text))
+	first := 0
+	for i, field := range fields {
+		if containsAny(strings.ToLower(field), words) {
+			first = i
+			break
+		}
+	}
+	start := first - snippetWords/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWords
+	if end > len(fields) {
+		end = len(fields)
+	}
+
+	parts := make([]string, 0, end-start)
+	for _, field := range fields[start:end] {
+		parts = append(parts, highlight(field, words))
+	}
+	result := strings.Join(parts, " ")
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(fields) {
+		result += "…"
+	}
+	return result
+}
+
+// highlight surrounds the matched words in a field with matchStart and matchEnd
+func highlight(field string, words []string) string {
+	lower := strings.ToLower(field)
+	if len(lower) != len(field) {
+		// Lowercasing changed the byte offsets, so matches cannot be mapped back
+		return field
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(field); {
+		matched := 0
+		for _, word := range words {
+			if strings.HasPrefix(lower[i:], word) && len(word) > matched {
+				matched = len(word)
+			}
+		}
+		if matched == 0 {
+			b.WriteByte(field[i])
+			i++
+			continue
+		}
+		b.WriteString(matchStart + field[i:i+matched] + matchEnd)
+		i += matched
+	}
+	return b.String()
+}
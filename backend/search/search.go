@@ -0,0 +1,122 @@
+// backend/search/search.go
+
+package search
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// HighlightStart and HighlightEnd surround the matched words in result snippets
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+	// MaxQueryTerms is the number of words of a query that are searched for, the rest are ignored
+	MaxQueryTerms = 10
+	// snippetWords is the number of words in a snippet
+	snippetWords = 16
+	// matchStart and matchEnd surround the matched words while a snippet is built, before it is escaped.
+	// They are private use characters, so they do not turn into markup when escaping the text.
+	matchStart = "\uE000"
+	matchEnd   = "\uE001"
+)
+
+// ErrUnknownType is returned when searching a type no source provides
+var ErrUnknownType = errors.New("search: unknown type")
+
+// Source is a table of searchable content
+type Source struct {
+	Type        string // Type of the results, e.g. "video"
+	Table       string
+	Title       string // Title column
+	Description string // Description column
+	Visible     string // Optional SQL condition rows must meet to be found, besides not being soft deleted
+}
+
+// Query is a search request
+type Query struct {
+	Text   string
+	Type   string // Only search this type of content, all types when empty
+	Offset int
+	Limit  int
+}
+
+// Result is one matching item
+type Result struct {
+	Type    string  `json:"type"`
+	ID      uint    `json:"id"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"` // HTML escaped matching text with the matched words between HighlightStart and HighlightEnd
+	Score   float64 `json:"score"`   // Relevance, higher is better
+}
+
+// markup HTML escapes a snippet and replaces its match markers with HighlightStart and HighlightEnd,
+// so clients can render it as HTML without running markup stored in the content
+func markup(snippet string) string {
+	return strings.NewReplacer(matchStart, HighlightStart, matchEnd, HighlightEnd).Replace(html.EscapeString(snippet))
+}
+
+// Engine indexes and searches content. The database engines keep their index in the tables of the
+// content, a dedicated search engine can ignore the transactions passed to Index and Remove.
+type Engine interface {
+	// Index updates the document of one item from its row, within the transaction that changed it
+	Index(tx *gorm.DB, docType string, id uint) error
+	// Remove drops the document of one item, within the transaction that deleted it
+	Remove(tx *gorm.DB, docType string, id uint) error
+	// Reindex rebuilds every document from the content tables
+	Reindex() error
+	// Search returns a page of the items matching a query, best first, and the total number of matches
+	Search(query Query) ([]Result, int64, error)
+}
+
+// New creates the best engine the database supports: FTS5 on SQLite built with the sqlite_fts5 tag,
+// LIKE queries otherwise
+func New(db *gorm.DB, sources []Source) (Engine, error) {
+	if db.Dialector.Name() == "sqlite" {
+		engine, err := NewFTS5Engine(db, sources)
+		if err == nil {
+			return engine, nil
+		}
+		if !errors.Is(err, ErrFTS5Unavailable) {
+			return nil, err
+		}
+	}
+	return NewLikeEngine(db, sources), nil
+}
+
+// terms splits a query into lowercase words, keeping at most MaxQueryTerms
+func terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > MaxQueryTerms {
+		words = words[:MaxQueryTerms]
+	}
+	return words
+}
+
+// sourcesFor returns the sources to search for a type, or every source when the type is empty
+func sourcesFor(sources []Source, docType string) ([]Source, error) {
+	if docType == "" {
+		return sources, nil
+	}
+	for _, source := range sources {
+		if source.Type == docType {
+			return []Source{source}, nil
+		}
+	}
+	return nil, ErrUnknownType
+}
+
+// visibleRows returns the condition selecting the rows of a source that can be found
+func visibleRows(source Source) string {
+	condition := source.Table + ".deleted_at IS NULL"
+	if source.Visible != "" {
+		condition += " AND (" + source.Visible + ")"
+	}
+	return condition
+}
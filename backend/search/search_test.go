@@ -0,0 +1,246 @@
+// backend/search/search_test.go
+
+package search
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testSources are two content types, one with rows hidden from search
+var testSources = []Source{
+	{Type: "doc", Table: "docs", Title: "title", Description: "description", Visible: "docs.hidden = false"},
+	{Type: "note", Table: "notes", Title: "name", Description: "body"},
+}
+
+// newTestContent opens an in-memory SQLite database holding the tables of testSources
+func newTestContent(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	statements := []string{
+		"CREATE TABLE docs (id INTEGER PRIMARY KEY, title TEXT, description TEXT, hidden BOOLEAN NOT NULL DEFAULT false, deleted_at DATETIME)",
+		"CREATE TABLE notes (id INTEGER PRIMARY KEY, name TEXT, body TEXT, deleted_at DATETIME)",
+		`INSERT INTO docs (id, title, description, hidden, deleted_at) VALUES
+			(1, 'Go concurrency patterns', 'Channels and goroutines explained', false, NULL),
+			(2, 'Cooking', 'How to go shopping for fresh pasta', false, NULL),
+			(3, 'Go go gadget', 'Hidden from search', true, NULL),
+			(4, 'Go away', 'Deleted', false, '2026-03-06 12:00:00'),
+			(5, 'Gardening', 'Nothing to see', false, NULL),
+			(6, 'Untitled', NULL, false, NULL)`,
+		`INSERT INTO notes (id, name, body, deleted_at) VALUES
+			(1, '<b>Go</b> notes', '<script>alert("go")</script> & more', NULL)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("creating content: %v", err)
+		}
+	}
+	return db
+}
+
+// testEngines returns the engines to test on db: the LIKE engine and, when SQLite has it, the FTS5 engine
+func testEngines(t *testing.T, db *gorm.DB) map[string]Engine {
+	t.Helper()
+	engines := map[string]Engine{"like": NewLikeEngine(db, testSources)}
+	fts5, err := NewFTS5Engine(db, testSources)
+	switch {
+	case errors.Is(err, ErrFTS5Unavailable):
+		t.Log("SQLite is built without FTS5, only the LIKE engine is tested; build with -tags sqlite_fts5 to test both")
+	case err != nil:
+		t.Fatalf("NewFTS5Engine: %v", err)
+	default:
+		engines["fts5"] = fts5
+	}
+	return engines
+}
+
+// resultKeys returns "type:id" for each result, in order
+func resultKeys(results []Result) []string {
+	keys := []string{}
+	for _, result := range results {
+		keys = append(keys, fmt.Sprintf("%s:%d", result.Type, result.ID))
+	}
+	return keys
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Go Concurrency", []string{"go", "concurrency"}},
+		{"  rock'n'roll, 1970s!  ", []string{"rock", "n", "roll", "1970s"}},
+		{"100% _off_ \"pasta\"*", []string{"100", "off", "pasta"}},
+		{"Crème brûlée", []string{"crème", "brûlée"}},
+		{"a b c d e f g h i j k l", []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}},
+		{"?!", []string{}},
+	}
+	for _, test := range tests {
+		got := terms(test.text)
+		if got == nil {
+			got = []string{}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("terms(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestSnippetEscapesContent(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		words []string
+		want  string
+	}{
+		{"markup", `<b onclick="go()">Go</b> home`, []string{"go"},
+			"&lt;b onclick=&#34;<mark>go</mark>()&#34;&gt;<mark>Go</mark>&lt;/b&gt; home"},
+		{"stored match markers", "before go after", []string{"after"}, "before go <mark>after</mark>"},
+		{"entities", "fish & chips", []string{"chips"}, "fish &amp; <mark>chips</mark>"},
+		{"longest word", "gopher", []string{"go", "goph"}, "<mark>goph</mark>er"},
+		{"changed length when lowercased", "İstanbul", []string{"stan"}, "İstanbul"},
+		{"window", "one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen sixteen seventeen eighteen nineteen twenty",
+			[]string{"ten"}, "…six seven eight nine <mark>ten</mark> eleven twelve thirteen fourteen fifteen sixteen seventeen eighteen nineteen twenty"},
+	}
+	for _, test := range tests {
+		if got := markup(snippet(test.text, test.words)); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got, want := escapeLike("50%_off!"), "50!%!_off!!"; got != want {
+		t.Errorf("escapeLike got %q, want %q", got, want)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	db := newTestContent(t)
+	for name, engine := range testEngines(t, db) {
+		tests := []struct {
+			name  string
+			query Query
+			want  []string // Results in order
+			total int64
+		}{
+			{"title before description", Query{Text: "go", Limit: 10}, []string{"doc:1", "note:1", "doc:2"}, 3},
+			{"every word", Query{Text: "go pasta", Limit: 10}, []string{"doc:2"}, 1},
+			{"prefix of the last word", Query{Text: "concurr", Limit: 10}, []string{"doc:1"}, 1},
+			{"one type", Query{Text: "go", Type: "note", Limit: 10}, []string{"note:1"}, 1},
+			{"page", Query{Text: "go", Offset: 2, Limit: 1}, []string{"doc:2"}, 3},
+			{"no match", Query{Text: "zebra", Limit: 10}, []string{}, 0},
+			{"no words", Query{Text: "!!", Limit: 10}, []string{}, 0},
+		}
+		for _, test := range tests {
+			results, total, err := engine.Search(test.query)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", name, test.name, err)
+			}
+			got := resultKeys(results)
+			if test.name == "title before description" && len(got) == 3 {
+				// Both title matches rank first, in an order each engine decides
+				sort.Strings(got[:2])
+			}
+			if total != test.total || !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s: %s: got %q of %d, want %q of %d", name, test.name, got, total, test.want, test.total)
+			}
+			for i := 1; i < len(results); i++ {
+				if results[i].Score > results[i-1].Score {
+					t.Errorf("%s: %s: result %d scores %g, above %g", name, test.name, i, results[i].Score, results[i-1].Score)
+				}
+			}
+		}
+
+		if _, _, err := engine.Search(Query{Text: "go", Type: "video", Limit: 10}); !errors.Is(err, ErrUnknownType) {
+			t.Errorf("%s: searching an unknown type got %v, want ErrUnknownType", name, err)
+		}
+
+		results, _, err := engine.Search(Query{Text: "go", Type: "note", Limit: 10})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		// Whichever field the snippet comes from, only the highlights are markup
+		if len(results) != 1 {
+			t.Fatalf("%s: got %d notes, want 1", name, len(results))
+		}
+		text := strings.NewReplacer(HighlightStart, "", HighlightEnd, "").Replace(results[0].Snippet)
+		if strings.ContainsAny(text, "<>") || !strings.Contains(text, "&lt;") || !strings.Contains(results[0].Snippet, HighlightStart) {
+			t.Errorf("%s: snippet %q is not escaped and highlighted", name, results[0].Snippet)
+		}
+	}
+}
+
+func TestFTS5IndexFollowsChanges(t *testing.T) {
+	db := newTestContent(t)
+	engine, err := NewFTS5Engine(db, testSources)
+	if errors.Is(err, ErrFTS5Unavailable) {
+		t.Skip("SQLite is built without FTS5, build with -tags sqlite_fts5")
+	}
+	if err != nil {
+		t.Fatalf("NewFTS5Engine: %v", err)
+	}
+
+	find := func(text string) []string {
+		t.Helper()
+		results, _, err := engine.Search(Query{Text: text, Limit: 10})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		return resultKeys(results)
+	}
+	if err := db.Exec("UPDATE docs SET title = 'Vegetable patch' WHERE id = 5").Error; err != nil {
+		t.Fatalf("updating doc: %v", err)
+	}
+	if got := find("vegetable"); len(got) != 0 {
+		t.Errorf("found %q before indexing the change", got)
+	}
+	if err := engine.Index(db, "doc", 5); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if got := find("vegetable"); !reflect.DeepEqual(got, []string{"doc:5"}) {
+		t.Errorf("found %q after indexing, want doc:5", got)
+	}
+	if got := find("gardening"); len(got) != 0 {
+		t.Errorf("found %q by the replaced text", got)
+	}
+
+	if err := engine.Remove(db, "doc", 5); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if got := find("vegetable"); len(got) != 0 {
+		t.Errorf("found %q after removing", got)
+	}
+	if err := engine.Reindex(); err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if got := find("vegetable"); !reflect.DeepEqual(got, []string{"doc:5"}) {
+		t.Errorf("found %q after reindexing, want doc:5", got)
+	}
+	// Documents of rows deleted without a hook are skipped
+	if err := db.Exec("UPDATE docs SET deleted_at = '2026-03-06 12:00:00' WHERE id = 5").Error; err != nil {
+		t.Fatalf("deleting doc: %v", err)
+	}
+	if got := find("vegetable"); len(got) != 0 {
+		t.Errorf("found %q after deleting the row", got)
+	}
+}
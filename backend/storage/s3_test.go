@@ -0,0 +1,220 @@
+// backend/storage/s3_test.go
+
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// standInObject is an object stored by the S3 stand-in
+type standInObject struct {
+	body        []byte
+	contentType string
+}
+
+// s3StandIn is a local stand-in for an S3 bucket. It checks the AWS Signature V4 of every request
+// the way S3 does and keeps objects in memory, by path.
+type s3StandIn struct {
+	t               *testing.T
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+
+	mu      sync.Mutex
+	objects map[string]standInObject
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.verify(r); err != nil {
+		s.t.Logf("rejected %s %s: %v", r.Method, r.URL, err)
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := r.URL.EscapedPath()
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(body)) != r.ContentLength {
+			http.Error(w, "IncompleteBody", http.StatusBadRequest)
+			return
+		}
+		s.objects[path] = standInObject{body: body, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		object, ok := s.objects[path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Write(object.body)
+	case http.MethodDelete:
+		delete(s.objects, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify recomputes the Signature V4 of a request from what was received and compares it with its Authorization header
+func (s *s3StandIn) verify(r *http.Request) error {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 ") {
+		return errors.New("missing signature")
+	}
+	fields := map[string]string{}
+	for _, field := range strings.Split(strings.TrimPrefix(authorization, "AWS4-HMAC-SHA256 "), ", ") {
+		name, value, _ := strings.Cut(field, "=")
+		fields[name] = value
+	}
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[0] != s.accessKeyID || credential[2] != s.region ||
+		credential[3] != "s3" || credential[4] != "aws4_request" {
+		return errors.New("bad credential scope " + fields["Credential"])
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if !strings.HasPrefix(amzDate, credential[1]) {
+		return errors.New("date does not match the credential scope")
+	}
+
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	if !sort.StringsAreSorted(signedHeaders) {
+		return errors.New("signed headers are not sorted")
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	for _, required := range []string{"host", "x-amz-content-sha256", "x-amz-date"} {
+		if !strings.Contains(";"+fields["SignedHeaders"]+";", ";"+required+";") {
+			return errors.New(required + " is not signed")
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		fields["SignedHeaders"],
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + strings.Join(credential[1:], "/") + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.secretAccessKey)
+	for _, part := range []string{credential[1], s.region, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	if !hmac.Equal([]byte(hex.EncodeToString(key)), []byte(fields["Signature"])) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// newS3StandIn starts an S3 stand-in and returns a storage using it, its clock fixed
+func newS3StandIn(t *testing.T, config S3Config) (*s3StandIn, *S3Storage) {
+	t.Helper()
+	standIn := &s3StandIn{
+		t:               t,
+		region:          "eu-west-1",
+		accessKeyID:     "AKIDEXAMPLE",
+		secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		objects:         map[string]standInObject{},
+	}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+
+	config.Endpoint = server.URL + "/"
+	config.Region = standIn.region
+	config.Bucket = "creatives"
+	config.AccessKeyID = standIn.accessKeyID
+	if config.SecretAccessKey == "" {
+		config.SecretAccessKey = standIn.secretAccessKey
+	}
+	store, err := NewS3Storage(config)
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	store.now = func() time.Time { return time.Date(2026, 3, 6, 12, 30, 0, 0, time.FixedZone("CET", 3600)) }
+	return standIn, store
+}
+
+func TestS3StoragePutOpenDelete(t *testing.T) {
+	standIn, store := newS3StandIn(t, S3Config{})
+	ctx := context.Background()
+	key := "creatives/spring sale+1.mp4"
+	body := "not really a video"
+
+	if err := store.Put(ctx, key, strings.NewReader(body), int64(len(body)), "video/mp4"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	object, ok := standIn.objects["/creatives/creatives/spring%20sale%2B1.mp4"]
+	if !ok {
+		t.Fatalf("object not stored under its escaped path, stored: %v", standIn.objects)
+	}
+	if string(object.body) != body || object.contentType != "video/mp4" {
+		t.Errorf("stored %q as %q, want %q as video/mp4", object.body, object.contentType, body)
+	}
+
+	reader, err := store.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	read, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(read) != body {
+		t.Errorf("Open read %q, %v, want %q", read, err, body)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete got %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing object got %v, want nil", err)
+	}
+}
+
+func TestS3StorageRejectedSignature(t *testing.T) {
+	_, store := newS3StandIn(t, S3Config{SecretAccessKey: "wrong"})
+	err := store.Put(context.Background(), "creatives/ad.mp4", strings.NewReader("x"), 1, "video/mp4")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put with a wrong secret got %v, want a 403 error", err)
+	}
+}
+
+func TestS3StorageURL(t *testing.T) {
+	_, store := newS3StandIn(t, S3Config{})
+	endpoint := store.Config.Endpoint
+	if got, want := store.URL("creatives/spring sale.mp4"), endpoint+"/creatives/creatives/spring%20sale.mp4"; got != want {
+		t.Errorf("URL is %q, want %q", got, want)
+	}
+
+	_, public := newS3StandIn(t, S3Config{PublicURL: "https://cdn.example.com/ads/"})
+	if got, want := public.URL("creatives/a+b.mp4"), "https://cdn.example.com/ads/creatives/a%2Bb.mp4"; got != want {
+		t.Errorf("URL with a public URL is %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+// backend/storage/storage.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrNotFound is returned when a stored object does not exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage stores uploaded creative files
+type Storage interface {
+	// Put stores body under key, replacing any existing object
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Open returns a reader for the object stored under key
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key
+	Delete(ctx context.Context, key string) error
+	// URL returns the address players should fetch the object from
+	URL(key string) string
+}
+
+// NewFromEnv creates the storage configured by the environment.
+// CREATIVE_STORAGE selects "local" (default) or "s3"; see NewLocalStorage and NewS3Storage for their settings.
+func NewFromEnv() (Storage, error) {
+	switch os.Getenv("CREATIVE_STORAGE") {
+	case "", "local":
+		return NewLocalStorage(getenv("CREATIVE_STORAGE_DIR", "uploads"), getenv("CREATIVE_BASE_URL", "/uploads"))
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          getenv("S3_REGION", "us-east-1"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		})
+	default:
+		return nil, errors.New("storage: CREATIVE_STORAGE must be \"local\" or \"s3\"")
+	}
+}
+
+// getenv returns an environment variable or a fallback when it is unset
+func getenv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,101 @@
+// backend/targeting/ast.go
+
+package targeting
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node is a node of a parsed targeting expression
+type Node interface {
+	// Eval evaluates the node against the attributes of a placement
+	Eval(ctx Context) bool
+	String() string
+}
+
+// AndNode is true when both sides are true
+type AndNode struct {
+	Left, Right Node
+}
+
+// OrNode is true when either side is true
+type OrNode struct {
+	Left, Right Node
+}
+
+// NotNode negates its operand
+type NotNode struct {
+	Operand Node
+}
+
+// CompareNode compares a field against one or more values.
+// Op is "=", "!=", "in" or ":" (the field contains the value, e.g. tag:sports).
+type CompareNode struct {
+	Field  string
+	Op     string
+	Values []string
+}
+
+// Eval implements Node
+func (n *AndNode) Eval(ctx Context) bool {
+	return n.Left.Eval(ctx) && n.Right.Eval(ctx)
+}
+
+// Eval implements Node
+func (n *OrNode) Eval(ctx Context) bool {
+	return n.Left.Eval(ctx) || n.Right.Eval(ctx)
+}
+
+// Eval implements Node
+func (n *NotNode) Eval(ctx Context) bool {
+	return !n.Operand.Eval(ctx)
+}
+
+// Eval implements Node. Fields may hold several values (a playlist has many tags),
+// so "=", "in" and ":" match when any value matches and "!=" when none does.
+func (n *CompareNode) Eval(ctx Context) bool {
+	matched := false
+	for _, actual := range ctx[n.Field] {
+		for _, expected := range n.Values {
+			if strings.EqualFold(actual, expected) {
+				matched = true
+			}
+		}
+	}
+	if n.Op == "!=" {
+		return !matched
+	}
+	return matched
+}
+
+// String implements Node
+func (n *AndNode) String() string {
+	return fmt.Sprintf("(%s AND %s)", n.Left, n.Right)
+}
+
+// String implements Node
+func (n *OrNode) String() string {
+	return fmt.Sprintf("(%s OR %s)", n.Left, n.Right)
+}
+
+// String implements Node
+func (n *NotNode) String() string {
+	return fmt.Sprintf("NOT %s", n.Operand)
+}
+
+// String implements Node
+func (n *CompareNode) String() string {
+	quoted := make([]string, len(n.Values))
+	for i, value := range n.Values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	switch n.Op {
+	case "in":
+		return fmt.Sprintf("%s in (%s)", n.Field, strings.Join(quoted, ", "))
+	case ":":
+		return fmt.Sprintf("%s:%s", n.Field, quoted[0])
+	default:
+		return fmt.Sprintf("%s %s %s", n.Field, n.Op, quoted[0])
+	}
+}
@@ -0,0 +1,16 @@
+// backend/targeting/context.go
+
+package targeting
+
+// Context holds the attributes of a placement (playlist, video, location) that expressions are evaluated against.
+// Each field may have several values, for example one per tag.
+type Context map[string][]string
+
+// Add appends non-empty values to a field
+func (ctx Context) Add(field string, values ...string) {
+	for _, value := range values {
+		if value != "" {
+			ctx[field] = append(ctx[field], value)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// backend/targeting/lexer_test.go
+
+package targeting
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []token
+	}{
+		{"", []token{{kind: tokenEOF}}},
+		{`language in ("en","fr")`, []token{
+			{tokenIdent, "language", 0}, {tokenIn, "in", 9}, {tokenLParen, "(", 12}, {tokenString, "en", 13},
+			{tokenComma, ",", 17}, {tokenString, "fr", 18}, {tokenRParen, ")", 22}, {tokenEOF, "", 23},
+		}},
+		{"NOT tag:sports-news And city != new_york.1", []token{
+			{tokenNot, "NOT", 0}, {tokenIdent, "tag", 4}, {tokenColon, ":", 7}, {tokenIdent, "sports-news", 8},
+			{tokenAnd, "And", 20}, {tokenIdent, "city", 24}, {tokenNotEquals, "!=", 29}, {tokenIdent, "new_york.1", 32}, {tokenEOF, "", 42},
+		}},
+		{`category = "say \"hi\"" or région=Île`, []token{
+			{tokenIdent, "category", 0}, {tokenEquals, "=", 9}, {tokenString, `say "hi"`, 11},
+			{tokenOr, "or", 24}, {tokenIdent, "région", 27}, {tokenEquals, "=", 33}, {tokenIdent, "Île", 34}, {tokenEOF, "", 37},
+		}},
+	}
+	for _, test := range tests {
+		got, err := lex(test.input)
+		if err != nil {
+			t.Errorf("lex(%q): %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("lex(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestLexErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{`tag ! "x"`, 4},
+		{`tag = "open`, 6},
+		{"tag = a; city = b", 7},
+		{"tag = 'x'", 6},
+	}
+	for _, test := range tests {
+		_, err := lex(test.input)
+		var syntaxError *SyntaxError
+		if !errors.As(err, &syntaxError) || syntaxError.Pos != test.pos {
+			t.Errorf("lex(%q) got %v, want a syntax error at position %d", test.input, err, test.pos)
+		}
+	}
+}
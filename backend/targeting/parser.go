@@ -0,0 +1,255 @@
+// backend/targeting/parser.go
+
+package targeting
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxDepth bounds how deeply NOT and parentheses may nest in an expression
+	maxDepth = 32
+	// maxCachedExpressions bounds the parse cache; it is emptied when full
+	maxCachedExpressions = 1024
+)
+
+// Fields lists the placement attributes an expression may refer to
+var Fields = map[string]bool{
+	"language": true,
+	"category": true,
+	"tag":      true,
+	"country":  true,
+	"region":   true,
+	"city":     true,
+}
+
+// SyntaxError reports an invalid targeting expression
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+// Error implements error
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("targeting expression: %s at position %d", e.Msg, e.Pos)
+}
+
+// Parse parses a targeting expression such as
+//
+//	language in ("en","fr") AND NOT category = "kids" AND tag:sports
+//
+// into an AST. Keywords are case-insensitive and AND binds tighter than OR.
+func Parse(expression string) (Node, error) {
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: next.pos, Msg: fmt.Sprintf("unexpected %s", next)}
+	}
+	return node, nil
+}
+
+// parseCache holds the outcome of parsing each expression recently validated or matched, so that
+// advertisements are not parsed again for every placement
+var parseCache = struct {
+	sync.Mutex
+	parsed map[string]parseResult
+}{parsed: map[string]parseResult{}}
+
+// parseResult is a cached outcome of Parse
+type parseResult struct {
+	node Node
+	err  error
+}
+
+// parseCached parses an expression, reusing the outcome of an earlier parse of the same expression
+func parseCached(expression string) (Node, error) {
+	parseCache.Lock()
+	result, ok := parseCache.parsed[expression]
+	parseCache.Unlock()
+	if ok {
+		return result.node, result.err
+	}
+
+	node, err := Parse(expression)
+	parseCache.Lock()
+	if len(parseCache.parsed) >= maxCachedExpressions {
+		parseCache.parsed = map[string]parseResult{}
+	}
+	parseCache.parsed[expression] = parseResult{node: node, err: err}
+	parseCache.Unlock()
+	return node, err
+}
+
+// Validate checks that an expression parses. An empty expression is valid and matches everything.
+func Validate(expression string) error {
+	if strings.TrimSpace(expression) == "" {
+		return nil
+	}
+	_, err := parseCached(expression)
+	return err
+}
+
+// Match evaluates an expression against a context, parsing it unless it was recently validated or matched.
+// An empty expression matches everything.
+func Match(expression string, ctx Context) (bool, error) {
+	if strings.TrimSpace(expression) == "" {
+		return true, nil
+	}
+	node, err := parseCached(expression)
+	if err != nil {
+		return false, err
+	}
+	return node.Eval(ctx), nil
+}
+
+// parser is a recursive descent parser over a token stream
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected %s, found %s", what, t)}
+	}
+	return t, nil
+}
+
+// parseOr parses: and ( OR and )*. depth is how many NOT and parentheses enclose it.
+func (p *parser) parseOr(depth int) (Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &OrNode{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary ( AND unary )*
+func (p *parser) parseAnd(depth int) (Node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &AndNode{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseUnary parses: NOT unary | '(' or ')' | comparison
+func (p *parser) parseUnary(depth int) (Node, error) {
+	if kind := p.peek().kind; (kind == tokenNot || kind == tokenLParen) && depth >= maxDepth {
+		return nil, &SyntaxError{Pos: p.peek().pos, Msg: fmt.Sprintf("expression nested more than %d deep", maxDepth)}
+	}
+
+	switch p.peek().kind {
+	case tokenNot:
+		p.next()
+		operand, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &NotNode{Operand: operand}, nil
+	case tokenLParen:
+		p.next()
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+// parseComparison parses: field ( '=' | '!=' | ':' ) value | field IN '(' value ( ',' value )* ')'
+func (p *parser) parseComparison() (Node, error) {
+	field, err := p.expect(tokenIdent, "field name")
+	if err != nil {
+		return nil, err
+	}
+	name := strings.ToLower(field.text)
+	if !Fields[name] {
+		return nil, &SyntaxError{Pos: field.pos, Msg: fmt.Sprintf("unknown field %q", field.text)}
+	}
+
+	op := p.next()
+	switch op.kind {
+	case tokenEquals, tokenNotEquals, tokenColon:
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return &CompareNode{Field: name, Op: op.text, Values: []string{value}}, nil
+	case tokenIn:
+		if _, err := p.expect(tokenLParen, "'('"); err != nil {
+			return nil, err
+		}
+		var values []string
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+		if _, err := p.expect(tokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return &CompareNode{Field: name, Op: "in", Values: values}, nil
+	default:
+		return nil, &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("expected '=', '!=', ':' or IN after %s, found %s", field.text, op)}
+	}
+}
+
+// parseValue parses a quoted string or a bare word
+func (p *parser) parseValue() (string, error) {
+	t := p.next()
+	if t.kind != tokenString && t.kind != tokenIdent {
+		return "", &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected value, found %s", t)}
+	}
+	return t.text, nil
+}
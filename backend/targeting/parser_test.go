@@ -0,0 +1,156 @@
+// backend/targeting/parser_test.go
+
+package targeting
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"tag:a", `tag:"a"`},
+		{"tag:a OR tag:b AND tag:c", `(tag:"a" OR (tag:"b" AND tag:"c"))`},
+		{"tag:a AND tag:b OR tag:c", `((tag:"a" AND tag:"b") OR tag:"c")`},
+		{"(tag:a OR tag:b) AND tag:c", `((tag:"a" OR tag:"b") AND tag:"c")`},
+		{"NOT tag:a AND tag:b", `(NOT tag:"a" AND tag:"b")`},
+		{"NOT (tag:a AND tag:b)", `NOT (tag:"a" AND tag:"b")`},
+		{"not not Language = EN", `NOT NOT language = "EN"`},
+		{"tag:a or tag:b or tag:c", `((tag:"a" OR tag:"b") OR tag:"c")`},
+		{`country in (FR, "DE") and city != Paris`, `(country in ("FR", "DE") AND city != "Paris")`},
+		{"((((region = north))))", `region = "north"`},
+	}
+	for _, test := range tests {
+		node, err := Parse(test.expression)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.expression, err)
+			continue
+		}
+		if got := node.String(); got != test.want {
+			t.Errorf("Parse(%q) = %s, want %s", test.expression, got, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		pos        int
+		message    string
+	}{
+		{"colour = red", 0, `unknown field "colour"`},
+		{"tag", 3, "expected '=', '!=', ':' or IN after tag, found end of expression"},
+		{"tag = ", 6, "expected value, found end of expression"},
+		{"(tag:a OR tag:b", 15, "expected ')', found end of expression"},
+		{"tag:a tag:b", 6, "unexpected 'tag'"},
+		{"tag:a AND", 9, "expected field name, found end of expression"},
+		{"language in ()", 13, "expected value, found ')'"},
+		{"language in (en fr)", 16, "expected ')', found 'fr'"},
+		{"NOT AND tag:a", 4, "expected field name, found 'AND'"},
+		{") tag:a", 0, "expected field name, found ')'"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.expression)
+		var syntaxError *SyntaxError
+		if !errors.As(err, &syntaxError) {
+			t.Errorf("Parse(%q) got %v, want a syntax error", test.expression, err)
+			continue
+		}
+		if syntaxError.Pos != test.pos || syntaxError.Msg != test.message {
+			t.Errorf("Parse(%q) got %q at %d, want %q at %d", test.expression, syntaxError.Msg, syntaxError.Pos, test.message, test.pos)
+		}
+	}
+}
+
+func TestParseDepth(t *testing.T) {
+	nested := func(open, close string, depth int) string {
+		return strings.Repeat(open, depth) + "tag:a" + strings.Repeat(close, depth)
+	}
+	for _, expression := range []string{nested("(", ")", maxDepth), nested("NOT ", "", maxDepth), nested("NOT (", ")", maxDepth/2)} {
+		if _, err := Parse(expression); err != nil {
+			t.Errorf("Parse of an expression nested %d deep: %v", maxDepth, err)
+		}
+	}
+	tooDeep := []struct {
+		expression string
+		pos        int
+	}{
+		{nested("(", ")", maxDepth+1), maxDepth},
+		{nested("NOT ", "", maxDepth+1), maxDepth * len("NOT ")},
+		{nested("(", ")", 100000), maxDepth},
+	}
+	for _, test := range tooDeep {
+		_, err := Parse(test.expression)
+		var syntaxError *SyntaxError
+		if !errors.As(err, &syntaxError) || syntaxError.Pos != test.pos || !strings.Contains(syntaxError.Msg, "nested") {
+			t.Errorf("Parse of an expression %d characters long got %v, want a nesting error at %d", len(test.expression), err, test.pos)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	ctx := Context{}
+	ctx.Add("language", "en")
+	ctx.Add("tag", "sports", "Football", "")
+	ctx.Add("category", "news")
+	ctx.Add("country", "FR")
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{"", true},
+		{"   ", true},
+		{"language = en", true},
+		{"language = EN", true},
+		{"language != en", false},
+		{`language in ("fr", "en")`, true},
+		{`language in ("fr", "de")`, false},
+		{"tag:football", true},
+		{"tag:tennis", false},
+		{"tag != tennis", true},
+		{"tag != sports", false},
+		{"city = Paris", false},
+		{"city != Paris", true},
+		{"category = kids OR tag:sports AND country = FR", true},
+		{"(category = kids OR tag:sports) AND country = DE", false},
+		{"NOT category = kids AND language = en", true},
+		{"NOT (category = news AND language = en)", false},
+	}
+	for _, test := range tests {
+		got, err := Match(test.expression, ctx)
+		if err != nil {
+			t.Errorf("Match(%q): %v", test.expression, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Match(%q) = %v, want %v", test.expression, got, test.want)
+		}
+	}
+
+	if _, err := Match("colour = red", ctx); err == nil {
+		t.Error("Match of an invalid expression succeeded")
+	}
+}
+
+func TestParseCache(t *testing.T) {
+	expression := "tag:cached AND language = en"
+	if err := Validate(expression); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	validated, _ := parseCached(expression)
+	matched, _ := parseCached(expression)
+	if validated == nil || validated != matched {
+		t.Error("an expression validated once is parsed again")
+	}
+
+	invalid := "tag:cached AND"
+	first, second := Validate(invalid), Validate(invalid)
+	if first == nil || first != second {
+		t.Errorf("an invalid expression got %v, then %v, want the same cached error", first, second)
+	}
+}
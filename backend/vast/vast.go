@@ -0,0 +1,139 @@
+// backend/vast/vast.go
+
+package vast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/shuttlersit/ads-player/backend/models"
+)
+
+// Version is the VAST version produced by this package
+const Version = "4.0"
+
+// VAST is the root element of a VAST response
+type VAST struct {
+	XMLName xml.Name `xml:"VAST"`
+	Version string   `xml:"version,attr"`
+	Ads     []Ad     `xml:"Ad"`
+}
+
+// Ad is a single advertisement; Sequence orders ads within an ad pod
+type Ad struct {
+	ID       string `xml:"id,attr"`
+	Sequence int    `xml:"sequence,attr,omitempty"`
+	InLine   InLine `xml:"InLine"`
+}
+
+// InLine holds everything a player needs to play an ad
+type InLine struct {
+	AdSystem    string     `xml:"AdSystem"`
+	AdTitle     string     `xml:"AdTitle"`
+	Description string     `xml:"Description,omitempty"`
+	Impressions []CDATA    `xml:"Impression"`
+	Creatives   []Creative `xml:"Creatives>Creative"`
+}
+
+// Creative wraps a linear (video) creative
+type Creative struct {
+	ID     string `xml:"id,attr"`
+	Linear Linear `xml:"Linear"`
+}
+
+// Linear describes a video creative
+type Linear struct {
+	Duration     string      `xml:"Duration"`
+	MediaFiles   []MediaFile `xml:"MediaFiles>MediaFile"`
+	ClickThrough *CDATA      `xml:"VideoClicks>ClickThrough,omitempty"`
+}
+
+// MediaFile is a playable rendition of a creative
+type MediaFile struct {
+	Delivery string `xml:"delivery,attr"`
+	Type     string `xml:"type,attr"`
+	Width    int    `xml:"width,attr"`
+	Height   int    `xml:"height,attr"`
+	URL      string `xml:",cdata"`
+}
+
+// CDATA is an element whose text is written as a CDATA section
+type CDATA struct {
+	Text string `xml:",cdata"`
+}
+
+// NewAdPod converts an ad pod into a VAST document with one sequenced Ad per advertisement.
+// impressionURL returns the URL players call when an advertisement starts, which VAST requires for every ad.
+// An empty pod produces an empty VAST document, which tells the player there is nothing to play.
+func NewAdPod(pod models.AdPod, impressionURL func(models.Advertisement) string) *VAST {
+	document := &VAST{Version: Version, Ads: []Ad{}}
+	for i, advertisement := range pod.Advertisements {
+		ad := newAd(advertisement, impressionURL(advertisement))
+		if len(pod.Advertisements) > 1 {
+			ad.Sequence = i + 1
+		}
+		document.Ads = append(document.Ads, ad)
+	}
+	return document
+}
+
+// Marshal renders the document with an XML header
+func (v *VAST) Marshal() ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// newAd converts a single advertisement into a VAST ad tracking its impression at impressionURL
+func newAd(advertisement models.Advertisement, impressionURL string) Ad {
+	id := strconv.FormatUint(uint64(advertisement.ID), 10)
+
+	linear := Linear{
+		Duration: FormatDuration(advertisement.Duration),
+		MediaFiles: []MediaFile{{
+			Delivery: "progressive",
+			Type:     mediaType(advertisement),
+			Width:    advertisement.Probe.Width,
+			Height:   advertisement.Probe.Height,
+			URL:      advertisement.ContentURL,
+		}},
+	}
+	if advertisement.ClickThroughURL != "" {
+		linear.ClickThrough = &CDATA{Text: advertisement.ClickThroughURL}
+	}
+
+	return Ad{
+		ID: id,
+		InLine: InLine{
+			AdSystem:    "ads-player",
+			AdTitle:     advertisement.Title,
+			Description: advertisement.Description,
+			Impressions: []CDATA{{Text: impressionURL}},
+			Creatives:   []Creative{{ID: id, Linear: linear}},
+		},
+	}
+}
+
+// FormatDuration formats seconds as the HH:MM:SS duration VAST expects
+func FormatDuration(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+}
+
+// mediaType returns a creative's MIME type, guessing from its URL when it was not uploaded
+func mediaType(advertisement models.Advertisement) string {
+	if advertisement.Creative.ContentType != "" {
+		return advertisement.Creative.ContentType
+	}
+	if parsed, err := url.Parse(advertisement.ContentURL); err == nil {
+		if contentType := mime.TypeByExtension(path.Ext(parsed.Path)); contentType != "" {
+			return contentType
+		}
+	}
+	return "video/mp4"
+}